@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/examples"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/spf13/cobra"
+)
+
+// defaultExampleChars is the length of the document excerpt stored by examples add
+const defaultExampleChars = 3000
+
+// examplesCmd represents the examples command
+var examplesCmd = &cobra.Command{
+	Use:   "examples",
+	Short: "Curate the few-shot examples included in the prompt",
+	Long: `Curate few-shot examples: excerpts of documents together with their corrected extraction.
+
+When examples.dir is set in the config, extract includes the most relevant examples in the
+prompt: first those whose vendor is named in the document, then those with the most similar
+text, as many as fit into examples.max_examples and examples.token_budget.`,
+}
+
+// examplesAddCmd represents the examples add command
+var examplesAddCmd = &cobra.Command{
+	Use:   "add",
+	Short: "Add a document and its corrected JSON as an example",
+	Long: `Add an example from a source document and the JSON extracted from it, after correcting
+the JSON by hand. The first --max-chars characters of the document are stored as excerpt.
+The example is named after the JSON file unless --name is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(logger)
+		if err != nil {
+			return err
+		}
+		dir, err := examplesDir(cmd, cfg)
+		if err != nil {
+			return err
+		}
+
+		inputFile, _ := cmd.Flags().GetString("input")
+		jsonFile, _ := cmd.Flags().GetString("json")
+		name, _ := cmd.Flags().GetString("name")
+		vendor, _ := cmd.Flags().GetString("vendor")
+		aliases, _ := cmd.Flags().GetStringSlice("alias")
+		note, _ := cmd.Flags().GetString("note")
+		maxChars, _ := cmd.Flags().GetInt("max-chars")
+		force, _ := cmd.Flags().GetBool("force")
+
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(jsonFile), filepath.Ext(jsonFile))
+		}
+		example := &examples.Example{Name: name, Vendor: vendor, Aliases: aliases, Note: note}
+		return runExamplesAdd(dir, inputFile, jsonFile, example, maxChars, force, logger)
+	},
+}
+
+// examplesListCmd represents the examples list command
+var examplesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the examples with their vendor and estimated size",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(logger)
+		if err != nil {
+			return err
+		}
+		dir, err := examplesDir(cmd, cfg)
+		if err != nil {
+			return err
+		}
+		return runExamplesList(cfg, dir, logger)
+	},
+}
+
+// examplesSelectCmd represents the examples select command
+var examplesSelectCmd = &cobra.Command{
+	Use:   "select",
+	Short: "Show which examples would be included in the prompt for a document",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(logger)
+		if err != nil {
+			return err
+		}
+		dir, err := examplesDir(cmd, cfg)
+		if err != nil {
+			return err
+		}
+		inputFile, _ := cmd.Flags().GetString("input")
+		return runExamplesSelect(cfg, dir, inputFile, logger)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(examplesCmd)
+	examplesCmd.AddCommand(examplesAddCmd)
+	examplesCmd.AddCommand(examplesListCmd)
+	examplesCmd.AddCommand(examplesSelectCmd)
+	examplesCmd.PersistentFlags().String("dir", "", "Examples directory (default examples.dir of the config)")
+
+	examplesAddCmd.Flags().StringP("input", "i", "", "Path to the source document (required)")
+	examplesAddCmd.Flags().StringP("json", "j", "", "Path to the corrected JSON of the document (required)")
+	examplesAddCmd.Flags().String("name", "", "Name of the example (default the JSON file name)")
+	examplesAddCmd.Flags().String("vendor", "", "Vendor the example is for (default the company of the JSON)")
+	examplesAddCmd.Flags().StringSlice("alias", nil, "Other spelling of the vendor, can be repeated")
+	examplesAddCmd.Flags().String("note", "", "Why the example was added")
+	examplesAddCmd.Flags().Int("max-chars", defaultExampleChars, "Maximum length of the document excerpt")
+	examplesAddCmd.Flags().Bool("force", false, "Overwrite an existing example")
+	examplesAddCmd.MarkFlagRequired("input")
+	examplesAddCmd.MarkFlagRequired("json")
+
+	examplesSelectCmd.Flags().StringP("input", "i", "", "Path to the document (required)")
+	examplesSelectCmd.MarkFlagRequired("input")
+}
+
+// examplesDir returns the directory of --dir or examples.dir
+func examplesDir(cmd *cobra.Command, cfg *config.Config) (string, error) {
+	dir := cfg.Examples.Dir
+	if cmd.Flags().Changed("dir") {
+		dir, _ = cmd.Flags().GetString("dir")
+	}
+	if dir == "" {
+		logger.Error("No examples directory, set examples.dir in the config or use --dir")
+		return "", fmt.Errorf("no examples directory configured")
+	}
+	return dir, nil
+}
+
+// loadExamples loads the examples of dir with the selection settings of the config
+func loadExamples(cfg *config.Config, dir string) (*examples.Store, error) {
+	return examples.Load(dir, examples.Options{
+		MaxExamples:   cfg.Examples.MaxExamples,
+		TokenBudget:   cfg.Examples.TokenBudget,
+		MinSimilarity: cfg.Examples.MinSimilarity,
+	})
+}
+
+// runExamplesAdd handles the examples add command logic
+func runExamplesAdd(dir string, inputFile string, jsonFile string, example *examples.Example, maxChars int, force bool, log interfaces.Logger) error {
+	content, err := os.ReadFile(inputFile)
+	if err != nil {
+		log.Error("Failed to read file %s: %v", inputFile, err)
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	doc, err := document.Load(jsonFile)
+	if err != nil {
+		log.Error("Failed to load %s: %v", jsonFile, err)
+		return err
+	}
+
+	text := string(content)
+	if maxChars > 0 && len([]rune(text)) > maxChars {
+		text = string([]rune(text)[:maxChars])
+		log.Warn("Document is longer than %d characters, only the beginning is stored", maxChars)
+	}
+	example.Text = text
+	example.Expected = *doc.Info
+	if example.Vendor == "" {
+		example.Vendor = strings.TrimSpace(document.StringValue(doc.Info.Company))
+	}
+
+	path, err := examples.Save(dir, example, force)
+	if err != nil {
+		log.Error("Failed to save example: %v", err)
+		return err
+	}
+	log.Info("Added example %s for vendor %q (~%d tokens) to %s", example.Name, example.Vendor, examples.Tokens(example), path)
+	return nil
+}
+
+// runExamplesList prints every example with its vendor and estimated size
+func runExamplesList(cfg *config.Config, dir string, log interfaces.Logger) error {
+	store, err := loadExamples(cfg, dir)
+	if err != nil {
+		log.Error("Failed to load examples: %v", err)
+		return err
+	}
+	for _, example := range store.Examples() {
+		fmt.Printf("%-30s %-25s ~%5d tokens  %s\n", example.Name, example.Vendor, examples.Tokens(example), example.Note)
+	}
+	log.Info("%d example(s) in %s", len(store.Examples()), dir)
+	return nil
+}
+
+// runExamplesSelect prints the examples chosen for a document and why
+func runExamplesSelect(cfg *config.Config, dir string, inputFile string, log interfaces.Logger) error {
+	store, err := loadExamples(cfg, dir)
+	if err != nil {
+		log.Error("Failed to load examples: %v", err)
+		return err
+	}
+	content, err := os.ReadFile(inputFile)
+	if err != nil {
+		log.Error("Failed to read file %s: %v", inputFile, err)
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+
+	selected := store.Select(string(content))
+	total := 0
+	for _, s := range selected {
+		match := "similar text"
+		if s.VendorMatch {
+			match = "vendor match"
+		}
+		fmt.Printf("%-30s %-12s similarity %.2f  ~%5d tokens\n", s.Example.Name, match, s.Similarity, s.Tokens)
+		total += s.Tokens
+	}
+	log.Info("Selected %d of %d example(s), ~%d tokens", len(selected), len(store.Examples()), total)
+	return nil
+}
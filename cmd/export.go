@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// exportCmd represents the export command
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export extracted JSON data to other formats",
+	Long: `Export one or many JSON files produced by the extract command to formats
+used by spreadsheets and accounting software.
+
+Inputs are given as arguments and may be JSON files or directories,
+directories are searched recursively for *.json files.`,
+}
+
+func init() {
+	rootCmd.AddCommand(exportCmd)
+}
+
+// loadExportDocuments loads all documents for an export command
+func loadExportDocuments(inputs []string, log interfaces.Logger) ([]document.Document, error) {
+	if len(inputs) == 0 {
+		log.Error("No input files or directories given")
+		return nil, fmt.Errorf("at least one input file or directory is required")
+	}
+
+	log.Info("Loading extracted documents from %d input path(s)", len(inputs))
+
+	docs, err := document.LoadAll(inputs)
+	if err != nil {
+		log.Error("Failed to load documents: %v", err)
+		return nil, fmt.Errorf("failed to load documents: %w", err)
+	}
+
+	if len(docs) == 0 {
+		log.Warn("No JSON documents found in the given inputs")
+	} else {
+		log.Info("Loaded %d document(s)", len(docs))
+	}
+
+	return docs, nil
+}
+
+// checkExportOutput returns true if the output file already exists and the export should be skipped
+func checkExportOutput(outputFile string, log interfaces.Logger) bool {
+	if _, err := os.Stat(outputFile); err == nil {
+		log.Warn("Output file already exists: %s", outputFile)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/export"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/locale"
+)
+
+// exportCSVCmd represents the export csv command
+var exportCSVCmd = &cobra.Command{
+	Use:   "csv [json files or directories...]",
+	Short: "Export extracted documents to a CSV file",
+	Long: `Export extracted documents to a CSV file with one row per document.
+
+Columns, locale, delimiter and BOM can be set in the config file (export.csv)
+and overridden with flags. Use --locale sv-SE for Swedish Excel (comma decimals,
+semicolon delimiter) and --bom so Excel detects UTF-8.
+
+Available columns: ` + strings.Join(export.CSVColumnNames(), ", ") + `,
+and id:<name> for a single ID field (e.g. "id:Invoice Number").`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputFile, _ := cmd.Flags().GetString("output")
+
+		cfg, err := loadConfig(logger)
+		if err != nil {
+			return err
+		}
+		csvCfg := cfg.Export.CSV
+
+		if cmd.Flags().Changed("columns") {
+			csvCfg.Columns, _ = cmd.Flags().GetStringSlice("columns")
+		}
+		if cmd.Flags().Changed("locale") {
+			csvCfg.Locale, _ = cmd.Flags().GetString("locale")
+		}
+		if cmd.Flags().Changed("delimiter") {
+			csvCfg.Delimiter, _ = cmd.Flags().GetString("delimiter")
+		}
+		if cmd.Flags().Changed("bom") {
+			csvCfg.BOM, _ = cmd.Flags().GetBool("bom")
+		}
+
+		loc, err := locale.Lookup(csvCfg.Locale)
+		if err != nil {
+			logger.Error("Invalid locale: %v", err)
+			return err
+		}
+
+		opts := export.CSVOptions{
+			Columns: csvCfg.Columns,
+			Locale:  loc,
+			BOM:     csvCfg.BOM,
+		}
+		if csvCfg.Delimiter != "" {
+			delimiter, err := parseDelimiter(csvCfg.Delimiter)
+			if err != nil {
+				logger.Error("Invalid delimiter: %v", err)
+				return err
+			}
+			opts.Delimiter = delimiter
+		}
+
+		return runExportCSV(args, outputFile, opts, logger)
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(exportCSVCmd)
+	exportCSVCmd.Flags().StringP("output", "o", "", "Path to the output CSV file (required)")
+	exportCSVCmd.Flags().StringSlice("columns", nil, "Comma-separated list of columns to export")
+	exportCSVCmd.Flags().String("locale", "", "Locale for decimal separator and default delimiter (en, sv-SE)")
+	exportCSVCmd.Flags().String("delimiter", "", "Field delimiter (overrides locale default, use \\t for tab)")
+	exportCSVCmd.Flags().Bool("bom", false, "Write a UTF-8 byte order mark for Excel")
+	exportCSVCmd.MarkFlagRequired("output")
+}
+
+// runExportCSV handles the export csv command logic
+func runExportCSV(inputs []string, outputFile string, opts export.CSVOptions, log interfaces.Logger) error {
+	log.Info("Starting CSV export to: %s", outputFile)
+
+	if checkExportOutput(outputFile, log) {
+		return nil
+	}
+
+	docs, err := loadExportDocuments(inputs, log)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Using locale %s (decimal separator '%s')", opts.Locale.Tag, opts.Locale.DecimalSeparator)
+
+	outputFileHandle, err := os.Create(outputFile)
+	if err != nil {
+		log.Error("Failed to create output file %s: %v", outputFile, err)
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outputFileHandle.Close()
+
+	if err := export.WriteCSV(outputFileHandle, docs, opts); err != nil {
+		log.Error("Failed to write CSV: %v", err)
+		return fmt.Errorf("failed to write CSV: %w", err)
+	}
+
+	log.Info("Successfully exported %d document(s) to %s", len(docs), outputFile)
+
+	return nil
+}
+
+// parseDelimiter converts a delimiter flag value into a single rune
+func parseDelimiter(value string) (rune, error) {
+	if value == `\t` || value == "tab" {
+		return '\t', nil
+	}
+	if utf8.RuneCountInString(value) != 1 {
+		return 0, fmt.Errorf("delimiter must be a single character, got %q", value)
+	}
+	r, _ := utf8.DecodeRuneInString(value)
+	return r, nil
+}
@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/accounting"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/export"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// ledgerWriter writes ledger transactions in a plain-text accounting format
+type ledgerWriter func(w io.Writer, transactions []accounting.LedgerTransaction, opts export.LedgerOptions) error
+
+// exportBeancountCmd represents the export beancount command
+var exportBeancountCmd = &cobra.Command{
+	Use:   "beancount [json files or directories...]",
+	Short: "Export extracted documents as beancount transactions",
+	Long: `Export extracted invoices and receipts as beancount transactions.
+
+The payee is the company, the narration the service description. Postings are
+written in the original currency with the SEK amount as total price (@@), and
+ID fields and the source file are added as metadata. Accounts are mapped with
+the rules in the ledger section of the config file.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLedgerExportCommand(cmd, args, "beancount", export.WriteBeancount)
+	},
+}
+
+// exportHledgerCmd represents the export hledger command
+var exportHledgerCmd = &cobra.Command{
+	Use:   "hledger [json files or directories...]",
+	Short: "Export extracted documents as hledger journal entries",
+	Long: `Export extracted invoices and receipts as hledger journal entries.
+
+The description is written as "payee | note" with the company as payee and the
+service description as note. Postings are written in the original currency with
+the SEK amount as total price (@@), and ID fields and the source file are added
+as tags. Accounts are mapped with the rules in the ledger section of the config file.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLedgerExportCommand(cmd, args, "hledger", export.WriteHledger)
+	},
+}
+
+func init() {
+	for _, ledgerCmd := range []*cobra.Command{exportBeancountCmd, exportHledgerCmd} {
+		exportCmd.AddCommand(ledgerCmd)
+		ledgerCmd.Flags().StringP("output", "o", "", "Path to the output journal file (required)")
+		ledgerCmd.Flags().Bool("open-accounts", true, "Declare/open all used accounts at the top of the file")
+		ledgerCmd.MarkFlagRequired("output")
+	}
+}
+
+// runLedgerExportCommand reads flags and config for a ledger export command
+func runLedgerExportCommand(cmd *cobra.Command, args []string, format string, write ledgerWriter) error {
+	outputFile, _ := cmd.Flags().GetString("output")
+	openAccounts, _ := cmd.Flags().GetBool("open-accounts")
+
+	cfg, err := loadConfig(logger)
+	if err != nil {
+		return err
+	}
+
+	return runExportLedger(args, outputFile, format, openAccounts, cfg, write, logger)
+}
+
+// runExportLedger handles the export beancount and export hledger command logic
+func runExportLedger(inputs []string, outputFile string, format string, openAccounts bool, cfg *config.Config, write ledgerWriter, log interfaces.Logger) error {
+	log.Info("Starting %s export to: %s", format, outputFile)
+
+	if checkExportOutput(outputFile, log) {
+		return nil
+	}
+
+	booker, err := accounting.NewLedgerBooker(cfg.Ledger)
+	if err != nil {
+		log.Error("Invalid ledger configuration: %v", err)
+		return fmt.Errorf("invalid ledger configuration: %w", err)
+	}
+
+	docs, err := loadExportDocuments(inputs, log)
+	if err != nil {
+		return err
+	}
+
+	transactions, skipped := booker.Book(docs)
+	for _, skip := range skipped {
+		log.Warn("Skipping %s: %s", skip.Path, skip.Reason)
+	}
+	log.Info("Created %d transaction(s), skipped %d document(s)", len(transactions), len(skipped))
+
+	opts := export.LedgerOptions{
+		BaseCurrency: booker.BaseCurrency(),
+		OpenAccounts: openAccounts,
+		Header:       fmt.Sprintf("Generated by %s %s on %s", appName, appVersion, time.Now().Format("2006-01-02 15:04:05")),
+	}
+
+	outputFileHandle, err := os.Create(outputFile)
+	if err != nil {
+		log.Error("Failed to create output file %s: %v", outputFile, err)
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outputFileHandle.Close()
+
+	if err := write(outputFileHandle, transactions, opts); err != nil {
+		log.Error("Failed to write %s file: %v", format, err)
+		return fmt.Errorf("failed to write %s file: %w", format, err)
+	}
+
+	log.Info("Successfully exported %d transaction(s) to %s", len(transactions), outputFile)
+
+	return nil
+}
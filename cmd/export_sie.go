@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/accounting"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/export"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// exportSIECmd represents the export sie command
+var exportSIECmd = &cobra.Command{
+	Use:   "sie [json files or directories...]",
+	Short: "Export extracted documents as SIE4 verifications",
+	Long: `Export extracted invoices and receipts as a SIE type 4 file that can be
+imported by Swedish bookkeeping software.
+
+Each document becomes one verification (#VER) with transactions (#TRANS):
+the expense account mapped from the Description category, the input VAT
+account and the bank (receipts) or supplier debt (invoices) account.
+VAT charged by foreign sellers is not deductible and stays in the expense
+unless accounting.vat.foreign_treatment is set.
+Accounts are configured in the accounting section of the config file.
+The file is encoded in code page 437 (PC8) as the SIE format requires.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputFile, _ := cmd.Flags().GetString("output")
+
+		cfg, err := loadConfig(logger)
+		if err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("series") {
+			cfg.Accounting.SIE.Series, _ = cmd.Flags().GetString("series")
+		}
+		if cmd.Flags().Changed("start-number") {
+			cfg.Accounting.SIE.StartNumber, _ = cmd.Flags().GetInt("start-number")
+		}
+
+		return runExportSIE(args, outputFile, cfg, logger)
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(exportSIECmd)
+	exportSIECmd.Flags().StringP("output", "o", "", "Path to the output .se file (required)")
+	exportSIECmd.Flags().String("series", "", "Verification series (default \"A\")")
+	exportSIECmd.Flags().Int("start-number", 0, "Number of the first verification (0 lets the importing program number them)")
+	exportSIECmd.MarkFlagRequired("output")
+}
+
+// runExportSIE handles the export sie command logic
+func runExportSIE(inputs []string, outputFile string, cfg *config.Config, log interfaces.Logger) error {
+	log.Info("Starting SIE export to: %s", outputFile)
+
+	if checkExportOutput(outputFile, log) {
+		return nil
+	}
+
+	booker, err := accounting.NewBooker(cfg.Accounting)
+	if err != nil {
+		log.Error("Invalid accounting configuration: %v", err)
+		return fmt.Errorf("invalid accounting configuration: %w", err)
+	}
+
+	docs, err := loadExportDocuments(inputs, log)
+	if err != nil {
+		return err
+	}
+
+	verifications, skipped := booker.Book(docs)
+
+	for _, skip := range skipped {
+		log.Warn("Skipping %s: %s", skip.Path, skip.Reason)
+	}
+	log.Info("Created %d verification(s), skipped %d document(s)", len(verifications), len(skipped))
+
+	opts := export.SIEOptions{
+		ProgramName:          appName,
+		ProgramVersion:       appVersion,
+		CompanyName:          cfg.Company.Name,
+		OrgNumber:            cfg.Company.OrgNumber,
+		Series:               cfg.Accounting.SIE.Series,
+		StartNumber:          cfg.Accounting.SIE.StartNumber,
+		FiscalYearStartMonth: cfg.Accounting.SIE.FiscalYearStartMonth,
+	}
+
+	if start, end, ok := export.SIEFiscalYear(verifications, opts.FiscalYearStartMonth); ok {
+		log.Info("Fiscal year: %s - %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+		for _, verification := range verifications {
+			if verification.Date.Before(start) {
+				log.Warn("Verification dated %s (%s) is outside the fiscal year", verification.Date.Format("2006-01-02"), verification.Source)
+			}
+		}
+	}
+
+	if cfg.Company.Name == "" {
+		log.Warn("company.name is not set in the config, #FNAMN will be omitted")
+	}
+
+	outputFileHandle, err := os.Create(outputFile)
+	if err != nil {
+		log.Error("Failed to create output file %s: %v", outputFile, err)
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outputFileHandle.Close()
+
+	if err := export.WriteSIE(outputFileHandle, verifications, booker, opts); err != nil {
+		log.Error("Failed to write SIE: %v", err)
+		return fmt.Errorf("failed to write SIE: %w", err)
+	}
+
+	log.Info("Successfully exported %d verification(s) to %s", len(verifications), outputFile)
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/einvoice"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// exportUBLCmd represents the export ubl command
+var exportUBLCmd = &cobra.Command{
+	Use:   "ubl <json file>",
+	Short: "Export an extracted invoice or credit note as UBL 2.1 / Peppol BIS Billing 3.0 XML",
+	Long: `Export one extracted invoice as UBL 2.1 XML following Peppol BIS Billing 3.0
+as far as the extracted data allows. Credit notes are written as UBL CreditNote
+documents with positive amounts and a reference to the credited invoice.
+
+The seller is taken from the extracted data and the buyer from the company
+section of the config file. Mandatory Peppol fields that cannot be filled are
+left out and listed in a report, printed as warnings and optionally written as
+JSON with --report.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputFile, _ := cmd.Flags().GetString("output")
+		reportFile, _ := cmd.Flags().GetString("report")
+
+		cfg, err := loadConfig(logger)
+		if err != nil {
+			return err
+		}
+
+		return runExportUBL(args[0], outputFile, reportFile, cfg, logger)
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(exportUBLCmd)
+	exportUBLCmd.Flags().StringP("output", "o", "", "Path to the output XML file (required)")
+	exportUBLCmd.Flags().String("report", "", "Path to write the missing field report as JSON")
+	exportUBLCmd.MarkFlagRequired("output")
+}
+
+// ublReport is the JSON report of mandatory Peppol fields missing from an export
+type ublReport struct {
+	Source  string                  `json:"source"`
+	Output  string                  `json:"output"`
+	Missing []einvoice.MissingField `json:"missing"`
+}
+
+// runExportUBL handles the export ubl command logic
+func runExportUBL(inputFile string, outputFile string, reportFile string, cfg *config.Config, log interfaces.Logger) error {
+	log.Info("Starting UBL export for file: %s", inputFile)
+
+	if checkExportOutput(outputFile, log) {
+		return nil
+	}
+
+	doc, err := document.Load(inputFile)
+	if err != nil {
+		log.Error("Failed to load document: %v", err)
+		return fmt.Errorf("failed to load document: %w", err)
+	}
+
+	switch doc.Info.DocumentType {
+	case interfaces.DocumentTypeInvoice:
+	case interfaces.DocumentTypeReceipt:
+		log.Warn("Document is a receipt, exporting it as an invoice")
+	case interfaces.DocumentTypeCreditNote:
+		log.Info("Document is a credit note, exporting it as a UBL credit note")
+	default:
+		log.Error("Document type %q cannot be exported as an invoice", doc.Info.DocumentType)
+		return fmt.Errorf("document type %q cannot be exported as an invoice", doc.Info.DocumentType)
+	}
+
+	invoice, missing := einvoice.BuildUBLInvoice(doc.Info, cfg.Company)
+
+	outputFileHandle, err := os.Create(outputFile)
+	if err != nil {
+		log.Error("Failed to create output file %s: %v", outputFile, err)
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outputFileHandle.Close()
+
+	if err := einvoice.WriteUBL(outputFileHandle, invoice); err != nil {
+		log.Error("Failed to write UBL invoice: %v", err)
+		return fmt.Errorf("failed to write UBL invoice: %w", err)
+	}
+
+	log.Info("Successfully wrote UBL invoice to %s", outputFile)
+
+	if len(missing) == 0 {
+		log.Info("All mandatory Peppol BIS 3.0 fields are present")
+	} else {
+		log.Warn("%d mandatory Peppol BIS 3.0 field(s) are missing:", len(missing))
+		for _, field := range missing {
+			log.Warn("  %s %s: %s", field.Term, field.Name, field.Reason)
+		}
+	}
+
+	if reportFile != "" {
+		report := ublReport{Source: inputFile, Output: outputFile, Missing: missing}
+		if report.Missing == nil {
+			report.Missing = []einvoice.MissingField{}
+		}
+
+		reportJSON, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Error("Failed to marshal report: %v", err)
+			return fmt.Errorf("failed to marshal report: %w", err)
+		}
+		if err := os.WriteFile(reportFile, reportJSON, 0644); err != nil {
+			log.Error("Failed to write report file %s: %v", reportFile, err)
+			return fmt.Errorf("failed to write report file: %w", err)
+		}
+		log.Info("Wrote missing field report to %s", reportFile)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/export"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// exportXLSXCmd represents the export xlsx command
+var exportXLSXCmd = &cobra.Command{
+	Use:   "xlsx [json files or directories...]",
+	Short: "Export extracted documents to an Excel workbook",
+	Long: `Export extracted documents to an Excel (.xlsx) workbook.
+
+The workbook contains a summary sheet with totals by category (Description) and
+by company, a sheet with all documents and one sheet per issue month. Dates,
+amounts and currencies are written as typed cells with number formats.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputFile, _ := cmd.Flags().GetString("output")
+		return runExportXLSX(args, outputFile, logger)
+	},
+}
+
+func init() {
+	exportCmd.AddCommand(exportXLSXCmd)
+	exportXLSXCmd.Flags().StringP("output", "o", "", "Path to the output .xlsx file (required)")
+	exportXLSXCmd.MarkFlagRequired("output")
+}
+
+// runExportXLSX handles the export xlsx command logic
+func runExportXLSX(inputs []string, outputFile string, log interfaces.Logger) error {
+	log.Info("Starting XLSX export to: %s", outputFile)
+
+	if checkExportOutput(outputFile, log) {
+		return nil
+	}
+
+	docs, err := loadExportDocuments(inputs, log)
+	if err != nil {
+		return err
+	}
+
+	outputFileHandle, err := os.Create(outputFile)
+	if err != nil {
+		log.Error("Failed to create output file %s: %v", outputFile, err)
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outputFileHandle.Close()
+
+	if err := export.WriteXLSX(outputFileHandle, docs); err != nil {
+		log.Error("Failed to write XLSX: %v", err)
+		return fmt.Errorf("failed to write XLSX: %w", err)
+	}
+
+	log.Info("Successfully exported %d document(s) to %s", len(docs), outputFile)
+
+	return nil
+}
@@ -7,16 +7,31 @@ import (
 	"math"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/ai"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/einvoice"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/evidence"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/examples"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/extra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/heuristics"
 	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/naming"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/prompts"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/validate"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/vendors"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/verify"
 )
 
 const maxFileSize = 200 * 1024 // 200KB in bytes
 
+// maxEInvoiceSize is the size limit of structured e-invoices, which are parsed without the AI
+// provider and often embed the PDF of the invoice
+const maxEInvoiceSize = 20 * 1024 * 1024 // 20MB in bytes
+
 // extractCmd represents the extract command
 var extractCmd = &cobra.Command{
 	Use:   "extract",
@@ -26,7 +41,33 @@ The tool will parse the file and output structured JSON with receipt/invoice det
 	RunE: func(cmd *cobra.Command, args []string) error {
 		inputFile, _ := cmd.Flags().GetString("input")
 		outputFile, _ := cmd.Flags().GetString("output")
-		return runExtract(inputFile, outputFile, logger)
+
+		cfg, err := loadConfig(logger)
+		if err != nil {
+			return err
+		}
+		namer, err := loadNamer(cfg, logger)
+		if err != nil {
+			return err
+		}
+
+		maxRounds := verify.DefaultMaxRounds
+		if cfg.Verify.MaxRounds != nil {
+			maxRounds = *cfg.Verify.MaxRounds
+		}
+		if cmd.Flags().Changed("verify-rounds") {
+			maxRounds, _ = cmd.Flags().GetInt("verify-rounds")
+		}
+		verifier, err := loadVerifier(cfg, maxRounds, logger)
+		if err != nil {
+			return err
+		}
+		registry, err := loadVendorRegistry(cfg, logger)
+		if err != nil {
+			return err
+		}
+
+		return runExtract(inputFile, outputFile, cfg, namer, verifier, registry, logger)
 	},
 }
 
@@ -34,12 +75,13 @@ func init() {
 	rootCmd.AddCommand(extractCmd)
 	extractCmd.Flags().StringP("input", "i", "", "Path to the input file (required)")
 	extractCmd.Flags().StringP("output", "o", "", "Path to the output JSON file (required)")
+	extractCmd.Flags().Int("verify-rounds", verify.DefaultMaxRounds, "Maximum number of correction requests for extractions with problems, 0 disables them")
 	extractCmd.MarkFlagRequired("input")
 	extractCmd.MarkFlagRequired("output")
 }
 
 // runExtract handles the extract command logic
-func runExtract(inputFile string, outputFile string, log interfaces.Logger) error {
+func runExtract(inputFile string, outputFile string, cfg *config.Config, namer *naming.Namer, verifier *verify.Verifier, registry *vendors.Registry, log interfaces.Logger) error {
 	log.Info("Starting receipt/invoice extraction for file: %s", inputFile)
 
 	// Check if output file already exists
@@ -61,61 +103,120 @@ func runExtract(inputFile string, outputFile string, log interfaces.Logger) erro
 		return fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	if fileInfo.Size() > maxFileSize {
-		log.Error("File size (%d bytes) exceeds maximum allowed size (%d bytes): %s", 
-			fileInfo.Size(), maxFileSize, inputFile)
-		return fmt.Errorf("file size exceeds 200KB limit")
+	if fileInfo.Size() > maxEInvoiceSize {
+		log.Error("File size (%d bytes) exceeds maximum allowed size (%d bytes): %s",
+			fileInfo.Size(), maxEInvoiceSize, inputFile)
+		return fmt.Errorf("file size exceeds 20MB limit")
 	}
 
-	// Check if file is binary
-	isBinary, err := isBinaryFile(inputFile)
+	// Read file content
+	content, err := os.ReadFile(inputFile)
 	if err != nil {
-		log.Error("Failed to check if file is binary %s: %v", inputFile, err)
-		return fmt.Errorf("failed to check file type: %w", err)
+		log.Error("Failed to read file %s: %v", inputFile, err)
+		return fmt.Errorf("failed to read file: %w", err)
 	}
 
-	if isBinary {
-		log.Error("File appears to be binary, only text files are supported: %s", inputFile)
-		return fmt.Errorf("binary files are not supported")
+	// Structured e-invoices are recognised before the limits of the AI provider apply, as an
+	// embedded PDF (cac:AdditionalDocumentReference) easily makes them larger than 200KB
+	format := einvoice.Detect(content)
+	if format == einvoice.FormatUnknown {
+		if fileInfo.Size() > maxFileSize {
+			log.Error("File size (%d bytes) exceeds maximum allowed size (%d bytes): %s",
+				fileInfo.Size(), maxFileSize, inputFile)
+			return fmt.Errorf("file size exceeds 200KB limit")
+		}
+
+		// Check if file is binary
+		isBinary, err := isBinaryFile(inputFile)
+		if err != nil {
+			log.Error("Failed to check if file is binary %s: %v", inputFile, err)
+			return fmt.Errorf("failed to check file type: %w", err)
+		}
+
+		if isBinary {
+			log.Error("File appears to be binary, only text files are supported: %s", inputFile)
+			return fmt.Errorf("binary files are not supported")
+		}
 	}
 
 	// Validate file extension
 	ext := filepath.Ext(inputFile)
-	if ext != ".txt" && ext != ".md" {
-		log.Warn("File extension '%s' is not .txt or .md, proceeding anyway", ext)
+	if ext != ".txt" && ext != ".md" && ext != ".xml" {
+		log.Warn("File extension '%s' is not .txt, .md or .xml, proceeding anyway", ext)
 	}
 
 	log.Info("File validation successful")
 	log.Info("File: %s, Size: %d bytes, Type: text", inputFile, fileInfo.Size())
 	log.Info("Output will be written to: %s", outputFile)
 
-	// Initialize OpenAI provider (it handles its own config)
-	aiProvider, err := ai.NewOpenAIAIProvider(log)
-	if err != nil {
-		log.Error("Failed to initialize AI provider: %v", err)
-		return fmt.Errorf("failed to initialize AI provider: %w", err)
-	}
+	var result *interfaces.ReceiptInvoiceInfo
+	if format != einvoice.FormatUnknown {
+		// Structured e-invoices are parsed directly, no AI provider is needed
+		log.Info("Detected structured e-invoice (%s), parsing without AI provider", format)
 
-	// Read file content
-	content, err := os.ReadFile(inputFile)
-	if err != nil {
-		log.Error("Failed to read file %s: %v", inputFile, err)
-		return fmt.Errorf("failed to read file: %w", err)
-	}
+		result, _, err = einvoice.Parse(content)
+		if err != nil {
+			log.Error("Failed to parse e-invoice: %v", err)
+			return fmt.Errorf("failed to parse e-invoice: %w", err)
+		}
+		if result.SECentAmount == nil {
+			log.Warn("E-invoice has no SEK amount or VAT in SEK, se_cent_amount is left empty")
+		}
+	} else {
+		// Initialize the OpenAI provider, or the ensemble of the config (they handle their own credentials)
+		aiProvider, err := loadAIProvider(cfg, log)
+		if err != nil {
+			log.Error("Failed to initialize AI provider: %v", err)
+			return fmt.Errorf("failed to initialize AI provider: %w", err)
+		}
 
-	log.Info("Processing document with AI provider...")
+		// The rule-based findings do not need the AI provider, they are compared with its result
+		findings := heuristics.Extract(string(content))
+		log.Debug("Pre-extraction found %d date(s), %d amount(s) and %d identifier(s)", len(findings.Dates), len(findings.Amounts), len(findings.Identifiers))
 
-	// Extract information using AI
-	result, err := aiProvider.GetReceiptInvoiceInfo(string(content))
-	if err != nil {
-		log.Error("Failed to extract information: %v", err)
-		return fmt.Errorf("failed to extract information: %w", err)
+		log.Info("Processing document with AI provider...")
+
+		// Extract information using AI
+		result, err = aiProvider.GetReceiptInvoiceInfo(string(content))
+		if err != nil {
+			log.Error("Failed to extract information: %v", err)
+			return fmt.Errorf("failed to extract information: %w", err)
+		}
+		result.Source = interfaces.SourceAI
+
+		// Credit notes reduce costs, their amounts are stored negative whatever the document shows.
+		// The sign is fixed before verification so that it is not sent back for correction.
+		if document.NegateCreditNote(result) {
+			log.Info("Document is a credit note, its amounts were made negative")
+		}
+
+		// Check that the evidence given by the provider really is in the document, values the
+		// rules found differently point at hallucinated fields
+		verify.Annotate(result, string(content), findings)
+
+		// Send results with problems back to the provider for correction
+		result, err = verifier.Run(aiProvider, string(content), findings, result, log)
+		if err != nil {
+			return err
+		}
+		logLowConfidence(result, log)
+		logCrossChecks(result, log)
 	}
 
 	log.Info("Successfully extracted information from document")
 
+	// Normalise the company and category of known vendors, learning the vendors of accepted results
+	if registry != nil {
+		if err := applyVendorRegistry(registry, result, verifier, cfg.Vendors.AutoLearn, log); err != nil {
+			return err
+		}
+	}
+
+	// Record where the information came from so exports and reports can refer back to it
+	result.SourceFile = inputFile
+
 	// Generate suggested filename and populate the field
-	result.SuggestedFileName = generateSuggestedFileName(result)
+	result.SuggestedFileName = namer.Name(result)
 	log.Info("Generated suggested filename: %s", result.SuggestedFileName)
 
 	// Convert result to JSON
@@ -140,44 +241,188 @@ func runExtract(inputFile string, outputFile string, log interfaces.Logger) erro
 	return nil
 }
 
-// generateSuggestedFileName creates a suggested filename from extracted data
-// Format: <date>-<company>-<description>-<amount>SEK (lowercase, non-alphanumeric chars become _)
-func generateSuggestedFileName(info *interfaces.ReceiptInvoiceInfo) string {
-	// Helper function to clean strings: lowercase and replace non-alphanumeric with _
-	cleanString := func(s string) string {
-		// Convert to lowercase
-		s = strings.ToLower(s)
-		// Replace non-alphanumeric characters with underscore
-		reg := regexp.MustCompile(`[^a-z0-9]`)
-		return reg.ReplaceAllString(s, "_")
+// loadNamer returns the namer for suggested filenames configured in the naming section
+func loadNamer(cfg *config.Config, log interfaces.Logger) (*naming.Namer, error) {
+	namer, err := naming.New(naming.Options{
+		Pattern:         cfg.Naming.Pattern,
+		MaxLength:       cfg.Naming.MaxLength,
+		Missing:         cfg.Naming.Missing,
+		CompanySuffixes: cfg.Naming.CompanySuffixes,
+	})
+	if err != nil {
+		log.Error("Invalid naming pattern: %v", err)
+		return nil, fmt.Errorf("invalid naming pattern: %w", err)
 	}
-	
-	// Extract date (use "unknown" if not available)
-	date := "unknown"
-	if info.DateIssued != nil && *info.DateIssued != "" && *info.DateIssued != "." {
-		date = cleanString(*info.DateIssued)
+	return namer, nil
+}
+
+// loadAIProvider returns the ensemble configured in the ensemble section, or the OpenAI provider
+// configured by the environment if the section has no backends
+func loadAIProvider(cfg *config.Config, log interfaces.Logger) (interfaces.AIProvider, error) {
+	prompt, err := loadPrompt(cfg)
+	if err != nil {
+		return nil, err
 	}
-	
-	// Extract company (use "unknown" if not available)
-	company := "unknown"
-	if info.Company != nil && *info.Company != "" && *info.Company != "." {
-		company = cleanString(*info.Company)
+	var store *examples.Store
+	if cfg.Examples.Dir != "" {
+		if store, err = loadExamples(cfg, cfg.Examples.Dir); err != nil {
+			return nil, fmt.Errorf("failed to load examples: %w", err)
+		}
+		log.Info("Loaded %d few-shot example(s) from %s", len(store.Examples()), cfg.Examples.Dir)
 	}
-	
-	// Extract description (always available as it's mandatory)
-	description := cleanString(info.Description)
-	
-	// Extract amount in SEK (convert from se_cent_amount to SEK, rounded to nearest krona)
-	amountSEK := "unknown"
-	if info.SECentAmount != nil && *info.SECentAmount > 0 {
-		// Convert öre to SEK and round to nearest krona
-		sekAmount := float64(*info.SECentAmount) / 100.0
-		roundedSEK := int(math.Round(sekAmount))
-		amountSEK = fmt.Sprintf("%dsek", roundedSEK)
+	extraFields := loadExtraFields(cfg)
+	if len(cfg.Ensemble.Backends) == 0 {
+		return ai.NewOpenAIAIProviderWithOptions(log, ai.OpenAIOptions{Prompt: prompt, Examples: store, ExtraFields: extraFields})
+	}
+
+	var backends []ai.EnsembleBackend
+	for i, backendCfg := range cfg.Ensemble.Backends {
+		provider := backendCfg.Provider
+		if provider == "" {
+			provider = "openai"
+		}
+		if provider != "openai" {
+			return nil, fmt.Errorf("ensemble backend %d: unsupported provider %q, only openai is supported", i+1, provider)
+		}
+
+		openAIProvider, err := ai.NewOpenAIAIProviderWithOptions(log, ai.OpenAIOptions{
+			Model:       backendCfg.Model,
+			BaseURL:     backendCfg.BaseURL,
+			APIKeyEnv:   backendCfg.APIKeyEnv,
+			Prompt:      prompt,
+			Examples:    store,
+			ExtraFields: extraFields,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("ensemble backend %d: %w", i+1, err)
+		}
+		name := backendCfg.Name
+		if name == "" {
+			name = provider + ":" + openAIProvider.Model()
+		}
+		backends = append(backends, ai.EnsembleBackend{Name: name, Provider: openAIProvider})
+	}
+
+	threshold := ai.DefaultDisagreementThreshold
+	if cfg.Ensemble.DisagreementThreshold != nil {
+		threshold = *cfg.Ensemble.DisagreementThreshold
+	}
+	return ai.NewEnsembleAIProvider(backends, ai.EnsembleOptions{
+		DisagreementThreshold: threshold,
+		MinSEKCents:           int(math.Round(cfg.Ensemble.MinSEKAmount * 100)),
+	}, log)
+}
+
+// loadExtraFields returns the user-defined fields of the extra_fields section
+func loadExtraFields(cfg *config.Config) []extra.Field {
+	var fields []extra.Field
+	for _, field := range cfg.ExtraFields {
+		fields = append(fields, extra.Field{
+			Name:        field.Name,
+			Type:        field.Type,
+			Description: field.Description,
+			Enum:        field.Enum,
+		})
+	}
+	return fields
+}
+
+// loadPrompt returns the prompt file of prompt.file, or the built-in prompt of prompt.version
+func loadPrompt(cfg *config.Config) (*prompts.Prompt, error) {
+	if cfg.Prompt.File != "" {
+		return prompts.LoadFile(cfg.Prompt.File)
+	}
+	return prompts.Load(cfg.Prompt.Version)
+}
+
+// loadVerifier returns the self-verification of AI extractions configured in the verify section
+// Problems are found with the rules of the validate section.
+func loadVerifier(cfg *config.Config, maxRounds int, log interfaces.Logger) (*verify.Verifier, error) {
+	opts, _, err := validateOptions(cfg.Validate, nil, "")
+	if err != nil {
+		log.Error("Invalid validate configuration: %v", err)
+		return nil, err
+	}
+	validator, err := validate.New(opts)
+	if err != nil {
+		log.Error("Invalid validate configuration: %v", err)
+		return nil, fmt.Errorf("invalid validate configuration: %w", err)
+	}
+
+	verifier, err := verify.New(verify.Options{
+		MaxRounds: maxRounds,
+		Validator: validator,
+		Trigger:   validate.Severity(cfg.Verify.Trigger),
+	})
+	if err != nil {
+		log.Error("Invalid verify configuration: %v", err)
+		return nil, fmt.Errorf("invalid verify configuration: %w", err)
+	}
+	return verifier, nil
+}
+
+// applyVendorRegistry matches the result to its vendor and normalises it
+// With autoLearn, the vendor of a result without problems is added to the registry first, or
+// the missing details of a known vendor are filled in.
+func applyVendorRegistry(registry *vendors.Registry, info *interfaces.ReceiptInvoiceInfo, verifier *verify.Verifier, autoLearn bool, log interfaces.Logger) error {
+	if autoLearn {
+		if problems := verifier.Problems(info); len(problems) > 0 {
+			log.Info("Not learning the vendor, the extraction has %d problem(s)", len(problems))
+		} else if vendor, changed := registry.Learn(info); changed {
+			if err := registry.Save(); err != nil {
+				log.Error("Failed to save vendor registry: %v", err)
+				return err
+			}
+			log.Info("Learned vendor %s, saved to %s", vendor.Name, registry.Path())
+		}
+	}
+
+	match := registry.Apply(info)
+	if match == nil {
+		log.Info("Company %q is not in the vendor registry", document.StringValue(info.Company))
+		return nil
+	}
+	log.Info("Matched vendor %s by %s", match.Name, strings.ReplaceAll(match.MatchedBy, "_", " "))
+	for _, change := range match.Changes {
+		log.Info("  %s: %q -> %q", change.Field, change.From, change.To)
+	}
+	return nil
+}
+
+// logLowConfidence warns about extracted fields with low confidence
+func logLowConfidence(info *interfaces.ReceiptInvoiceInfo, log interfaces.Logger) {
+	low := evidence.Low(info)
+	if len(low) == 0 {
+		if len(info.Evidence) > 0 {
+			log.Info("All %d extracted field(s) have medium or high confidence", len(info.Evidence))
+		}
+		return
+	}
+
+	log.Warn("%d of %d extracted field(s) have low confidence, check them against the source:", len(low), len(info.Evidence))
+	for _, e := range low {
+		reason := e.Note
+		if reason == "" {
+			reason = "reported by the AI provider"
+		}
+		log.Warn("  %s = %q (%s)", e.Field, evidence.Value(info, e.Field), reason)
+	}
+}
+
+// logCrossChecks warns about extracted fields that conflict with rule-based pre-extraction
+func logCrossChecks(info *interfaces.ReceiptInvoiceInfo, log interfaces.Logger) {
+	if len(info.CrossChecks) == 0 {
+		return
+	}
+
+	log.Info("Cross-check with pre-extraction: %d agree, %d conflict, %d not found by the rules, %d not extracted",
+		heuristics.Count(info.CrossChecks, interfaces.CheckAgree),
+		heuristics.Count(info.CrossChecks, interfaces.CheckConflict),
+		heuristics.Count(info.CrossChecks, interfaces.CheckAIOnly),
+		heuristics.Count(info.CrossChecks, interfaces.CheckRulesOnly))
+	for _, check := range heuristics.Conflicts(info.CrossChecks) {
+		log.Warn("  %s = %q, the document has %s", check.Field, check.Value, strings.Join(check.Found, ", "))
 	}
-	
-	// Combine all parts
-	return fmt.Sprintf("%s-%s-%s-%s", date, company, description, amountSEK)
 }
 
 // isBinaryFile checks if a file appears to be binary by examining the first 512 bytes
@@ -4,13 +4,17 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
-	"html/template"
 	"os"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/i18n"
 	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/locale"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/sourceview"
 )
 
 //go:embed overview-template.html
@@ -21,11 +25,47 @@ var htmloverviewCmd = &cobra.Command{
 	Use:   "htmloverview",
 	Short: "Generate HTML overview from extracted JSON data",
 	Long: `Generate a professional HTML overview report from JSON data extracted by the extract command.
-The HTML output is optimized for printing and includes all extracted information in a nicely formatted layout.`,
+The HTML output is optimized for printing and includes all extracted information in a nicely formatted layout.
+
+Use --template to render with a custom template file, or a directory containing
+overview.html and partials (*.tmpl). Run "template dump overview" for a
+starting point.
+
+Use --embed-source to include the original text (source_file in the JSON, or --source)
+in a collapsible section with the extracted values highlighted. The section is only
+printed when it is expanded.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		inputFile, _ := cmd.Flags().GetString("input")
 		outputFile, _ := cmd.Flags().GetString("output")
-		return runHTMLOverview(inputFile, outputFile, logger)
+
+		cfg, err := loadConfig(logger)
+		if err != nil {
+			return err
+		}
+		templatePath := cfg.HTML.Template
+		if cmd.Flags().Changed("template") {
+			templatePath, _ = cmd.Flags().GetString("template")
+		}
+		loc, tr, err := htmlLocalization(cmd, cfg.HTML, logger)
+		if err != nil {
+			return err
+		}
+
+		opts := htmlOverviewOptions{
+			TemplatePath: templatePath,
+			Locale:       loc,
+			Translator:   tr,
+			EmbedSource:  cfg.HTML.EmbedSource,
+		}
+		if cmd.Flags().Changed("embed-source") {
+			opts.EmbedSource, _ = cmd.Flags().GetBool("embed-source")
+		}
+		if cmd.Flags().Changed("source") {
+			opts.SourcePath, _ = cmd.Flags().GetString("source")
+			opts.EmbedSource = true
+		}
+
+		return runHTMLOverview(inputFile, outputFile, opts, logger)
 	},
 }
 
@@ -33,18 +73,53 @@ func init() {
 	rootCmd.AddCommand(htmloverviewCmd)
 	htmloverviewCmd.Flags().StringP("input", "i", "", "Path to the input JSON file (required)")
 	htmloverviewCmd.Flags().StringP("output", "o", "", "Path to the output HTML file (required)")
+	htmloverviewCmd.Flags().String("template", "", "Custom template file or directory with overview.html and partials")
+	htmloverviewCmd.Flags().String("lang", "", "Language of the labels (en, sv)")
+	htmloverviewCmd.Flags().String("locale", "", "Locale for numbers and dates (en, sv-SE), defaults to the locale of --lang")
+	htmloverviewCmd.Flags().Bool("embed-source", false, "Embed the source document with the extracted values highlighted")
+	htmloverviewCmd.Flags().String("source", "", "Source document to embed (default is source_file from the JSON, implies --embed-source)")
 	htmloverviewCmd.MarkFlagRequired("input")
 	htmloverviewCmd.MarkFlagRequired("output")
 }
 
+// htmlOverviewOptions holds the rendering settings of the htmloverview command
+type htmlOverviewOptions struct {
+	TemplatePath string
+	Locale       locale.Locale
+	Translator   *i18n.Translator
+	EmbedSource  bool
+	SourcePath   string
+}
+
 // TemplateData represents the data structure passed to the HTML template
 type TemplateData struct {
 	Data        *interfaces.ReceiptInvoiceInfo `json:"data"`
 	ProcessedAt string                         `json:"processed_at"`
+	Source      *SourceData                    `json:"source,omitempty"`
+}
+
+// SourceData is the embedded source document, nil unless --embed-source is used
+type SourceData struct {
+	// Path is the source file that was embedded
+	Path string `json:"path"`
+
+	// Segments cover the source text, highlighted segments have a Field
+	Segments []SourceSegment `json:"segments"`
+
+	// Found and Missing are the labels of extracted values found and not found in the source
+	Found   []string `json:"found"`
+	Missing []string `json:"missing"`
+}
+
+// SourceSegment is a piece of the source text, Label names the extracted value it matched
+type SourceSegment struct {
+	Text  string `json:"text"`
+	Field string `json:"field,omitempty"`
+	Label string `json:"label,omitempty"`
 }
 
 // runHTMLOverview handles the htmloverview command logic
-func runHTMLOverview(inputFile string, outputFile string, log interfaces.Logger) error {
+func runHTMLOverview(inputFile string, outputFile string, opts htmlOverviewOptions, log interfaces.Logger) error {
 	log.Info("Starting HTML overview generation for file: %s", inputFile)
 
 	// Check if output file already exists
@@ -81,6 +156,8 @@ func runHTMLOverview(inputFile string, outputFile string, log interfaces.Logger)
 	if receiptData.Company != nil {
 		log.Info("Company: %s", *receiptData.Company)
 	}
+	logLowConfidence(&receiptData, log)
+	logCrossChecks(&receiptData, log)
 
 	// Prepare template data
 	templateData := TemplateData{
@@ -88,48 +165,27 @@ func runHTMLOverview(inputFile string, outputFile string, log interfaces.Logger)
 		ProcessedAt: time.Now().Format("2006-01-02 15:04:05"),
 	}
 
-	log.Info("Generating HTML output...")
-
-	// Create template functions
-	funcMap := template.FuncMap{
-		"lower": strings.ToLower,
-		"default": func(defaultValue, value string) string {
-			if value == "" {
-				return defaultValue
-			}
-			return value
-		},
-		"div": func(a, b float64) float64 {
-			if b == 0 {
-				return 0
-			}
-			return a / b
-		},
-		"divInt": func(a *int, b float64) float64 {
-			if a == nil || b == 0 {
-				return 0
-			}
-			return float64(*a) / b
-		},
-		"formatFloat": func(f *float64) string {
-			if f == nil {
-				return "0.00"
+	if opts.EmbedSource {
+		source, err := loadSourceData(inputFile, &receiptData, opts.SourcePath, opts.Translator)
+		if err != nil {
+			// The overview is still useful without the source, so this is not fatal
+			log.Warn("Source document not embedded: %v", err)
+		} else {
+			templateData.Source = source
+			log.Info("Embedding source document: %s", source.Path)
+			if len(source.Missing) > 0 {
+				log.Warn("Extracted values not found in the source: %s", strings.Join(source.Missing, ", "))
 			}
-			return fmt.Sprintf("%.2f", *f)
-		},
-		"formatFloatWithCurrency": func(f *float64, currency *string) string {
-			if f == nil {
-				return "0.00"
-			}
-			if currency == nil || *currency == "" {
-				return fmt.Sprintf("%.2f", *f)
-			}
-			return fmt.Sprintf("%.2f %s", *f, *currency)
-		},
+		}
 	}
 
-	// Parse template
-	tmpl, err := template.New("overview").Funcs(funcMap).Parse(htmlTemplateContent)
+	log.Info("Generating HTML output...")
+
+	// Parse template, the built-in one unless a custom template is given
+	if opts.TemplatePath != "" {
+		log.Info("Using custom template: %s", opts.TemplatePath)
+	}
+	tmpl, err := loadHTMLTemplate("overview", htmlTemplateContent, opts.TemplatePath, templateFuncs(opts.Locale, opts.Translator))
 	if err != nil {
 		log.Error("Failed to parse HTML template: %v", err)
 		return fmt.Errorf("failed to parse HTML template: %w", err)
@@ -167,4 +223,102 @@ func runHTMLOverview(inputFile string, outputFile string, log interfaces.Logger)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// loadSourceData reads the source document and highlights the extracted values in it
+// Without an explicit path, source_file is resolved relative to the working directory
+// and then to the directory of the JSON file.
+func loadSourceData(jsonFile string, info *interfaces.ReceiptInvoiceInfo, sourcePath string, tr *i18n.Translator) (*SourceData, error) {
+	if sourcePath == "" {
+		if info.SourceFile == "" {
+			return nil, fmt.Errorf("the JSON has no source_file, use --source")
+		}
+		sourcePath = document.SourcePath(document.Document{Path: jsonFile, Info: info})
+	}
+
+	fileInfo, err := os.Stat(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("source file not found: %s", sourcePath)
+	}
+	if fileInfo.Size() > maxFileSize {
+		return nil, fmt.Errorf("source file %s exceeds the 200KB limit", sourcePath)
+	}
+
+	content, err := os.ReadFile(sourcePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source file: %w", err)
+	}
+
+	view := sourceview.Highlight(strings.ReplaceAll(string(content), "\r\n", "\n"), sourceview.NeedlesFor(info))
+
+	source := &SourceData{Path: sourcePath}
+	for _, segment := range view.Segments {
+		s := SourceSegment{Text: segment.Text, Field: segment.Field}
+		if segment.Field != "" {
+			s.Label = sourceFieldLabel(segment.Field, tr)
+		}
+		source.Segments = append(source.Segments, s)
+	}
+	for _, field := range view.Found {
+		source.Found = append(source.Found, sourceFieldLabel(field, tr))
+	}
+	for _, field := range view.Missing {
+		source.Missing = append(source.Missing, sourceFieldLabel(field, tr))
+	}
+
+	return source, nil
+}
+
+// sourceFieldLabel returns the translated label of a field, ID fields keep their name
+func sourceFieldLabel(field string, tr *i18n.Translator) string {
+	switch field {
+	case sourceview.FieldCompany:
+		return tr.T("label.company")
+	case sourceview.FieldDateIssued:
+		return tr.T("label.date_issued")
+	case sourceview.FieldPeriodStart:
+		return tr.T("label.period_start")
+	case sourceview.FieldPeriodEnd:
+		return tr.T("label.period_end")
+	case "service_description":
+		return tr.T("label.service")
+	case "original_currency":
+		return tr.T("label.currency")
+	case sourceview.FieldOriginalAmount:
+		return tr.T("label.original_amount")
+	case sourceview.FieldOriginalVatAmount:
+		return tr.T("label.original_vat")
+	case sourceview.FieldSEKAmount:
+		return tr.T("label.amount_sek")
+	}
+	return strings.TrimPrefix(field, sourceview.IdFieldPrefix)
+}
+
+// htmlLocalization returns the translator from --lang and the locale from --locale, falling
+// back to the config and then to the locale of the language
+func htmlLocalization(cmd *cobra.Command, cfg config.HTMLConfig, log interfaces.Logger) (locale.Locale, *i18n.Translator, error) {
+	lang := cfg.Lang
+	if cmd.Flags().Changed("lang") {
+		lang, _ = cmd.Flags().GetString("lang")
+	}
+	tr, err := i18n.New(lang)
+	if err != nil {
+		log.Error("Invalid language: %v", err)
+		return locale.Locale{}, nil, err
+	}
+
+	tag := cfg.Locale
+	if cmd.Flags().Changed("locale") {
+		tag, _ = cmd.Flags().GetString("locale")
+	}
+	if tag == "" {
+		return tr.Locale(), tr, nil
+	}
+
+	loc, err := locale.Lookup(tag)
+	if err != nil {
+		log.Error("Invalid locale: %v", err)
+		return locale.Locale{}, nil, err
+	}
+	return loc, tr, nil
+}
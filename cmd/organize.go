@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/naming"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/organize"
+	"github.com/spf13/cobra"
+)
+
+// organizeCmd represents the organize command
+var organizeCmd = &cobra.Command{
+	Use:   "organize [json files or directories...]",
+	Short: "Move or copy documents into folders named by their extracted data",
+	Long: `Move or copy each extracted document into a folder hierarchy below --dest.
+For every JSON file the source document (source_file) and the HTML and PDF
+overviews with the same base name as the JSON file are moved along with it.
+
+The target path is given by --pattern, default "{year}/{month}/{suggested_filename}.{ext}".
+All fields of the naming pattern can be used, e.g. {date:2006-01} or {id:Invoice Number},
+and {ext} is the extension of each file.
+
+If a target is taken, "-2", "-3", ... is appended to the names of all files of the
+document. Documents are handled in date order, so the same input gives the same result.
+Moved JSON files get an updated source_file.
+
+Every operation is recorded in an undo log (organize-undo-<time>.jsonl in the
+destination unless --undo-log is given). Revert a run with --undo <log>.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		if undoLog, _ := cmd.Flags().GetString("undo"); undoLog != "" {
+			return runOrganizeUndo(undoLog, dryRun, logger)
+		}
+
+		cfg, err := loadConfig(logger)
+		if err != nil {
+			return err
+		}
+
+		opts := organize.Options{
+			Destination: cfg.Organize.Destination,
+			Pattern:     cfg.Organize.Pattern,
+			Copy:        cfg.Organize.Copy,
+		}
+		if cmd.Flags().Changed("dest") {
+			opts.Destination, _ = cmd.Flags().GetString("dest")
+		}
+		if cmd.Flags().Changed("pattern") {
+			opts.Pattern, _ = cmd.Flags().GetString("pattern")
+		}
+		if cmd.Flags().Changed("copy") {
+			opts.Copy, _ = cmd.Flags().GetBool("copy")
+		}
+		if opts.Pattern == "" {
+			opts.Pattern = organize.DefaultPattern
+		}
+		undoLog, _ := cmd.Flags().GetString("undo-log")
+
+		namer, err := loadNamer(cfg, logger)
+		if err != nil {
+			return err
+		}
+
+		return runOrganize(args, opts, namer, dryRun, undoLog, logger)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(organizeCmd)
+	organizeCmd.Flags().StringP("dest", "d", "", "Destination root directory (required unless set in the config)")
+	organizeCmd.Flags().String("pattern", organize.DefaultPattern, "Target path of each file below the destination")
+	organizeCmd.Flags().Bool("copy", false, "Copy the files instead of moving them")
+	organizeCmd.Flags().Bool("dry-run", false, "Show the plan without touching any files")
+	organizeCmd.Flags().String("undo-log", "", "Path of the undo log (default organize-undo-<time>.jsonl in the destination)")
+	organizeCmd.Flags().String("undo", "", "Revert the operations recorded in an undo log")
+}
+
+// runOrganize plans and carries out the organize command
+func runOrganize(inputs []string, opts organize.Options, namer *naming.Namer, dryRun bool, undoLog string, log interfaces.Logger) error {
+	if opts.Destination == "" {
+		log.Error("No destination given")
+		return fmt.Errorf("a destination is required, use --dest or organize.destination in the config")
+	}
+	if err := organize.ValidatePattern(opts.Pattern); err != nil {
+		log.Error("Invalid pattern: %v", err)
+		return err
+	}
+
+	docs, err := loadExportDocuments(inputs, log)
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+
+	// Documents extracted before suggested_filename existed get one now
+	for _, doc := range docs {
+		if doc.Info.SuggestedFileName == "" {
+			doc.Info.SuggestedFileName = namer.Name(doc.Info)
+		}
+	}
+
+	plan, err := organize.NewPlan(docs, opts)
+	if err != nil {
+		log.Error("Failed to plan: %v", err)
+		return fmt.Errorf("failed to plan: %w", err)
+	}
+
+	for _, path := range plan.MissingSources {
+		log.Warn("Source file of %s not found, organising the JSON file only", path)
+	}
+	for _, path := range plan.InPlace {
+		log.Debug("Already in place: %s", path)
+	}
+
+	if len(plan.Operations) == 0 {
+		log.Info("Nothing to do, all %d file(s) are already in place", len(plan.InPlace))
+		return nil
+	}
+
+	if dryRun {
+		log.Info("Dry run, %d file(s) would be %s:", len(plan.Operations), pastTense(plan.Operations[0].Action))
+		for _, op := range plan.Operations {
+			fmt.Printf("%s %s -> %s\n", op.Action, op.From, op.To)
+			if op.SourceFile != "" {
+				fmt.Printf("     source_file: %s -> %s\n", op.PreviousSourceFile, op.SourceFile)
+			}
+		}
+		return nil
+	}
+
+	if undoLog == "" {
+		undoLog = defaultUndoLog(opts.Destination)
+	}
+	if err := os.MkdirAll(filepath.Dir(undoLog), 0755); err != nil {
+		log.Error("Failed to create directory for undo log: %v", err)
+		return fmt.Errorf("failed to create directory for undo log: %w", err)
+	}
+	logFile, err := os.OpenFile(undoLog, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		log.Error("Failed to create undo log %s: %v", undoLog, err)
+		return fmt.Errorf("failed to create undo log: %w", err)
+	}
+	defer logFile.Close()
+
+	if err := organize.Apply(plan, logFile); err != nil {
+		log.Error("Failed to organize documents: %v", err)
+		log.Warn("Completed operations are recorded in %s", undoLog)
+		return fmt.Errorf("failed to organize documents: %w", err)
+	}
+
+	log.Info("Successfully %s %d file(s) into %s", pastTense(plan.Operations[0].Action), len(plan.Operations), opts.Destination)
+	log.Info("Undo log written to %s", undoLog)
+	return nil
+}
+
+// runOrganizeUndo reverts the operations of an undo log
+func runOrganizeUndo(undoLog string, dryRun bool, log interfaces.Logger) error {
+	logFile, err := os.Open(undoLog)
+	if err != nil {
+		log.Error("Failed to open undo log %s: %v", undoLog, err)
+		return fmt.Errorf("failed to open undo log: %w", err)
+	}
+	defer logFile.Close()
+
+	ops, err := organize.ReadUndoLog(logFile)
+	if err != nil {
+		log.Error("Failed to read undo log %s: %v", undoLog, err)
+		return fmt.Errorf("failed to read undo log: %w", err)
+	}
+
+	if dryRun {
+		log.Info("Dry run, %d operation(s) would be reverted:", len(ops))
+		for i := len(ops) - 1; i >= 0; i-- {
+			if ops[i].Action == organize.ActionCopy {
+				fmt.Printf("remove %s\n", ops[i].To)
+			} else {
+				fmt.Printf("move %s -> %s\n", ops[i].To, ops[i].From)
+			}
+		}
+		return nil
+	}
+
+	reverted, err := organize.Undo(ops, log)
+	if err != nil {
+		log.Error("Failed to undo: %v", err)
+		return fmt.Errorf("failed to undo: %w", err)
+	}
+
+	log.Info("Reverted %d of %d operation(s) from %s", reverted, len(ops), undoLog)
+	if reverted == len(ops) {
+		if err := os.Remove(undoLog); err == nil {
+			log.Info("Removed undo log %s", undoLog)
+		}
+	}
+	return nil
+}
+
+// defaultUndoLog returns a new undo log path in the destination, named by the current time
+func defaultUndoLog(destination string) string {
+	name := "organize-undo-" + time.Now().Format("20060102-150405")
+	path := filepath.Join(destination, name+".jsonl")
+	for n := 2; ; n++ {
+		if _, err := os.Lstat(path); err != nil {
+			return path
+		}
+		path = filepath.Join(destination, fmt.Sprintf("%s-%d.jsonl", name, n))
+	}
+}
+
+// pastTense returns "moved" or "copied" for an operation action
+func pastTense(action string) string {
+	if action == organize.ActionCopy {
+		return "copied"
+	}
+	return "moved"
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/pdf"
+)
+
+// pdfoverviewCmd represents the pdfoverview command
+var pdfoverviewCmd = &cobra.Command{
+	Use:   "pdfoverview",
+	Short: "Generate an A4 PDF overview from extracted JSON data",
+	Long: `Generate an A4 PDF overview from JSON data extracted by the extract command.
+The PDF shows the same information as the HTML overview and is written directly,
+without a browser, so archived verification pages can be created anywhere the tool runs.
+
+The language, locale and embed_source settings of the html section in the config
+file apply to the PDF as well. With --embed-source the original text is appended
+in a monospaced font with the extracted values highlighted.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		inputFile, _ := cmd.Flags().GetString("input")
+		outputFile, _ := cmd.Flags().GetString("output")
+
+		cfg, err := loadConfig(logger)
+		if err != nil {
+			return err
+		}
+		loc, tr, err := htmlLocalization(cmd, cfg.HTML, logger)
+		if err != nil {
+			return err
+		}
+
+		opts := htmlOverviewOptions{
+			Locale:      loc,
+			Translator:  tr,
+			EmbedSource: cfg.HTML.EmbedSource,
+		}
+		if cmd.Flags().Changed("embed-source") {
+			opts.EmbedSource, _ = cmd.Flags().GetBool("embed-source")
+		}
+		if cmd.Flags().Changed("source") {
+			opts.SourcePath, _ = cmd.Flags().GetString("source")
+			opts.EmbedSource = true
+		}
+
+		return runPDFOverview(inputFile, outputFile, opts, logger)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(pdfoverviewCmd)
+	pdfoverviewCmd.Flags().StringP("input", "i", "", "Path to the input JSON file (required)")
+	pdfoverviewCmd.Flags().StringP("output", "o", "", "Path to the output PDF file (required)")
+	pdfoverviewCmd.Flags().String("lang", "", "Language of the labels (en, sv)")
+	pdfoverviewCmd.Flags().String("locale", "", "Locale for numbers and dates (en, sv-SE), defaults to the locale of --lang")
+	pdfoverviewCmd.Flags().Bool("embed-source", false, "Append the source document with the extracted values highlighted")
+	pdfoverviewCmd.Flags().String("source", "", "Source document to embed (default is source_file from the JSON, implies --embed-source)")
+	pdfoverviewCmd.MarkFlagRequired("input")
+	pdfoverviewCmd.MarkFlagRequired("output")
+}
+
+// runPDFOverview handles the pdfoverview command logic
+// The TemplatePath of opts is not used, the PDF layout is fixed.
+func runPDFOverview(inputFile string, outputFile string, opts htmlOverviewOptions, log interfaces.Logger) error {
+	log.Info("Starting PDF overview generation for file: %s", inputFile)
+
+	// Check if output file already exists
+	if _, err := os.Stat(outputFile); err == nil {
+		log.Warn("Output file already exists: %s", outputFile)
+		return nil
+	}
+
+	// Check if input file exists
+	if _, err := os.Stat(inputFile); os.IsNotExist(err) {
+		log.Error("Input file does not exist: %s", inputFile)
+		return fmt.Errorf("input file does not exist: %s", inputFile)
+	}
+
+	jsonContent, err := os.ReadFile(inputFile)
+	if err != nil {
+		log.Error("Failed to read input file %s: %v", inputFile, err)
+		return fmt.Errorf("failed to read input file: %w", err)
+	}
+
+	var receiptData interfaces.ReceiptInvoiceInfo
+	if err := json.Unmarshal(jsonContent, &receiptData); err != nil {
+		log.Error("Failed to parse JSON from %s: %v", inputFile, err)
+		return fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	log.Info("Document type: %s", receiptData.DocumentType)
+	log.Info("Description: %s", receiptData.Description)
+
+	overview := pdf.Overview{
+		Info:        &receiptData,
+		Translator:  opts.Translator,
+		Locale:      opts.Locale,
+		ProcessedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+
+	if opts.EmbedSource {
+		source, err := loadSourceData(inputFile, &receiptData, opts.SourcePath, opts.Translator)
+		if err != nil {
+			// The overview is still useful without the source, so this is not fatal
+			log.Warn("Source document not embedded: %v", err)
+		} else {
+			log.Info("Embedding source document: %s", source.Path)
+			if len(source.Missing) > 0 {
+				log.Warn("Extracted values not found in the source: %s", strings.Join(source.Missing, ", "))
+			}
+			overview.Source = &pdf.Source{Path: source.Path, Found: source.Found, Missing: source.Missing}
+			for _, segment := range source.Segments {
+				overview.Source.Segments = append(overview.Source.Segments, pdf.SourceSegment{Text: segment.Text, Label: segment.Label})
+			}
+		}
+	}
+
+	log.Info("Generating PDF output...")
+
+	// Render into memory first so a failed render leaves no partial file behind
+	var buf bytes.Buffer
+	if err := pdf.RenderOverview(&buf, overview); err != nil {
+		log.Error("Failed to render PDF: %v", err)
+		return fmt.Errorf("failed to render PDF: %w", err)
+	}
+
+	if err := os.WriteFile(outputFile, buf.Bytes(), 0644); err != nil {
+		log.Error("Failed to write output file %s: %v", outputFile, err)
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+
+	log.Info("Successfully generated PDF overview: %s", outputFile)
+	return nil
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/accounting"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/export"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/spf13/cobra"
+)
+
+// periodizeOptions holds the settings of the periodize command
+type periodizeOptions struct {
+	// Format is the output format: sie, beancount or hledger
+	Format string
+
+	// From and To limit the entries to a date range, zero values are open ends
+	From time.Time
+	To   time.Time
+
+	// OpenAccounts declares the used accounts in ledger output
+	OpenAccounts bool
+}
+
+// periodizeCmd represents the periodize command
+var periodizeCmd = &cobra.Command{
+	Use:   "periodize [json files or directories...]",
+	Short: "Create monthly accrual entries for documents covering several months",
+	Long: `Create accrual entries that spread the cost of invoices and receipts over their
+service period, e.g. an annual licence over twelve months.
+
+The documents themselves are booked with export sie, beancount or hledger. For every
+document whose period_start and period_end span more than one month, periodize adds:
+
+  - an entry on the booking date moving the expense to the prepaid expense account
+    (BAS 1790, accounting.prepaid_account or ledger.prepaid_account)
+  - one entry per month, dated the last day of the month, moving the month's share
+    back to the expense account
+
+The months get equal amounts, the rounding difference lands in the last month. VAT and
+the supplier debt are not affected. Months that ended before the booking date are
+dated on the booking date.
+
+Use --from and --to to write the entries of one fiscal year, SIE files cover a
+single fiscal year.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputFile, _ := cmd.Flags().GetString("output")
+		opts := periodizeOptions{}
+		opts.Format, _ = cmd.Flags().GetString("format")
+		opts.OpenAccounts, _ = cmd.Flags().GetBool("open-accounts")
+		if opts.Format != "sie" && opts.Format != "beancount" && opts.Format != "hledger" {
+			return fmt.Errorf("invalid format %q, use sie, beancount or hledger", opts.Format)
+		}
+		for _, flag := range []struct {
+			name   string
+			target *time.Time
+		}{{"from", &opts.From}, {"to", &opts.To}} {
+			value, _ := cmd.Flags().GetString(flag.name)
+			if value == "" {
+				continue
+			}
+			date, err := time.Parse(document.DateLayout, value)
+			if err != nil {
+				return fmt.Errorf("invalid --%s date %q, use YYYY-MM-DD", flag.name, value)
+			}
+			*flag.target = date
+		}
+
+		cfg, err := loadConfig(logger)
+		if err != nil {
+			return err
+		}
+		if cmd.Flags().Changed("series") {
+			cfg.Accounting.SIE.Series, _ = cmd.Flags().GetString("series")
+		}
+		if cmd.Flags().Changed("start-number") {
+			cfg.Accounting.SIE.StartNumber, _ = cmd.Flags().GetInt("start-number")
+		}
+
+		return runPeriodize(args, outputFile, opts, cfg, logger)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(periodizeCmd)
+	periodizeCmd.Flags().StringP("output", "o", "", "Path to the output file (required)")
+	periodizeCmd.Flags().String("format", "sie", "Output format (sie, beancount, hledger)")
+	periodizeCmd.Flags().String("from", "", "Only write entries dated on or after this date (YYYY-MM-DD)")
+	periodizeCmd.Flags().String("to", "", "Only write entries dated on or before this date (YYYY-MM-DD)")
+	periodizeCmd.Flags().String("series", "", "Verification series of the SIE output (default \"A\")")
+	periodizeCmd.Flags().Int("start-number", 0, "Number of the first verification of the SIE output (0 lets the importing program number them)")
+	periodizeCmd.Flags().Bool("open-accounts", true, "Declare/open all used accounts at the top of ledger output")
+	periodizeCmd.MarkFlagRequired("output")
+}
+
+// runPeriodize handles the periodize command logic
+func runPeriodize(inputs []string, outputFile string, opts periodizeOptions, cfg *config.Config, log interfaces.Logger) error {
+	log.Info("Starting %s periodisation to: %s", opts.Format, outputFile)
+
+	if checkExportOutput(outputFile, log) {
+		return nil
+	}
+
+	var booker *accounting.Booker
+	var ledgerBooker *accounting.LedgerBooker
+	if opts.Format == "sie" {
+		sieBooker, err := accounting.NewBooker(cfg.Accounting)
+		if err != nil {
+			log.Error("Invalid accounting configuration: %v", err)
+			return fmt.Errorf("invalid accounting configuration: %w", err)
+		}
+		booker = sieBooker
+	} else {
+		ledger, err := accounting.NewLedgerBooker(cfg.Ledger)
+		if err != nil {
+			log.Error("Invalid ledger configuration: %v", err)
+			return fmt.Errorf("invalid ledger configuration: %w", err)
+		}
+		ledgerBooker = ledger
+	}
+
+	docs, err := loadExportDocuments(inputs, log)
+	if err != nil {
+		return err
+	}
+
+	var verifications []accounting.Verification
+	var transactions []accounting.LedgerTransaction
+	var periodized, entries int
+	var skipped []accounting.Skipped
+	if opts.Format == "sie" {
+		verifications, periodized, skipped = booker.Periodize(docs)
+		verifications = filterVerifications(verifications, opts.From, opts.To)
+		entries = len(verifications)
+	} else {
+		transactions, periodized, skipped = ledgerBooker.Periodize(docs)
+		transactions = filterLedgerTransactions(transactions, opts.From, opts.To)
+		entries = len(transactions)
+	}
+
+	for _, skip := range skipped {
+		log.Warn("Skipping %s: %s", skip.Path, skip.Reason)
+	}
+	log.Info("Periodised %d document(s) into %d entries, skipped %d document(s)", periodized, entries, len(skipped))
+
+	outputFileHandle, err := os.Create(outputFile)
+	if err != nil {
+		log.Error("Failed to create output file %s: %v", outputFile, err)
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outputFileHandle.Close()
+
+	switch opts.Format {
+	case "sie":
+		sieOpts := export.SIEOptions{
+			ProgramName:          appName,
+			ProgramVersion:       appVersion,
+			CompanyName:          cfg.Company.Name,
+			OrgNumber:            cfg.Company.OrgNumber,
+			Series:               cfg.Accounting.SIE.Series,
+			StartNumber:          cfg.Accounting.SIE.StartNumber,
+			FiscalYearStartMonth: cfg.Accounting.SIE.FiscalYearStartMonth,
+		}
+		if start, _, ok := export.SIEFiscalYear(verifications, sieOpts.FiscalYearStartMonth); ok {
+			for _, verification := range verifications {
+				if verification.Date.Before(start) {
+					log.Warn("Entries span several fiscal years, use --from and --to to write one fiscal year per file")
+					break
+				}
+			}
+		}
+		err = export.WriteSIE(outputFileHandle, verifications, booker, sieOpts)
+	case "beancount", "hledger":
+		write := export.WriteBeancount
+		if opts.Format == "hledger" {
+			write = export.WriteHledger
+		}
+		err = write(outputFileHandle, transactions, export.LedgerOptions{
+			BaseCurrency: ledgerBooker.BaseCurrency(),
+			OpenAccounts: opts.OpenAccounts,
+			Header:       fmt.Sprintf("Accruals generated by %s %s on %s", appName, appVersion, time.Now().Format("2006-01-02 15:04:05")),
+		})
+	}
+	if err != nil {
+		log.Error("Failed to write %s file: %v", opts.Format, err)
+		return fmt.Errorf("failed to write %s file: %w", opts.Format, err)
+	}
+
+	log.Info("Successfully wrote %d accrual entries to %s", entries, outputFile)
+
+	return nil
+}
+
+// filterVerifications returns the verifications dated within from and to, zero dates are open ends
+func filterVerifications(verifications []accounting.Verification, from, to time.Time) []accounting.Verification {
+	var filtered []accounting.Verification
+	for _, verification := range verifications {
+		if inDateRange(verification.Date, from, to) {
+			filtered = append(filtered, verification)
+		}
+	}
+	return filtered
+}
+
+// filterLedgerTransactions returns the transactions dated within from and to, zero dates are open ends
+func filterLedgerTransactions(transactions []accounting.LedgerTransaction, from, to time.Time) []accounting.LedgerTransaction {
+	var filtered []accounting.LedgerTransaction
+	for _, transaction := range transactions {
+		if inDateRange(transaction.Date, from, to) {
+			filtered = append(filtered, transaction)
+		}
+	}
+	return filtered
+}
+
+// inDateRange reports whether date is within from and to, zero dates are open ends
+func inDateRange(date, from, to time.Time) bool {
+	return (from.IsZero() || !date.Before(from)) && (to.IsZero() || !date.After(to))
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/prompts"
+	"github.com/spf13/cobra"
+)
+
+// promptCmd represents the prompt command
+var promptCmd = &cobra.Command{
+	Use:   "prompt",
+	Short: "Work with the prompts of the AI provider",
+	Long: `Work with the versioned prompts used by the extract command.
+
+The built-in prompts are embedded in the binary. A prompt can be written to disk with
+"prompt dump", edited and then selected with prompt.file in the config. Every extraction
+records the version and SHA-256 of its prompt in the "prompt" field of the output.`,
+}
+
+// promptListCmd represents the prompt list command
+var promptListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the built-in prompts and the configured one",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg, err := loadConfig(logger)
+		if err != nil {
+			return err
+		}
+		return runPromptList(cfg.Prompt.Version, cfg.Prompt.File, logger)
+	},
+}
+
+// promptDumpCmd represents the prompt dump command
+var promptDumpCmd = &cobra.Command{
+	Use:   "dump [version]",
+	Short: "Write a built-in prompt as a starting point for a custom one",
+	Long: `Write a built-in prompt to stdout or to a file.
+
+Without an argument the default prompt (` + prompts.DefaultVersion + `) is written. If --output is a
+directory, the prompt is written as <version>.tmpl inside it. Rename the file to give the
+custom prompt its own version, the file name is the version recorded in the output.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		version := prompts.DefaultVersion
+		if len(args) == 1 {
+			version = args[0]
+		}
+		outputPath, _ := cmd.Flags().GetString("output")
+		force, _ := cmd.Flags().GetBool("force")
+		return runPromptDump(version, outputPath, force, logger)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(promptCmd)
+	promptCmd.AddCommand(promptListCmd)
+	promptCmd.AddCommand(promptDumpCmd)
+	promptDumpCmd.Flags().StringP("output", "o", "", "Output file or directory (default stdout)")
+	promptDumpCmd.Flags().Bool("force", false, "Overwrite an existing output file")
+}
+
+// runPromptList prints the built-in prompts with their hashes, marking the configured one
+func runPromptList(version string, file string, log interfaces.Logger) error {
+	if version == "" {
+		version = prompts.DefaultVersion
+	}
+	for _, v := range prompts.Versions() {
+		prompt, err := prompts.Load(v)
+		if err != nil {
+			log.Error("Built-in prompt %s is invalid: %v", v, err)
+			return err
+		}
+		marker := " "
+		if file == "" && v == version {
+			marker = "*"
+		}
+		fmt.Printf("%s %-20s %s\n", marker, prompt.Version(), prompt.Hash())
+	}
+
+	if file != "" {
+		prompt, err := prompts.LoadFile(file)
+		if err != nil {
+			log.Error("Invalid prompt file: %v", err)
+			return err
+		}
+		fmt.Printf("* %-20s %s (%s)\n", prompt.Version(), prompt.Hash(), file)
+	}
+	return nil
+}
+
+// runPromptDump handles the prompt dump command logic
+func runPromptDump(version string, outputPath string, force bool, log interfaces.Logger) error {
+	content, ok := prompts.Source(version)
+	if !ok {
+		log.Error("Unknown prompt: %s", version)
+		return fmt.Errorf("unknown prompt %q (available: %s)", version, strings.Join(prompts.Versions(), ", "))
+	}
+
+	if outputPath == "" {
+		fmt.Print(content)
+		return nil
+	}
+
+	if info, err := os.Stat(outputPath); err == nil && info.IsDir() {
+		outputPath = filepath.Join(outputPath, version+".tmpl")
+	}
+
+	if _, err := os.Stat(outputPath); err == nil && !force {
+		log.Warn("Output file already exists: %s (use --force to overwrite)", outputPath)
+		return nil
+	}
+
+	if err := os.WriteFile(outputPath, []byte(content), 0644); err != nil {
+		log.Error("Failed to write prompt %s: %v", outputPath, err)
+		return fmt.Errorf("failed to write prompt: %w", err)
+	}
+
+	log.Info("Wrote built-in prompt %s to %s", version, outputPath)
+	return nil
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/i18n"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/locale"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/report"
+)
+
+//go:embed report-template.html
+var reportTemplateContent string
+
+// reportCmd represents the report command
+var reportCmd = &cobra.Command{
+	Use:   "report [json files or directories...]",
+	Short: "Generate one HTML summary report from many extracted JSON files",
+	Long: `Generate a single printable HTML report from many JSON files produced by the extract command.
+
+The report contains a sortable table of all documents, totals per original currency and
+in SEK, VAT totals, subtotals per category and per company, and a detail section for
+every document linked from the tables.
+
+Inputs may be JSON files or directories, directories are searched recursively for *.json files.
+Use --template for a custom template file or a directory with report.html and partials (*.tmpl).`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		outputFile, _ := cmd.Flags().GetString("output")
+		title, _ := cmd.Flags().GetString("title")
+
+		cfg, err := loadConfig(logger)
+		if err != nil {
+			return err
+		}
+		templatePath := cfg.HTML.ReportTemplate
+		if cmd.Flags().Changed("template") {
+			templatePath, _ = cmd.Flags().GetString("template")
+		}
+		loc, tr, err := htmlLocalization(cmd, cfg.HTML, logger)
+		if err != nil {
+			return err
+		}
+		if !cmd.Flags().Changed("title") {
+			title = tr.T("report.title")
+		}
+
+		return runReport(args, outputFile, title, templatePath, loc, tr, logger)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportCmd)
+	reportCmd.Flags().StringP("output", "o", "", "Path to the output HTML file (required)")
+	reportCmd.Flags().String("title", "", "Title shown in the report header (default is a translated \"Receipt/Invoice Summary\")")
+	reportCmd.Flags().String("template", "", "Custom template file or directory with report.html and partials")
+	reportCmd.Flags().String("lang", "", "Language of the labels (en, sv)")
+	reportCmd.Flags().String("locale", "", "Locale for numbers and dates (en, sv-SE), defaults to the locale of --lang")
+	reportCmd.MarkFlagRequired("output")
+}
+
+// ReportTemplateData represents the data structure passed to the report template
+type ReportTemplateData struct {
+	Report      *report.Report `json:"report"`
+	ProcessedAt string         `json:"processed_at"`
+}
+
+// reportGroupTable is passed to the "groups" template to render one subtotal table
+type reportGroupTable struct {
+	Title  string
+	Groups []document.Group
+	Report *report.Report
+}
+
+// runReport handles the report command logic
+func runReport(inputs []string, outputFile string, title string, templatePath string, loc locale.Locale, tr *i18n.Translator, log interfaces.Logger) error {
+	log.Info("Starting summary report generation")
+
+	if checkExportOutput(outputFile, log) {
+		return nil
+	}
+
+	docs, err := loadExportDocuments(inputs, log)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	rep := report.Build(docs, title, now)
+
+	funcMap := templateFuncs(loc, tr)
+	funcMap["groupTable"] = func(title string, groups []document.Group, r *report.Report) reportGroupTable {
+		return reportGroupTable{Title: title, Groups: groups, Report: r}
+	}
+
+	if templatePath != "" {
+		log.Info("Using custom template: %s", templatePath)
+	}
+	tmpl, err := loadHTMLTemplate("report", reportTemplateContent, templatePath, funcMap)
+	if err != nil {
+		log.Error("Failed to parse report template: %v", err)
+		return fmt.Errorf("failed to parse report template: %w", err)
+	}
+
+	outputFileHandle, err := os.Create(outputFile)
+	if err != nil {
+		log.Error("Failed to create output file %s: %v", outputFile, err)
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer outputFileHandle.Close()
+
+	templateData := ReportTemplateData{
+		Report:      rep,
+		ProcessedAt: now.Format("2006-01-02 15:04:05"),
+	}
+	if err := tmpl.Execute(outputFileHandle, templateData); err != nil {
+		log.Error("Failed to execute report template: %v", err)
+		return fmt.Errorf("failed to execute report template: %w", err)
+	}
+
+	log.Info("Successfully generated summary report: %s", outputFile)
+	log.Info("Documents: %d, total: %s SEK, VAT: %s SEK", len(rep.Entries),
+		locale.English.FormatCents(rep.SEKCents, true), locale.English.FormatCents(rep.VatCents, true))
+	for _, currency := range rep.Currencies {
+		log.Info("  %s: %s (%d document(s))", currency.Currency,
+			locale.English.FormatCents(currency.AmountCents, true), currency.Count)
+	}
+	if rep.MissingSEK > 0 {
+		log.Warn("%d document(s) have no SEK amount and are not part of the SEK total", rep.MissingSEK)
+	}
+	if rep.NotBooked > 0 {
+		log.Info("%d document(s) are reminders, quotes or other types that are not booked and not part of the totals", rep.NotBooked)
+	}
+
+	return nil
+}
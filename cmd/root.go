@@ -1,15 +1,26 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
 	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
 	pkglogger "github.com/scalebit-com/reciept-invoice-ai-tool/pkg/logger"
 )
 
 var logger interfaces.Logger
 
+// cfgFile is the path given with --config, empty means the default lookup
+var cfgFile string
+
+// appName and appVersion identify the tool in generated files
+const appName = "reciept-invoice-ai-tool"
+
+var appVersion = "dev"
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:   "reciept-invoice-ai-tool",
@@ -21,6 +32,14 @@ The tool can process text (.txt) and markdown (.md) files containing receipt dat
 and parse information such as store name, date, items, prices, and totals.`,
 }
 
+// SetVersion sets the version reported by --version and written to generated files
+func SetVersion(version string) {
+	if version != "" {
+		appVersion = version
+		rootCmd.Version = version
+	}
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
@@ -28,8 +47,14 @@ func Execute() {
 	logger = pkglogger.NewColorLogger()
 	
 	err := rootCmd.Execute()
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		// The command has already reported why
+		os.Exit(exitErr.code)
+	}
 	if err != nil {
-		logger.Error("Command execution failed: %v", err)
+		// stderr keeps the stdout of commands writing JSON clean
+		pkglogger.NewColorLoggerTo(os.Stderr).Error("Command execution failed: %v", err)
 		os.Exit(1)
 	}
 }
@@ -39,9 +64,39 @@ func init() {
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
-	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.reciept-invoice-ai-tool.yaml)")
+	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is ./.reciept-invoice-ai-tool.yaml, then $HOME/.reciept-invoice-ai-tool.yaml)")
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
 	rootCmd.Flags().BoolP("toggle", "t", false, "Help message for toggle")
-}
\ No newline at end of file
+}
+
+// exitError makes Execute exit with a specific code instead of 1
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// loadConfig loads the configuration file selected with --config
+func loadConfig(log interfaces.Logger) (*config.Config, error) {
+	cfg, err := config.LoadConfig(cfgFile)
+	if err != nil {
+		log.Error("Failed to load configuration: %v", err)
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if cfg.Path != "" {
+		log.Info("Loaded configuration from: %s", cfg.Path)
+	} else {
+		log.Debug("No configuration file found, using defaults")
+	}
+	return cfg, nil
+}
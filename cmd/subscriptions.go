@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/locale"
+	pkglogger "github.com/scalebit-com/reciept-invoice-ai-tool/pkg/logger"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/subscriptions"
+	"github.com/spf13/cobra"
+)
+
+// subscriptionsCmd represents the subscriptions command
+var subscriptionsCmd = &cobra.Command{
+	Use:   "subscriptions [json files or directories...]",
+	Short: "Find recurring charges, price changes and missed months in extracted documents",
+	Long: `Group the invoices and receipts of an archive by vendor and currency and report the
+subscriptions among them.
+
+A vendor's documents are a subscription if one of them was extracted as recurring, or if at
+least --min-charges of them are one billing interval (monthly, quarterly, semiannual, yearly)
+apart. The month of a charge is the start of its service period, or else its issue date.
+
+For every subscription the charges, the price changes between consecutive charges, the months
+between the first and last charge without one, and the month of the next charge are listed.
+Subscriptions whose next charge is overdue are marked as lapsed: they were cancelled or their
+latest documents are missing from the archive.
+
+With --format json the result is written to stdout as JSON and log messages go to stderr.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid format %q, use text or json", format)
+		}
+		minCharges, _ := cmd.Flags().GetInt("min-charges")
+
+		log := logger
+		if format == "json" {
+			// Keep stdout for the result
+			log = pkglogger.NewColorLoggerTo(os.Stderr)
+		}
+
+		if _, err := loadConfig(log); err != nil {
+			return err
+		}
+
+		return runSubscriptions(args, subscriptions.Options{MinCharges: minCharges}, format, log)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(subscriptionsCmd)
+	subscriptionsCmd.Flags().String("format", "text", "Output format (text, json)")
+	subscriptionsCmd.Flags().Int("min-charges", subscriptions.DefaultMinCharges, "Regular charges that make a subscription when no document is marked recurring")
+}
+
+// runSubscriptions handles the subscriptions command logic
+func runSubscriptions(inputs []string, opts subscriptions.Options, format string, log interfaces.Logger) error {
+	docs, err := loadExportDocuments(inputs, log)
+	if err != nil {
+		return err
+	}
+
+	found := subscriptions.Detect(docs, opts)
+
+	if format == "json" {
+		if found == nil {
+			found = []subscriptions.Subscription{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(found); err != nil {
+			log.Error("Failed to write result: %v", err)
+			return fmt.Errorf("failed to write result: %w", err)
+		}
+	} else {
+		for _, s := range found {
+			printSubscription(s)
+		}
+	}
+
+	lapsed, changes, missed := 0, 0, 0
+	for _, s := range found {
+		if s.Lapsed {
+			lapsed++
+		}
+		changes += len(s.PriceChanges)
+		missed += len(s.Missed)
+	}
+	log.Info("Found %d subscription(s) in %d document(s): %d price change(s), %d missed charge(s), %d lapsed",
+		len(found), len(docs), changes, missed, lapsed)
+	return nil
+}
+
+// printSubscription writes a subscription with its findings as text
+func printSubscription(s subscriptions.Subscription) {
+	amount := func(cents int) string {
+		return strings.TrimSpace(locale.English.FormatCents(cents, true) + " " + s.Currency)
+	}
+
+	interval := s.Interval
+	switch {
+	case interval == "":
+		interval = "unknown interval"
+	case s.Inferred:
+		interval += " (inferred)"
+	}
+	first, last := s.Charges[0].Month, s.Charges[len(s.Charges)-1].Month
+	fmt.Printf("%s: %s, %s, %d charge(s) %s – %s\n", s.Vendor, amount(s.LatestCents()), interval, len(s.Charges), first, last)
+
+	if s.YearlySEKCents != nil {
+		fmt.Printf("  cost per year:  %s SEK\n", locale.English.FormatCents(*s.YearlySEKCents, true))
+	}
+	for _, change := range s.PriceChanges {
+		fmt.Printf("  price change:   %s %s → %s (%s)\n", change.Month, amount(change.FromCents), amount(change.ToCents), change.File)
+	}
+	if len(s.Missed) > 0 {
+		fmt.Printf("  missed:         %s\n", strings.Join(s.Missed, ", "))
+	}
+	switch {
+	case s.Lapsed:
+		fmt.Printf("  lapsed:         next charge was expected %s\n", s.NextMonth)
+	case s.NextMonth != "":
+		fmt.Printf("  next charge:    %s\n", s.NextMonth)
+	}
+}
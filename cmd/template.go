@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// builtinTemplates maps template names to the templates embedded in the binary
+var builtinTemplates = map[string]string{
+	"overview": htmlTemplateContent,
+	"report":   reportTemplateContent,
+}
+
+// templateCmd represents the template command
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Work with the HTML templates",
+	Long: `Work with the HTML templates used by htmloverview and report.
+
+The built-in templates can be written to disk with "template dump", edited and then
+passed back with --template (or html.template / html.report_template in the config).`,
+}
+
+// templateDumpCmd represents the template dump command
+var templateDumpCmd = &cobra.Command{
+	Use:   "dump [" + strings.Join(builtinTemplateNames(), "|") + "]",
+	Short: "Write a built-in HTML template as a starting point for a custom one",
+	Long: `Write a built-in HTML template to stdout or to a file.
+
+Without an argument the htmloverview template ("overview") is written. If --output
+is a directory, the template is written as <name>.html inside it, the layout expected
+by --template <directory>.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name := "overview"
+		if len(args) == 1 {
+			name = args[0]
+		}
+		outputPath, _ := cmd.Flags().GetString("output")
+		force, _ := cmd.Flags().GetBool("force")
+		return runTemplateDump(name, outputPath, force, logger)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(templateCmd)
+	templateCmd.AddCommand(templateDumpCmd)
+	templateDumpCmd.Flags().StringP("output", "o", "", "Output file or directory (default stdout)")
+	templateDumpCmd.Flags().Bool("force", false, "Overwrite an existing output file")
+}
+
+// runTemplateDump handles the template dump command logic
+func runTemplateDump(name string, outputPath string, force bool, log interfaces.Logger) error {
+	content, ok := builtinTemplates[name]
+	if !ok {
+		log.Error("Unknown template: %s", name)
+		return fmt.Errorf("unknown template %q (available: %s)", name, strings.Join(builtinTemplateNames(), ", "))
+	}
+
+	if outputPath == "" {
+		fmt.Print(content)
+		return nil
+	}
+
+	if info, err := os.Stat(outputPath); err == nil && info.IsDir() {
+		outputPath = filepath.Join(outputPath, name+".html")
+	}
+
+	if _, err := os.Stat(outputPath); err == nil && !force {
+		log.Warn("Output file already exists: %s (use --force to overwrite)", outputPath)
+		return nil
+	}
+
+	if err := os.WriteFile(outputPath, []byte(content), 0644); err != nil {
+		log.Error("Failed to write template %s: %v", outputPath, err)
+		return fmt.Errorf("failed to write template: %w", err)
+	}
+
+	log.Info("Wrote built-in %s template to %s", name, outputPath)
+	return nil
+}
+
+// builtinTemplateNames returns the sorted names of the built-in templates
+func builtinTemplateNames() []string {
+	names := make([]string, 0, len(builtinTemplates))
+	for name := range builtinTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
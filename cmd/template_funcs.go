@@ -0,0 +1,314 @@
+package cmd
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/evidence"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/extra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/i18n"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/locale"
+)
+
+// templateFuncs returns the functions available to HTML templates (htmloverview and report)
+// Amount arguments accept numbers and pointers to numbers, nil pointers render as "".
+// String arguments accept strings and pointers to strings.
+//
+//	t key [args]                     message from the --lang catalogue, args are formatted with printf
+//	docType value                    translated DocumentType ("Receipt" is "Kvitto" in Swedish)
+//	interval value                   translated billing interval ("monthly" is "Månadsvis" in Swedish)
+//	lang                             language code of the catalogue ("en", "sv")
+//	date date                        YYYY-MM-DD date in the locale's date format
+//	lower s / upper s / trim s       change case, trim whitespace
+//	default fallback s               s, or fallback if s is empty
+//	str s                            dereference a *string ("" if nil)
+//	join sep list                    join a list of strings
+//	truncate n s                     shorten s to at most n characters
+//	formatDate layout date           reformat a YYYY-MM-DD date with a Go layout ("02 Jan 2006")
+//	now layout                       current time formatted with a Go layout
+//	money amount [currency]          amount with two decimals in the configured locale, e.g. "1,096.77 SEK"
+//	moneyIn locale amount [currency] same as money in an explicit locale ("sv-SE" gives "1 096,77 SEK")
+//	number amount decimals           number in the configured locale with thousands grouping
+//	cents öre                        amount in öre as kronor, e.g. 109677 → "1,096.77"
+//	sek öre                          same as cents followed by " SEK"
+//	idField info name                value of the ID field with the given name (case-insensitive)
+//	hasIdField info name             true if the document has a non-empty ID field with the name
+//	bookable info                    true for invoices, receipts and credit notes, the types that are booked
+//	referencedInvoice info           number of the invoice a credit note or reminder refers to ("" if unknown)
+//	extra info name                  value of a user-defined extra field as text ("" if missing or null)
+//	extraNames info                  sorted names of the extra fields of the document
+//	evidence info field              confidence and source text of a field ("company", "id:Invoice Number"), nil if none
+//	lowConfidence info               evidence of all fields with low confidence
+//	fieldLabel field                 translated label of a field ("company" is "Företag" in Swedish)
+//	evidenceNote note                translated reason why the confidence of a field was lowered
+//	add a b / sub a b / mul a b      integer arithmetic
+//	div a b / divInt öre b           float division (0 when dividing by zero)
+//	formatFloat f                    *float64 with two decimals ("0.00" if nil)
+//	formatFloatWithCurrency f cur    *float64 with two decimals and currency
+func templateFuncs(loc locale.Locale, tr *i18n.Translator) template.FuncMap {
+	formatMoney := func(l locale.Locale, value interface{}, currency []interface{}) string {
+		f, ok := toFloat(value)
+		if !ok {
+			return ""
+		}
+		result := l.FormatDecimal(f, 2, true)
+		if len(currency) > 0 {
+			if code := strings.TrimSpace(toText(currency[0])); code != "" {
+				result += " " + code
+			}
+		}
+		return result
+	}
+
+	return template.FuncMap{
+		"t":        tr.T,
+		"docType":  tr.DocumentType,
+		"interval": tr.BillingInterval,
+		"lang":     tr.Lang,
+		"date": func(value interface{}) string {
+			date := strings.TrimSpace(toText(value))
+			t, err := time.Parse(document.DateLayout, date)
+			if err != nil {
+				return date
+			}
+			return loc.FormatDate(t)
+		},
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		"trim":  strings.TrimSpace,
+		"default": func(defaultValue, value string) string {
+			if value == "" {
+				return defaultValue
+			}
+			return value
+		},
+		"str": toText,
+		"join": func(sep string, list []string) string {
+			return strings.Join(list, sep)
+		},
+		"truncate": func(n int, value interface{}) string {
+			runes := []rune(toText(value))
+			if n < 0 || len(runes) <= n {
+				return string(runes)
+			}
+			return string(runes[:n])
+		},
+		"formatDate": func(layout string, value interface{}) string {
+			date := strings.TrimSpace(toText(value))
+			t, err := time.Parse(document.DateLayout, date)
+			if err != nil {
+				return date
+			}
+			return t.Format(layout)
+		},
+		"now": func(layout string) string {
+			return time.Now().Format(layout)
+		},
+		"money": func(value interface{}, currency ...interface{}) string {
+			return formatMoney(loc, value, currency)
+		},
+		"moneyIn": func(tag string, value interface{}, currency ...interface{}) (string, error) {
+			l, err := locale.Lookup(tag)
+			if err != nil {
+				return "", err
+			}
+			return formatMoney(l, value, currency), nil
+		},
+		"number": func(value interface{}, decimals int) string {
+			f, ok := toFloat(value)
+			if !ok {
+				return ""
+			}
+			return loc.FormatDecimal(f, decimals, true)
+		},
+		"cents": func(value interface{}) string {
+			f, ok := toFloat(value)
+			if !ok {
+				return ""
+			}
+			return loc.FormatDecimal(f/100, 2, true)
+		},
+		"sek": func(value interface{}) string {
+			f, ok := toFloat(value)
+			if !ok {
+				return ""
+			}
+			return loc.FormatDecimal(f/100, 2, true) + " SEK"
+		},
+		"idField": func(info *interfaces.ReceiptInvoiceInfo, name string) string {
+			if info == nil {
+				return ""
+			}
+			return document.IdFieldValue(info, name)
+		},
+		"hasIdField": func(info *interfaces.ReceiptInvoiceInfo, name string) bool {
+			return info != nil && strings.TrimSpace(document.IdFieldValue(info, name)) != ""
+		},
+		"bookable": func(info *interfaces.ReceiptInvoiceInfo) bool {
+			return info != nil && document.Bookable(info)
+		},
+		"referencedInvoice": func(info *interfaces.ReceiptInvoiceInfo) string {
+			if info == nil {
+				return ""
+			}
+			return document.ReferencedInvoice(info)
+		},
+		"extra": func(info *interfaces.ReceiptInvoiceInfo, name string) string {
+			if info == nil {
+				return ""
+			}
+			return extra.Value(info, name)
+		},
+		"extraNames": func(info *interfaces.ReceiptInvoiceInfo) []string {
+			if info == nil {
+				return nil
+			}
+			return extra.Names(info)
+		},
+		"evidence": func(info *interfaces.ReceiptInvoiceInfo, field string) *interfaces.FieldEvidence {
+			if info == nil {
+				return nil
+			}
+			return evidence.Find(info, field)
+		},
+		"lowConfidence": func(info *interfaces.ReceiptInvoiceInfo) []interfaces.FieldEvidence {
+			if info == nil {
+				return nil
+			}
+			return evidence.Low(info)
+		},
+		"fieldLabel": func(field string) string {
+			return sourceFieldLabel(field, tr)
+		},
+		"evidenceNote": func(note string) string {
+			if key, ok := evidenceNoteKeys[note]; ok {
+				return tr.T(key)
+			}
+			return note
+		},
+		"add": func(a, b int) int { return a + b },
+		"sub": func(a, b int) int { return a - b },
+		"mul": func(a, b int) int { return a * b },
+		"div": func(a, b float64) float64 {
+			if b == 0 {
+				return 0
+			}
+			return a / b
+		},
+		"divInt": func(a *int, b float64) float64 {
+			if a == nil || b == 0 {
+				return 0
+			}
+			return float64(*a) / b
+		},
+		"formatFloat": func(f *float64) string {
+			if f == nil {
+				return "0.00"
+			}
+			return fmt.Sprintf("%.2f", *f)
+		},
+		"formatFloatWithCurrency": func(f *float64, currency *string) string {
+			if f == nil {
+				return "0.00"
+			}
+			if currency == nil || *currency == "" {
+				return fmt.Sprintf("%.2f", *f)
+			}
+			return fmt.Sprintf("%.2f %s", *f, *currency)
+		},
+	}
+}
+
+// evidenceNoteKeys are the catalogue keys of the notes set by evidence.Verify
+var evidenceNoteKeys = map[string]string{
+	evidence.NoteNoEvidence:        "overview.evidence.note.no_evidence",
+	evidence.NoteNoSnippet:         "overview.evidence.note.no_snippet",
+	evidence.NoteSnippetNotFound:   "overview.evidence.note.snippet_not_found",
+	evidence.NoteValueNotInSnippet: "overview.evidence.note.value_not_in_snippet",
+}
+
+// loadHTMLTemplate parses the built-in template, or a custom one if path is set
+// path may be a template file or a directory. In a directory, <name>.html is the main
+// template and all *.tmpl files are parsed as partials, so one directory can hold the
+// templates of several commands. Partials are included with {{template "file.tmpl" .}}
+// or by the names of their {{define}} blocks.
+func loadHTMLTemplate(name string, builtin string, path string, funcs template.FuncMap) (*template.Template, error) {
+	if path == "" {
+		return template.New(name).Funcs(funcs).Parse(builtin)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("template not found: %w", err)
+	}
+
+	if !info.IsDir() {
+		return template.New(filepath.Base(path)).Funcs(funcs).ParseFiles(path)
+	}
+
+	mainName := name + ".html"
+	mainFile := filepath.Join(path, mainName)
+	if _, err := os.Stat(mainFile); err != nil {
+		return nil, fmt.Errorf("template directory %s has no %s", path, mainName)
+	}
+
+	partials, err := filepath.Glob(filepath.Join(path, "*.tmpl"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(partials)
+	files := append([]string{mainFile}, partials...)
+
+	return template.New(mainName).Funcs(funcs).ParseFiles(files...)
+}
+
+// toFloat converts numbers and pointers to numbers, false for nil and other types
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case *float64:
+		if v == nil {
+			return 0, false
+		}
+		return *v, true
+	case int:
+		return float64(v), true
+	case *int:
+		if v == nil {
+			return 0, false
+		}
+		return float64(*v), true
+	case int64:
+		return float64(v), true
+	case float32:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// toText converts strings and pointers to strings, "" for nil
+func toText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case *string:
+		return document.StringValue(v)
+	case fmt.Stringer:
+		return v.String()
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(value)
+}
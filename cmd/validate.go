@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	pkglogger "github.com/scalebit-com/reciept-invoice-ai-tool/pkg/logger"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/validate"
+	"github.com/spf13/cobra"
+)
+
+// validateExitCode is the exit code when findings reach the --fail-on severity
+const validateExitCode = 2
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate [json files or directories...]",
+	Short: "Check extracted JSON files for implausible or missing values",
+	Long: `Run a set of rules over JSON files produced by the extract command and report
+errors and warnings:
+
+  required_fields  fields required for the document type are present
+  date             date_issued is a YYYY-MM-DD date and not in the future
+  period           period_start and period_end are dates in order around date_issued,
+                   billing_interval is known
+  currency         original_currency is an ISO 4217 currency code
+  vat              original_vat_amount matches a Swedish VAT rate (25, 12, 6, 0) on documents in SEK
+  sek_amount       se_cent_amount agrees with original_amount and the exchange rate
+  credit_note      credit notes are negative and reference an invoice, invoices and receipts are positive
+  cross_check      extracted values do not conflict with rule-based pre-extraction
+  ensemble         the backends of an ensemble extraction agree well enough to skip review
+
+The severity of each rule (error, warning, off), the VAT rates, exchange rates and required
+fields are set in the validate section of the config file. --rule overrides single rules.
+
+With --format json the result is written to stdout as JSON and log messages go to stderr.
+The exit code is 0 if the documents pass, 2 if findings reach the --fail-on severity
+(default error) and 1 if the command itself fails.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cmd.SilenceUsage = true
+		format, _ := cmd.Flags().GetString("format")
+		if format != "text" && format != "json" {
+			return fmt.Errorf("invalid format %q, use text or json", format)
+		}
+
+		log := logger
+		if format == "json" {
+			// Keep stdout for the result
+			log = pkglogger.NewColorLoggerTo(os.Stderr)
+		}
+
+		cfg, err := loadConfig(log)
+		if err != nil {
+			return err
+		}
+
+		failOn := cfg.Validate.FailOn
+		if cmd.Flags().Changed("fail-on") {
+			failOn, _ = cmd.Flags().GetString("fail-on")
+		}
+		rules, _ := cmd.Flags().GetStringToString("rule")
+
+		opts, failSeverity, err := validateOptions(cfg.Validate, rules, failOn)
+		if err != nil {
+			log.Error("Invalid validate configuration: %v", err)
+			return err
+		}
+
+		if listRules, _ := cmd.Flags().GetBool("list-rules"); listRules {
+			return runValidateListRules(opts, log)
+		}
+
+		err = runValidate(args, opts, failSeverity, format, log)
+		if _, ok := err.(*exitError); ok {
+			cmd.SilenceErrors = true
+		}
+		return err
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(validateCmd)
+	validateCmd.Flags().String("format", "text", "Output format (text, json)")
+	validateCmd.Flags().String("fail-on", string(validate.SeverityError), "Lowest severity that fails validation (error, warning, off)")
+	validateCmd.Flags().StringToString("rule", nil, "Set the severity of a rule, e.g. --rule vat=error,date=off")
+	validateCmd.Flags().Bool("list-rules", false, "List the rules with their configured severity, including --rule overrides, and exit")
+}
+
+// validateOptions builds validator options from the config and --rule overrides
+func validateOptions(cfg config.ValidateConfig, rules map[string]string, failOn string) (validate.Options, validate.Severity, error) {
+	opts := validate.Options{
+		Severities:            make(map[string]validate.Severity),
+		MaxFutureDays:         cfg.MaxFutureDays,
+		PeriodToleranceDays:   cfg.PeriodToleranceDays,
+		VATRates:              cfg.VATRates,
+		VATTolerance:          cfg.VATTolerance,
+		SEKToleranceCents:     cfg.SEKTolerance,
+		ExchangeRates:         cfg.ExchangeRates,
+		ExchangeRateTolerance: cfg.ExchangeRateTolerance,
+		RequiredFields:        cfg.RequiredFields,
+	}
+	for _, overrides := range []map[string]string{cfg.Rules, rules} {
+		for name, severity := range overrides {
+			opts.Severities[name] = validate.Severity(severity)
+		}
+	}
+
+	failSeverity := validate.SeverityError
+	if failOn != "" {
+		severity, err := validate.ParseSeverity(failOn)
+		if err != nil {
+			return opts, "", fmt.Errorf("fail_on: %w", err)
+		}
+		failSeverity = severity
+	}
+	return opts, failSeverity, nil
+}
+
+// runValidateListRules prints every rule with the severity set by the config and --rule
+func runValidateListRules(opts validate.Options, log interfaces.Logger) error {
+	if _, err := validate.New(opts); err != nil {
+		log.Error("Invalid validate configuration: %v", err)
+		return fmt.Errorf("invalid validate configuration: %w", err)
+	}
+	for _, rule := range validate.Rules {
+		severity := rule.Severity
+		if configured, ok := opts.Severities[rule.Name]; ok {
+			if s, err := validate.ParseSeverity(string(configured)); err == nil {
+				severity = s
+			}
+		}
+		fmt.Printf("%-16s %-8s %s\n", rule.Name, severity, rule.Description)
+	}
+	return nil
+}
+
+// runValidate handles the validate command logic
+func runValidate(inputs []string, opts validate.Options, failOn validate.Severity, format string, log interfaces.Logger) error {
+	validator, err := validate.New(opts)
+	if err != nil {
+		log.Error("Invalid validate configuration: %v", err)
+		return fmt.Errorf("invalid validate configuration: %w", err)
+	}
+
+	docs, err := loadExportDocuments(inputs, log)
+	if err != nil {
+		return err
+	}
+
+	result := validator.ValidateAll(docs)
+
+	if format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(result); err != nil {
+			log.Error("Failed to write result: %v", err)
+			return fmt.Errorf("failed to write result: %w", err)
+		}
+	} else {
+		for _, finding := range result.Findings {
+			fmt.Printf("%s: %s [%s] %s\n", finding.File, strings.ToUpper(string(finding.Severity)), finding.Rule, finding.Message)
+		}
+	}
+
+	log.Info("Validated %d document(s): %d error(s), %d warning(s)", result.Files, result.Errors, result.Warnings)
+	if result.Failed(failOn) {
+		log.Error("Validation failed")
+		return &exitError{
+			code: validateExitCode,
+			err:  fmt.Errorf("validation failed with %d error(s) and %d warning(s)", result.Errors, result.Warnings),
+		}
+	}
+	return nil
+}
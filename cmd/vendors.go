@@ -0,0 +1,262 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/vendors"
+	"github.com/spf13/cobra"
+)
+
+// vendorsCmd represents the vendors command
+var vendorsCmd = &cobra.Command{
+	Use:   "vendors",
+	Short: "Manage the vendor registry",
+	Long: `Manage the registry of vendors, the companies we buy from.
+
+When vendors.file is set in the config, extract matches every document to a vendor by the
+organisation number in its ID fields or by its company name and aliases. The company is
+replaced by the vendor name, the Description by the vendor category and a missing currency
+is filled in. The account and VAT treatment of the vendor are used by the SIE export.`,
+}
+
+// vendorsListCmd represents the vendors list command
+var vendorsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the registered vendors",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := openVendorRegistry(cmd)
+		if err != nil {
+			return err
+		}
+		return runVendorsList(registry, logger)
+	},
+}
+
+// vendorsAddCmd represents the vendors add command
+var vendorsAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add a vendor",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := openVendorRegistry(cmd)
+		if err != nil {
+			return err
+		}
+		vendor := &vendors.Vendor{Name: args[0]}
+		setVendorFlags(cmd, vendor)
+		return runVendorsAdd(registry, vendor, logger)
+	},
+}
+
+// vendorsEditCmd represents the vendors edit command
+var vendorsEditCmd = &cobra.Command{
+	Use:   "edit <name>",
+	Short: "Change the details of a vendor",
+	Long: `Change the details of a vendor, found by its name or one of its aliases.
+Only the given flags are changed, an empty value clears a detail.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := openVendorRegistry(cmd)
+		if err != nil {
+			return err
+		}
+		return runVendorsEdit(registry, args[0], cmd, logger)
+	},
+}
+
+// vendorsLearnCmd represents the vendors learn command
+var vendorsLearnCmd = &cobra.Command{
+	Use:   "learn [json files or directories...]",
+	Short: "Learn vendors from accepted extractions",
+	Long: `Learn vendors from extracted JSON files that were checked and accepted.
+
+Unknown companies are added with the category, currency and organisation number of the
+document. For known vendors, missing details are filled in and new spellings of the name are
+added as aliases; details already in the registry are not changed.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		registry, err := openVendorRegistry(cmd)
+		if err != nil {
+			return err
+		}
+		return runVendorsLearn(registry, args, logger)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(vendorsCmd)
+	vendorsCmd.AddCommand(vendorsListCmd)
+	vendorsCmd.AddCommand(vendorsAddCmd)
+	vendorsCmd.AddCommand(vendorsEditCmd)
+	vendorsCmd.AddCommand(vendorsLearnCmd)
+	vendorsCmd.PersistentFlags().String("file", "", "Vendor registry file (default vendors.file of the config)")
+
+	for _, cmd := range []*cobra.Command{vendorsAddCmd, vendorsEditCmd} {
+		cmd.Flags().StringSlice("alias", nil, "Other spelling of the name found in documents, can be repeated")
+		cmd.Flags().String("org-number", "", "Organisation number, e.g. 556677-8899")
+		cmd.Flags().String("category", "", "Description category of the vendor's documents")
+		cmd.Flags().Int("account", 0, "BAS expense account of the vendor's documents")
+		cmd.Flags().String("currency", "", "Currency of documents that do not state one")
+		cmd.Flags().String("vat", "", "VAT treatment: domestic, foreign, reverse_charge or none")
+	}
+	vendorsEditCmd.Flags().String("name", "", "New name of the vendor")
+	vendorsEditCmd.Flags().StringSlice("add-alias", nil, "Alias to add to the existing ones, can be repeated")
+}
+
+// openVendorRegistry loads the registry of --file or vendors.file
+func openVendorRegistry(cmd *cobra.Command) (*vendors.Registry, error) {
+	cfg, err := loadConfig(logger)
+	if err != nil {
+		return nil, err
+	}
+	if cmd.Flags().Changed("file") {
+		cfg.Vendors.File, _ = cmd.Flags().GetString("file")
+	}
+	if cfg.Vendors.File == "" {
+		logger.Error("No vendor registry, set vendors.file in the config or use --file")
+		return nil, fmt.Errorf("no vendor registry configured")
+	}
+	return loadVendorRegistry(cfg, logger)
+}
+
+// loadVendorRegistry loads the registry of vendors.file, nil if none is configured
+func loadVendorRegistry(cfg *config.Config, log interfaces.Logger) (*vendors.Registry, error) {
+	if cfg.Vendors.File == "" {
+		return nil, nil
+	}
+	registry, err := vendors.Load(cfg.Vendors.File)
+	if err != nil {
+		log.Error("Failed to load vendor registry: %v", err)
+		return nil, err
+	}
+	log.Debug("Loaded %d vendor(s) from %s", len(registry.Vendors), cfg.Vendors.File)
+	return registry, nil
+}
+
+// setVendorFlags copies the vendor flags that were given on the command line to the vendor
+func setVendorFlags(cmd *cobra.Command, vendor *vendors.Vendor) {
+	if cmd.Flags().Changed("alias") {
+		vendor.Aliases, _ = cmd.Flags().GetStringSlice("alias")
+	}
+	if cmd.Flags().Changed("org-number") {
+		vendor.OrgNumber, _ = cmd.Flags().GetString("org-number")
+	}
+	if cmd.Flags().Changed("category") {
+		vendor.Category, _ = cmd.Flags().GetString("category")
+	}
+	if cmd.Flags().Changed("account") {
+		vendor.Account, _ = cmd.Flags().GetInt("account")
+	}
+	if cmd.Flags().Changed("currency") {
+		currency, _ := cmd.Flags().GetString("currency")
+		vendor.Currency = strings.ToUpper(strings.TrimSpace(currency))
+	}
+	if cmd.Flags().Changed("vat") {
+		vendor.VAT, _ = cmd.Flags().GetString("vat")
+	}
+}
+
+// runVendorsList prints every vendor with its details
+func runVendorsList(registry *vendors.Registry, log interfaces.Logger) error {
+	for _, vendor := range registry.Vendors {
+		account := ""
+		if vendor.Account != 0 {
+			account = fmt.Sprint(vendor.Account)
+		}
+		fmt.Printf("%-25s %-12s %-20s %-5s %-4s %-15s %s\n", vendor.Name, vendor.OrgNumber, vendor.Category,
+			account, vendor.Currency, vendor.VAT, strings.Join(vendor.Aliases, ", "))
+	}
+	log.Info("%d vendor(s) in %s", len(registry.Vendors), registry.Path())
+	return nil
+}
+
+// runVendorsAdd handles the vendors add command logic
+func runVendorsAdd(registry *vendors.Registry, vendor *vendors.Vendor, log interfaces.Logger) error {
+	if err := registry.Add(vendor); err != nil {
+		log.Error("Failed to add vendor: %v", err)
+		return err
+	}
+	if err := registry.Save(); err != nil {
+		log.Error("Failed to save vendor registry: %v", err)
+		return err
+	}
+	log.Info("Added vendor %s to %s", vendor.Name, registry.Path())
+	return nil
+}
+
+// runVendorsEdit handles the vendors edit command logic
+func runVendorsEdit(registry *vendors.Registry, name string, cmd *cobra.Command, log interfaces.Logger) error {
+	vendor := registry.Find(name)
+	if vendor == nil {
+		log.Error("Unknown vendor: %s", name)
+		return fmt.Errorf("vendor %q is not in the registry", name)
+	}
+
+	edited := *vendor
+	edited.Aliases = append([]string(nil), vendor.Aliases...)
+	if cmd.Flags().Changed("name") {
+		edited.Name, _ = cmd.Flags().GetString("name")
+	}
+	setVendorFlags(cmd, &edited)
+	if cmd.Flags().Changed("add-alias") {
+		aliases, _ := cmd.Flags().GetStringSlice("add-alias")
+		edited.Aliases = append(edited.Aliases, aliases...)
+	}
+
+	if err := vendors.Check(&edited); err != nil {
+		log.Error("Invalid vendor: %v", err)
+		return err
+	}
+	for _, n := range append([]string{edited.Name}, edited.Aliases...) {
+		if existing := registry.Find(n); existing != nil && existing != vendor {
+			log.Error("%q is already registered as vendor %s", n, existing.Name)
+			return fmt.Errorf("%q is already registered as vendor %s", n, existing.Name)
+		}
+	}
+
+	*vendor = edited
+	if err := registry.Save(); err != nil {
+		log.Error("Failed to save vendor registry: %v", err)
+		return err
+	}
+	log.Info("Updated vendor %s in %s", vendor.Name, registry.Path())
+	return nil
+}
+
+// runVendorsLearn learns the vendors of accepted extractions
+func runVendorsLearn(registry *vendors.Registry, inputs []string, log interfaces.Logger) error {
+	if len(inputs) == 0 {
+		log.Error("No input files or directories given")
+		return fmt.Errorf("at least one input file or directory is required")
+	}
+	docs, err := document.LoadAll(inputs)
+	if err != nil {
+		log.Error("Failed to load documents: %v", err)
+		return fmt.Errorf("failed to load documents: %w", err)
+	}
+
+	changed := 0
+	for _, doc := range docs {
+		vendor, ok := registry.Learn(doc.Info)
+		if !ok {
+			continue
+		}
+		log.Info("Learned vendor %s from %s", vendor.Name, doc.Path)
+		changed++
+	}
+	if changed == 0 {
+		log.Info("Nothing new learned from %d document(s)", len(docs))
+		return nil
+	}
+
+	if err := registry.Save(); err != nil {
+		log.Error("Failed to save vendor registry: %v", err)
+		return err
+	}
+	log.Info("Learned from %d of %d document(s), %d vendor(s) in %s", changed, len(docs), len(registry.Vendors), registry.Path())
+	return nil
+}
@@ -1,7 +1,16 @@
 package main
 
-import "github.com/scalebit-com/reciept-invoice-ai-tool/cmd"
+import (
+	_ "embed"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/cmd"
+)
+
+//go:embed version.txt
+var version string
 
 func main() {
+	cmd.SetVersion(strings.TrimSpace(version))
 	cmd.Execute()
-}
\ No newline at end of file
+}
@@ -0,0 +1,327 @@
+package accounting
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// Default BAS accounts used when the config does not specify them
+const (
+	DefaultExpenseAccount             = 6990 // Övriga externa kostnader
+	DefaultReceiptAccount             = 1930 // Företagskonto/checkkonto/affärskonto
+	DefaultInvoiceAccount             = 2440 // Leverantörsskulder
+	DefaultPrepaidAccount             = 1790 // Övriga förutbetalda kostnader och upplupna intäkter
+	DefaultDomesticVatAccount         = 2641 // Debiterad ingående moms
+	DefaultForeignVatAccount          = 2645 // Beräknad ingående moms på förvärv från utlandet
+	DefaultReverseChargeOutputAccount = 2614 // Utgående moms omvänd skattskyldighet, 25 %
+	DefaultReverseChargeRate          = 25.0
+)
+
+// VAT treatments decide how VAT on a document is booked
+const (
+	// VatDomestic books the VAT on the document to the domestic input VAT account
+	VatDomestic = "domestic"
+
+	// VatForeign books the VAT on the document to the foreign input VAT account, for foreign VAT
+	// that is refunded
+	VatForeign = "foreign"
+
+	// VatReverseCharge calculates VAT on the net amount and books it as both input and output VAT
+	VatReverseCharge = "reverse_charge"
+
+	// VatNone books the full amount as expense, the default for foreign currencies as VAT charged
+	// by a foreign seller is not deductible
+	VatNone = "none"
+)
+
+// swedishVATNumber matches a Swedish VAT registration number, e.g. SE556677889901
+var swedishVATNumber = regexp.MustCompile(`\bSE\s?\d{10}\s?01\b`)
+
+// BASAccountNames are the names of commonly used BAS accounts
+var BASAccountNames = map[int]string{
+	1790: "Övriga förutbetalda kostnader och upplupna intäkter",
+	1930: "Företagskonto/checkkonto/affärskonto",
+	2440: "Leverantörsskulder",
+	2614: "Utgående moms omvänd skattskyldighet, 25 %",
+	2641: "Debiterad ingående moms",
+	2645: "Beräknad ingående moms på förvärv från utlandet",
+	4010: "Inköp material och varor",
+	5410: "Förbrukningsinventarier",
+	5420: "Programvaror",
+	5460: "Förbrukningsmaterial",
+	6110: "Kontorsmateriel",
+	6212: "Mobiltelefon",
+	6230: "Datakommunikation",
+	6540: "IT-tjänster",
+	6550: "Konsultarvoden",
+	6570: "Bankkostnader",
+	6990: "Övriga externa kostnader",
+}
+
+// Transaction is a single posting on a BAS account, positive amounts are debit
+type Transaction struct {
+	// Account is the BAS account number
+	Account int
+
+	// AmountCents is the amount in öre, positive for debit and negative for credit
+	AmountCents int
+}
+
+// Verification is a balanced accounting entry created from one document
+type Verification struct {
+	// Date is the accounting date
+	Date time.Time
+
+	// Text describes the verification
+	Text string
+
+	// Source is the JSON file the verification was created from
+	Source string
+
+	// Transactions are the postings, they always sum to zero
+	Transactions []Transaction
+}
+
+// Skipped describes a document that could not be turned into a verification
+type Skipped struct {
+	// Path is the JSON file of the skipped document
+	Path string
+
+	// Reason explains why the document was skipped
+	Reason string
+}
+
+// Booker turns extracted documents into verifications using the accounting config
+type Booker struct {
+	cfg config.AccountingConfig
+}
+
+// NewBooker creates a Booker, missing accounts are filled with BAS defaults
+// An unknown foreign VAT treatment is an error, it would book foreign documents without VAT.
+func NewBooker(cfg config.AccountingConfig) (*Booker, error) {
+	if cfg.DefaultExpenseAccount == 0 {
+		cfg.DefaultExpenseAccount = DefaultExpenseAccount
+	}
+	if cfg.ReceiptAccount == 0 {
+		cfg.ReceiptAccount = DefaultReceiptAccount
+	}
+	if cfg.InvoiceAccount == 0 {
+		cfg.InvoiceAccount = DefaultInvoiceAccount
+	}
+	if cfg.PrepaidAccount == 0 {
+		cfg.PrepaidAccount = DefaultPrepaidAccount
+	}
+	if cfg.VAT.DomesticAccount == 0 {
+		cfg.VAT.DomesticAccount = DefaultDomesticVatAccount
+	}
+	if cfg.VAT.ForeignAccount == 0 {
+		cfg.VAT.ForeignAccount = DefaultForeignVatAccount
+	}
+	if cfg.VAT.ReverseChargeOutputAccount == 0 {
+		cfg.VAT.ReverseChargeOutputAccount = DefaultReverseChargeOutputAccount
+	}
+	if cfg.VAT.ReverseChargeRate == 0 {
+		cfg.VAT.ReverseChargeRate = DefaultReverseChargeRate
+	}
+	switch cfg.VAT.ForeignTreatment {
+	case "":
+		cfg.VAT.ForeignTreatment = VatNone
+	case VatNone, VatForeign, VatReverseCharge:
+	default:
+		return nil, fmt.Errorf("unknown foreign VAT treatment %q (use %s, %s or %s)", cfg.VAT.ForeignTreatment,
+			VatNone, VatForeign, VatReverseCharge)
+	}
+	return &Booker{cfg: cfg}, nil
+}
+
+// Book creates verifications for all bookable documents
+// Documents that are not invoices or receipts, or lack a date or SEK amount, are skipped.
+func (b *Booker) Book(docs []document.Document) ([]Verification, []Skipped) {
+	var verifications []Verification
+	var skipped []Skipped
+
+	for _, doc := range docs {
+		verification, err := b.BookDocument(doc)
+		if err != nil {
+			skipped = append(skipped, Skipped{Path: doc.Path, Reason: err.Error()})
+			continue
+		}
+		verifications = append(verifications, verification)
+	}
+
+	return verifications, skipped
+}
+
+// BookDocument creates a verification for a single document
+func (b *Booker) BookDocument(doc document.Document) (Verification, error) {
+	info := document.Signed(doc.Info)
+
+	if !document.Bookable(info) {
+		return Verification{}, fmt.Errorf("document type %q is not bookable", info.DocumentType)
+	}
+
+	date, ok := document.ParseDate(info)
+	if !ok {
+		return Verification{}, fmt.Errorf("missing or invalid issue date")
+	}
+
+	if info.SECentAmount == nil {
+		return Verification{}, fmt.Errorf("missing SEK amount")
+	}
+	total := *info.SECentAmount
+
+	verification := Verification{
+		Date:   date,
+		Text:   VerificationText(info),
+		Source: doc.Path,
+	}
+
+	expense := total
+	var vatTransactions []Transaction
+
+	switch b.VatTreatment(info) {
+	case VatDomestic, VatForeign:
+		if vat, ok := document.VatCents(info); ok && vat != 0 {
+			account := b.cfg.VAT.DomesticAccount
+			if b.VatTreatment(info) == VatForeign {
+				account = b.cfg.VAT.ForeignAccount
+			}
+			expense -= vat
+			vatTransactions = append(vatTransactions, Transaction{Account: account, AmountCents: vat})
+		}
+	case VatReverseCharge:
+		vat := int(math.Round(float64(total) * b.cfg.VAT.ReverseChargeRate / 100))
+		if vat != 0 {
+			vatTransactions = append(vatTransactions,
+				Transaction{Account: b.cfg.VAT.ForeignAccount, AmountCents: vat},
+				Transaction{Account: b.cfg.VAT.ReverseChargeOutputAccount, AmountCents: -vat},
+			)
+		}
+	}
+
+	verification.Transactions = append(verification.Transactions, Transaction{Account: b.ExpenseAccount(info), AmountCents: expense})
+	verification.Transactions = append(verification.Transactions, vatTransactions...)
+	verification.Transactions = append(verification.Transactions, Transaction{Account: b.CreditAccount(info), AmountCents: -total})
+
+	return verification, nil
+}
+
+// ExpenseAccount returns the account of the document's vendor, or the expense account for its
+// Description category
+func (b *Booker) ExpenseAccount(info *interfaces.ReceiptInvoiceInfo) int {
+	if info.Vendor != nil && info.Vendor.Account != 0 {
+		return info.Vendor.Account
+	}
+	category := strings.TrimSpace(info.Description)
+	for _, name := range sortedKeys(b.cfg.ExpenseAccounts) {
+		if strings.EqualFold(strings.TrimSpace(name), category) {
+			return b.cfg.ExpenseAccounts[name]
+		}
+	}
+	return b.cfg.DefaultExpenseAccount
+}
+
+// CreditAccount returns the account credited with the total: supplier debts for invoices
+// and credit notes, which reduce them, and the bank account for receipts
+func (b *Booker) CreditAccount(info *interfaces.ReceiptInvoiceInfo) int {
+	if info.DocumentType == interfaces.DocumentTypeInvoice || info.DocumentType == interfaces.DocumentTypeCreditNote {
+		return b.cfg.InvoiceAccount
+	}
+	return b.cfg.ReceiptAccount
+}
+
+// VatTreatment returns how VAT on the document is booked
+// The treatment of the document's vendor wins. Otherwise documents in SEK and documents of Swedish
+// sellers, recognised by a Swedish VAT number or a vendor matched by organisation number, are
+// domestic, and other currencies use the configured foreign treatment.
+func (b *Booker) VatTreatment(info *interfaces.ReceiptInvoiceInfo) string {
+	if info.Vendor != nil && info.Vendor.VatTreatment != "" {
+		return info.Vendor.VatTreatment
+	}
+	currency := strings.ToUpper(strings.TrimSpace(document.StringValue(info.OriginalCurrency)))
+	if currency == "" || currency == "SEK" || SwedishSeller(info) {
+		return VatDomestic
+	}
+	return b.cfg.VAT.ForeignTreatment
+}
+
+// SwedishSeller reports whether a document was issued by a company registered for VAT in Sweden:
+// it has a Swedish VAT number among its ID fields, or its vendor was matched by organisation number
+func SwedishSeller(info *interfaces.ReceiptInvoiceInfo) bool {
+	if info.Vendor != nil && info.Vendor.MatchedBy == interfaces.MatchedByOrgNumber {
+		return true
+	}
+	for _, idField := range info.IdFields {
+		if swedishVATNumber.MatchString(strings.ToUpper(idField.Value)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedKeys returns the keys of a category mapping in sorted order, so that overlapping
+// entries such as "AI Services" and "ai services" always resolve to the same account
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// AccountName returns the configured or BAS name of an account
+func (b *Booker) AccountName(account int) string {
+	if name, ok := b.cfg.AccountNames[account]; ok {
+		return name
+	}
+	if name, ok := BASAccountNames[account]; ok {
+		return name
+	}
+	return fmt.Sprintf("Konto %d", account)
+}
+
+// UsedAccounts returns the sorted list of accounts used by the verifications
+func UsedAccounts(verifications []Verification) []int {
+	seen := make(map[int]bool)
+	var accounts []int
+	for _, verification := range verifications {
+		for _, transaction := range verification.Transactions {
+			if !seen[transaction.Account] {
+				seen[transaction.Account] = true
+				accounts = append(accounts, transaction.Account)
+			}
+		}
+	}
+	sort.Ints(accounts)
+	return accounts
+}
+
+// VerificationText builds a short text from company and category, e.g. "Anthropic, PBC - AI Services"
+// Credit notes are marked with the invoice they credit, e.g. "Acme AB - Office supplies (kredit 1234)".
+func VerificationText(info *interfaces.ReceiptInvoiceInfo) string {
+	company := strings.TrimSpace(document.StringValue(info.Company))
+	description := strings.TrimSpace(info.Description)
+	text := company + " - " + description
+	switch {
+	case company == "":
+		text = description
+	case description == "":
+		text = company
+	}
+	if document.IsCreditNote(info) {
+		if invoice := document.ReferencedInvoice(info); invoice != "" {
+			return text + " (kredit " + invoice + ")"
+		}
+		return text + " (kredit)"
+	}
+	return text
+}
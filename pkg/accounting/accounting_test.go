@@ -0,0 +1,198 @@
+package accounting
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+func str(s string) *string { return &s }
+
+func num(f float64) *float64 { return &f }
+
+func cents(c int) *int { return &c }
+
+// sekInvoice returns an invoice of 1250 SEK including 250 SEK VAT
+func sekInvoice() *interfaces.ReceiptInvoiceInfo {
+	return &interfaces.ReceiptInvoiceInfo{
+		DocumentType:      interfaces.DocumentTypeInvoice,
+		Description:       "Software",
+		Company:           str("Fortnox AB"),
+		DateIssued:        str("2025-08-02"),
+		OriginalAmount:    num(1250),
+		OriginalVatAmount: num(250),
+		OriginalCurrency:  str("SEK"),
+		SECentAmount:      cents(125000),
+		IdFields:          []interfaces.IdField{{Name: "Invoice Number", Value: "F-1001"}},
+	}
+}
+
+// eurInvoice returns an invoice of 100 EUR including the given VAT, 1150 SEK in total
+func eurInvoice(vat float64) *interfaces.ReceiptInvoiceInfo {
+	info := sekInvoice()
+	info.Company = str("Cloud Ltd")
+	info.OriginalAmount, info.OriginalVatAmount = num(100), num(vat)
+	info.OriginalCurrency = str("EUR")
+	info.SECentAmount = cents(115000)
+	return info
+}
+
+func TestBookDocument(t *testing.T) {
+	tests := []struct {
+		name      string
+		treatment string
+		info      func() *interfaces.ReceiptInvoiceInfo
+		want      []Transaction
+		text      string
+	}{
+		{
+			name: "domestic invoice",
+			info: sekInvoice,
+			want: []Transaction{{6990, 100000}, {2641, 25000}, {2440, -125000}},
+			text: "Fortnox AB - Software",
+		},
+		{
+			name: "receipt paid from the bank account",
+			info: func() *interfaces.ReceiptInvoiceInfo {
+				info := sekInvoice()
+				info.DocumentType = interfaces.DocumentTypeReceipt
+				return info
+			},
+			want: []Transaction{{6990, 100000}, {2641, 25000}, {1930, -125000}},
+		},
+		{
+			name: "category mapped to an expense account",
+			info: func() *interfaces.ReceiptInvoiceInfo {
+				info := sekInvoice()
+				info.Description = "ai services"
+				return info
+			},
+			want: []Transaction{{6540, 100000}, {2641, 25000}, {2440, -125000}},
+		},
+		{
+			name: "foreign invoice without deductible VAT",
+			info: func() *interfaces.ReceiptInvoiceInfo { return eurInvoice(20) },
+			want: []Transaction{{6990, 115000}, {2440, -115000}},
+		},
+		{
+			name:      "foreign VAT that is refunded",
+			treatment: VatForeign,
+			info:      func() *interfaces.ReceiptInvoiceInfo { return eurInvoice(20) },
+			want:      []Transaction{{6990, 92000}, {2645, 23000}, {2440, -115000}},
+		},
+		{
+			name:      "reverse charge",
+			treatment: VatReverseCharge,
+			info:      func() *interfaces.ReceiptInvoiceInfo { return eurInvoice(0) },
+			want:      []Transaction{{6990, 115000}, {2645, 28750}, {2614, -28750}, {2440, -115000}},
+		},
+		{
+			name:      "Swedish seller invoicing in EUR",
+			treatment: VatReverseCharge,
+			info: func() *interfaces.ReceiptInvoiceInfo {
+				info := eurInvoice(20)
+				info.IdFields = append(info.IdFields, interfaces.IdField{Name: "VAT Number", Value: "SE556123456701"})
+				return info
+			},
+			want: []Transaction{{6990, 92000}, {2641, 23000}, {2440, -115000}},
+		},
+		{
+			name:      "vendor account and treatment",
+			treatment: VatForeign,
+			info: func() *interfaces.ReceiptInvoiceInfo {
+				info := eurInvoice(20)
+				info.Vendor = &interfaces.VendorMatch{Name: "Cloud", MatchedBy: interfaces.MatchedByName, Account: 5420, VatTreatment: VatNone}
+				return info
+			},
+			want: []Transaction{{5420, 115000}, {2440, -115000}},
+		},
+		{
+			name: "credit note with positive amounts",
+			info: func() *interfaces.ReceiptInvoiceInfo {
+				info := sekInvoice()
+				info.DocumentType = interfaces.DocumentTypeCreditNote
+				info.IdFields = append(info.IdFields, interfaces.IdField{Name: interfaces.IdFieldReferencedInvoice, Value: "F-1000"})
+				return info
+			},
+			want: []Transaction{{6990, -100000}, {2641, -25000}, {2440, 125000}},
+			text: "Fortnox AB - Software (kredit F-1000)",
+		},
+		{
+			name:      "foreign credit note with reverse charge",
+			treatment: VatReverseCharge,
+			info: func() *interfaces.ReceiptInvoiceInfo {
+				info := eurInvoice(0)
+				info.DocumentType = interfaces.DocumentTypeCreditNote
+				info.OriginalAmount, info.SECentAmount = num(-100), cents(-115000)
+				return info
+			},
+			want: []Transaction{{6990, -115000}, {2645, -28750}, {2614, 28750}, {2440, 115000}},
+			text: "Cloud Ltd - Software (kredit)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booker, err := NewBooker(config.AccountingConfig{
+				ExpenseAccounts: map[string]int{"AI Services": 6540},
+				VAT:             config.VATConfig{ForeignTreatment: tt.treatment},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			verification, err := booker.BookDocument(document.Document{Path: "doc.json", Info: tt.info()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(verification.Transactions, tt.want) {
+				t.Errorf("transactions = %v, want %v", verification.Transactions, tt.want)
+			}
+			sum := 0
+			for _, transaction := range verification.Transactions {
+				sum += transaction.AmountCents
+			}
+			if sum != 0 {
+				t.Errorf("verification is off balance by %d öre", sum)
+			}
+			if tt.text != "" && verification.Text != tt.text {
+				t.Errorf("text = %q, want %q", verification.Text, tt.text)
+			}
+			if !verification.Date.Equal(date(t, "2025-08-02")) {
+				t.Errorf("date = %v, want 2025-08-02", verification.Date)
+			}
+		})
+	}
+}
+
+func TestBookDocumentSkips(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(info *interfaces.ReceiptInvoiceInfo)
+	}{
+		{"not a financial document", func(info *interfaces.ReceiptInvoiceInfo) { info.DocumentType = interfaces.DocumentTypeNone }},
+		{"missing date", func(info *interfaces.ReceiptInvoiceInfo) { info.DateIssued = nil }},
+		{"invalid date", func(info *interfaces.ReceiptInvoiceInfo) { info.DateIssued = str("August 2") }},
+		{"missing SEK amount", func(info *interfaces.ReceiptInvoiceInfo) { info.SECentAmount = nil }},
+	}
+	booker, err := NewBooker(config.AccountingConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := sekInvoice()
+			tt.modify(info)
+			if _, err := booker.BookDocument(document.Document{Path: "doc.json", Info: info}); err == nil {
+				t.Error("expected the document to be skipped")
+			}
+		})
+	}
+}
+
+func TestNewBookerRejectsUnknownTreatment(t *testing.T) {
+	if _, err := NewBooker(config.AccountingConfig{VAT: config.VATConfig{ForeignTreatment: "domestic"}}); err == nil {
+		t.Error("expected an error")
+	}
+}
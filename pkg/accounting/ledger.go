@@ -0,0 +1,351 @@
+package accounting
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/extra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// Default plain-text accounting accounts used when the config does not specify them
+const (
+	DefaultLedgerCurrency       = "SEK"
+	DefaultLedgerExpenseAccount = "Expenses:Uncategorized"
+	DefaultLedgerReceiptAccount = "Assets:Bank"
+	DefaultLedgerInvoiceAccount = "Liabilities:AccountsPayable"
+	DefaultLedgerPrepaidAccount = "Assets:PrepaidExpenses"
+)
+
+// ledgerSourceKey is the metadata key of the source file of a transaction
+const ledgerSourceKey = "source"
+
+// LedgerAmount is an amount in cents of a currency
+type LedgerAmount struct {
+	// Cents is the amount in hundredths of the currency unit
+	Cents int
+
+	// Currency is the ISO currency code
+	Currency string
+}
+
+// LedgerPosting is one posting of a ledger transaction
+type LedgerPosting struct {
+	// Account is the ledger account name (e.g. "Expenses:Software")
+	Account string
+
+	// Amount is the posted amount
+	Amount LedgerAmount
+
+	// Cost is the total price in the base currency (written with @@), nil if not converted
+	Cost *LedgerAmount
+}
+
+// LedgerMeta is a key/value pair attached to a transaction
+type LedgerMeta struct {
+	Key   string
+	Value string
+}
+
+// LedgerTransaction is a balanced plain-text accounting transaction
+type LedgerTransaction struct {
+	// Date is the transaction date
+	Date time.Time
+
+	// Payee is the company that requested payment
+	Payee string
+
+	// Narration describes what was paid for
+	Narration string
+
+	// Meta holds ID fields and the source file
+	Meta []LedgerMeta
+
+	// Postings are the transaction postings, balanced in the base currency
+	Postings []LedgerPosting
+}
+
+// LedgerBooker turns extracted documents into ledger transactions
+type LedgerBooker struct {
+	cfg   config.LedgerConfig
+	rules []compiledLedgerRule
+}
+
+// compiledLedgerRule is a LedgerRule with compiled patterns, nil patterns always match
+type compiledLedgerRule struct {
+	company     *regexp.Regexp
+	description *regexp.Regexp
+	service     *regexp.Regexp
+	account     string
+}
+
+// NewLedgerBooker creates a LedgerBooker, missing accounts are filled with defaults
+func NewLedgerBooker(cfg config.LedgerConfig) (*LedgerBooker, error) {
+	if cfg.BaseCurrency == "" {
+		cfg.BaseCurrency = DefaultLedgerCurrency
+	}
+	if cfg.DefaultExpenseAccount == "" {
+		cfg.DefaultExpenseAccount = DefaultLedgerExpenseAccount
+	}
+	if cfg.ReceiptAccount == "" {
+		cfg.ReceiptAccount = DefaultLedgerReceiptAccount
+	}
+	if cfg.InvoiceAccount == "" {
+		cfg.InvoiceAccount = DefaultLedgerInvoiceAccount
+	}
+	if cfg.PrepaidAccount == "" {
+		cfg.PrepaidAccount = DefaultLedgerPrepaidAccount
+	}
+
+	booker := &LedgerBooker{cfg: cfg}
+	for i, rule := range cfg.Rules {
+		if rule.Account == "" {
+			return nil, fmt.Errorf("ledger rule %d has no account", i+1)
+		}
+
+		compiled := compiledLedgerRule{account: rule.Account}
+		patterns := []struct {
+			pattern string
+			target  **regexp.Regexp
+		}{
+			{rule.Company, &compiled.company},
+			{rule.Description, &compiled.description},
+			{rule.Service, &compiled.service},
+		}
+		for _, p := range patterns {
+			if p.pattern == "" {
+				continue
+			}
+			re, err := regexp.Compile(p.pattern)
+			if err != nil {
+				return nil, fmt.Errorf("ledger rule %d has an invalid pattern %q: %w", i+1, p.pattern, err)
+			}
+			*p.target = re
+		}
+		booker.rules = append(booker.rules, compiled)
+	}
+
+	return booker, nil
+}
+
+// BaseCurrency returns the currency used for converted amounts
+func (b *LedgerBooker) BaseCurrency() string {
+	return b.cfg.BaseCurrency
+}
+
+// Book creates ledger transactions for all bookable documents
+func (b *LedgerBooker) Book(docs []document.Document) ([]LedgerTransaction, []Skipped) {
+	var transactions []LedgerTransaction
+	var skipped []Skipped
+
+	for _, doc := range docs {
+		transaction, err := b.BookDocument(doc)
+		if err != nil {
+			skipped = append(skipped, Skipped{Path: doc.Path, Reason: err.Error()})
+			continue
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, skipped
+}
+
+// BookDocument creates a ledger transaction for a single document
+// Amounts are posted in the original currency with the SEK total as @@ cost. If only
+// one of the amounts is known, the transaction is posted in that currency alone.
+func (b *LedgerBooker) BookDocument(doc document.Document) (LedgerTransaction, error) {
+	info := document.Signed(doc.Info)
+
+	if !document.Bookable(info) {
+		return LedgerTransaction{}, fmt.Errorf("document type %q is not bookable", info.DocumentType)
+	}
+
+	date, ok := document.ParseDate(info)
+	if !ok {
+		return LedgerTransaction{}, fmt.Errorf("missing or invalid issue date")
+	}
+
+	original, hasOriginal := originalAmount(info)
+	base, hasBase := LedgerAmount{}, info.SECentAmount != nil
+	if hasBase {
+		base = LedgerAmount{Cents: *info.SECentAmount, Currency: b.cfg.BaseCurrency}
+	}
+	if !hasOriginal && !hasBase {
+		return LedgerTransaction{}, fmt.Errorf("missing amount")
+	}
+
+	// Post in the original currency only if it differs from the base currency
+	converted := hasOriginal && hasBase && original.Currency != b.cfg.BaseCurrency
+	total := base
+	if !hasBase {
+		total = original
+	}
+
+	transaction := LedgerTransaction{
+		Date:      date,
+		Payee:     strings.TrimSpace(document.StringValue(info.Company)),
+		Narration: strings.TrimSpace(document.StringValue(info.ServiceDescription)),
+		Meta:      ledgerMeta(doc),
+	}
+	if transaction.Narration == "" {
+		transaction.Narration = info.Description
+	}
+
+	expenseAccount := b.ExpenseAccount(info)
+
+	// Split off VAT if an account is configured and the VAT amount is known
+	vatOriginal, vatBase := 0, 0
+	if b.cfg.VATAccount != "" && info.OriginalVatAmount != nil {
+		vatOriginal = int(math.Round(*info.OriginalVatAmount * 100))
+		if hasBase {
+			if vat, ok := document.VatCents(info); ok {
+				vatBase = vat
+			}
+		}
+		if !converted && hasBase {
+			vatOriginal = vatBase
+		}
+	}
+
+	if converted {
+		transaction.Postings = append(transaction.Postings, LedgerPosting{
+			Account: expenseAccount,
+			Amount:  LedgerAmount{Cents: original.Cents - vatOriginal, Currency: original.Currency},
+			Cost:    &LedgerAmount{Cents: base.Cents - vatBase, Currency: base.Currency},
+		})
+		if vatOriginal != 0 {
+			transaction.Postings = append(transaction.Postings, LedgerPosting{
+				Account: b.cfg.VATAccount,
+				Amount:  LedgerAmount{Cents: vatOriginal, Currency: original.Currency},
+				Cost:    &LedgerAmount{Cents: vatBase, Currency: base.Currency},
+			})
+		}
+	} else {
+		transaction.Postings = append(transaction.Postings, LedgerPosting{
+			Account: expenseAccount,
+			Amount:  LedgerAmount{Cents: total.Cents - vatOriginal, Currency: total.Currency},
+		})
+		if vatOriginal != 0 {
+			transaction.Postings = append(transaction.Postings, LedgerPosting{
+				Account: b.cfg.VATAccount,
+				Amount:  LedgerAmount{Cents: vatOriginal, Currency: total.Currency},
+			})
+		}
+	}
+
+	transaction.Postings = append(transaction.Postings, LedgerPosting{
+		Account: b.CreditAccount(info),
+		Amount:  LedgerAmount{Cents: -total.Cents, Currency: total.Currency},
+	})
+
+	return transaction, nil
+}
+
+// ExpenseAccount returns the expense account from the first matching rule, the
+// category mapping or the default account
+func (b *LedgerBooker) ExpenseAccount(info *interfaces.ReceiptInvoiceInfo) string {
+	company := document.StringValue(info.Company)
+	service := document.StringValue(info.ServiceDescription)
+
+	for _, rule := range b.rules {
+		if matchesPattern(rule.company, company) &&
+			matchesPattern(rule.description, info.Description) &&
+			matchesPattern(rule.service, service) {
+			return rule.account
+		}
+	}
+
+	category := strings.TrimSpace(info.Description)
+	for _, name := range sortedKeys(b.cfg.ExpenseAccounts) {
+		if strings.EqualFold(strings.TrimSpace(name), category) {
+			return b.cfg.ExpenseAccounts[name]
+		}
+	}
+
+	return b.cfg.DefaultExpenseAccount
+}
+
+// CreditAccount returns the account credited with the total
+func (b *LedgerBooker) CreditAccount(info *interfaces.ReceiptInvoiceInfo) string {
+	if info.DocumentType == interfaces.DocumentTypeInvoice || info.DocumentType == interfaces.DocumentTypeCreditNote {
+		return b.cfg.InvoiceAccount
+	}
+	return b.cfg.ReceiptAccount
+}
+
+// matchesPattern reports whether re matches s, a nil pattern always matches
+func matchesPattern(re *regexp.Regexp, s string) bool {
+	return re == nil || re.MatchString(s)
+}
+
+// originalAmount returns the original amount in cents if both amount and currency are known
+func originalAmount(info *interfaces.ReceiptInvoiceInfo) (LedgerAmount, bool) {
+	currency := strings.ToUpper(strings.TrimSpace(document.StringValue(info.OriginalCurrency)))
+	if info.OriginalAmount == nil || currency == "" {
+		return LedgerAmount{}, false
+	}
+	return LedgerAmount{Cents: int(math.Round(*info.OriginalAmount * 100)), Currency: currency}, true
+}
+
+// ledgerMeta builds metadata from the ID fields, the extra fields and the source file
+// Keys are unique as ledgers reject duplicates: an extra field named like an ID field gets the
+// prefix "extra_", other repeated keys are left out and "source" is reserved for the source file.
+func ledgerMeta(doc document.Document) []LedgerMeta {
+	var meta []LedgerMeta
+	seen := map[string]bool{ledgerSourceKey: true}
+	add := func(key, value string) {
+		if key == "" || seen[key] || strings.TrimSpace(value) == "" {
+			return
+		}
+		seen[key] = true
+		meta = append(meta, LedgerMeta{Key: key, Value: value})
+	}
+
+	for _, idField := range doc.Info.IdFields {
+		add(MetaKey(idField.Name), idField.Value)
+	}
+	for _, name := range extra.Names(doc.Info) {
+		key := MetaKey(name)
+		if seen[key] {
+			key = "extra_" + key
+		}
+		add(key, extra.Value(doc.Info, name))
+	}
+
+	source := doc.Info.SourceFile
+	if source == "" {
+		source = doc.Path
+	}
+	meta = append(meta, LedgerMeta{Key: ledgerSourceKey, Value: source})
+
+	return meta
+}
+
+// MetaKey converts a field name such as "Invoice Number" into a metadata key ("invoice_number")
+// The result is valid for both beancount metadata and hledger tags.
+func MetaKey(name string) string {
+	var b strings.Builder
+	lastUnderscore := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+			lastUnderscore = false
+		case !lastUnderscore && b.Len() > 0:
+			b.WriteRune('_')
+			lastUnderscore = true
+		}
+	}
+
+	key := strings.TrimSuffix(b.String(), "_")
+	if key != "" && !unicode.IsLetter(rune(key[0])) {
+		key = "id_" + key
+	}
+	return key
+}
@@ -0,0 +1,139 @@
+package accounting
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+func TestLedgerBookDocument(t *testing.T) {
+	sek := func(c int) LedgerAmount { return LedgerAmount{Cents: c, Currency: "SEK"} }
+	eur := func(c int) LedgerAmount { return LedgerAmount{Cents: c, Currency: "EUR"} }
+	cost := func(c int) *LedgerAmount { return &LedgerAmount{Cents: c, Currency: "SEK"} }
+
+	tests := []struct {
+		name       string
+		vatAccount string
+		info       func() *interfaces.ReceiptInvoiceInfo
+		want       []LedgerPosting
+	}{
+		{
+			name: "domestic invoice",
+			info: sekInvoice,
+			want: []LedgerPosting{
+				{Account: "Expenses:Software", Amount: sek(125000)},
+				{Account: DefaultLedgerInvoiceAccount, Amount: sek(-125000)},
+			},
+		},
+		{
+			name:       "domestic invoice with VAT account",
+			vatAccount: "Assets:VAT",
+			info:       sekInvoice,
+			want: []LedgerPosting{
+				{Account: "Expenses:Software", Amount: sek(100000)},
+				{Account: "Assets:VAT", Amount: sek(25000)},
+				{Account: DefaultLedgerInvoiceAccount, Amount: sek(-125000)},
+			},
+		},
+		{
+			name: "foreign invoice at cost",
+			info: func() *interfaces.ReceiptInvoiceInfo { return eurInvoice(20) },
+			want: []LedgerPosting{
+				{Account: DefaultLedgerExpenseAccount, Amount: eur(10000), Cost: cost(115000)},
+				{Account: DefaultLedgerInvoiceAccount, Amount: sek(-115000)},
+			},
+		},
+		{
+			name:       "foreign invoice with VAT account",
+			vatAccount: "Assets:VAT",
+			info:       func() *interfaces.ReceiptInvoiceInfo { return eurInvoice(20) },
+			want: []LedgerPosting{
+				{Account: DefaultLedgerExpenseAccount, Amount: eur(8000), Cost: cost(92000)},
+				{Account: "Assets:VAT", Amount: eur(2000), Cost: cost(23000)},
+				{Account: DefaultLedgerInvoiceAccount, Amount: sek(-115000)},
+			},
+		},
+		{
+			name: "foreign invoice without SEK amount",
+			info: func() *interfaces.ReceiptInvoiceInfo {
+				info := eurInvoice(20)
+				info.SECentAmount = nil
+				return info
+			},
+			want: []LedgerPosting{
+				{Account: DefaultLedgerExpenseAccount, Amount: eur(10000)},
+				{Account: DefaultLedgerInvoiceAccount, Amount: eur(-10000)},
+			},
+		},
+		{
+			name:       "credit note with positive amounts",
+			vatAccount: "Assets:VAT",
+			info: func() *interfaces.ReceiptInvoiceInfo {
+				info := sekInvoice()
+				info.DocumentType = interfaces.DocumentTypeCreditNote
+				return info
+			},
+			want: []LedgerPosting{
+				{Account: "Expenses:Software", Amount: sek(-100000)},
+				{Account: "Assets:VAT", Amount: sek(-25000)},
+				{Account: DefaultLedgerInvoiceAccount, Amount: sek(125000)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			booker, err := NewLedgerBooker(config.LedgerConfig{
+				Rules:      []config.LedgerRule{{Company: "^Fortnox", Account: "Expenses:Software"}},
+				VATAccount: tt.vatAccount,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			transaction, err := booker.BookDocument(document.Document{Path: "doc.json", Info: tt.info()})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(transaction.Postings, tt.want) {
+				t.Errorf("postings = %+v, want %+v", transaction.Postings, tt.want)
+			}
+		})
+	}
+}
+
+func TestLedgerMeta(t *testing.T) {
+	info := sekInvoice()
+	info.IdFields = append(info.IdFields, interfaces.IdField{Name: "invoice number", Value: "F-1002"})
+	info.Extra = map[string]interface{}{"Invoice Number": "X-1", "source": "mail", "Cost Center": float64(42), "project": nil}
+
+	got := ledgerMeta(document.Document{Path: "doc.json", Info: info})
+	want := []LedgerMeta{
+		{Key: "invoice_number", Value: "F-1001"},
+		{Key: "cost_center", Value: "42"},
+		{Key: "extra_invoice_number", Value: "X-1"},
+		{Key: "extra_source", Value: "mail"},
+		{Key: "source", Value: "doc.json"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("meta = %+v, want %+v", got, want)
+	}
+}
+
+func TestLedgerBookerRejectsInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule config.LedgerRule
+	}{
+		{"missing account", config.LedgerRule{Company: "Fortnox"}},
+		{"invalid pattern", config.LedgerRule{Company: "(", Account: "Expenses:Software"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewLedgerBooker(config.LedgerConfig{Rules: []config.LedgerRule{tt.rule}}); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
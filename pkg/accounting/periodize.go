@@ -0,0 +1,233 @@
+package accounting
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+)
+
+// ErrNoPeriod is returned for documents whose service period does not span several months,
+// they are booked in full and need no accrual entries
+var ErrNoPeriod = errors.New("no service period of more than one month")
+
+// Accrual is the cost of one month of a service period
+type Accrual struct {
+	// Date is the last day of the month, or the booking date for months that ended before it
+	Date time.Time
+
+	// AmountCents is the cost of the month, the last month also gets the rounding difference
+	AmountCents int
+}
+
+// PeriodMonths returns the length of the service period rounded to whole months, at least 1
+// The end date is inclusive: 2025-01-01 – 2025-12-31 and 2025-01-15 – 2026-01-14 are 12 months.
+func PeriodMonths(start, end time.Time) int {
+	next := end.AddDate(0, 0, 1)
+	months := 1
+	for next.Sub(addMonths(start, months)) > addMonths(start, months+1).Sub(next) {
+		months++
+	}
+	return months
+}
+
+// addMonths adds months to a date, clamping the day to the end of the month
+// Unlike time.AddDate, 2025-01-31 plus one month is 2025-02-28 and not 2025-03-03.
+func addMonths(date time.Time, months int) time.Time {
+	day := date.Day()
+	if last := time.Date(date.Year(), date.Month()+time.Month(months)+1, 0, 0, 0, 0, 0, time.UTC).Day(); day > last {
+		day = last
+	}
+	return time.Date(date.Year(), date.Month()+time.Month(months), day, 0, 0, 0, 0, time.UTC)
+}
+
+// SplitPeriod spreads cents evenly over the months of the service period
+// Each month gets the same amount rounded toward zero, the last month gets what is left so that
+// the accruals always sum to cents. Accruals are never dated before booked.
+func SplitPeriod(start, end, booked time.Time, cents int) []Accrual {
+	months := PeriodMonths(start, end)
+	monthly := cents / months
+
+	accruals := make([]Accrual, months)
+	for i := range accruals {
+		date := time.Date(start.Year(), start.Month()+time.Month(i)+1, 0, 0, 0, 0, 0, time.UTC)
+		if date.Before(booked) {
+			date = booked
+		}
+		accruals[i] = Accrual{Date: date, AmountCents: monthly}
+	}
+	accruals[months-1].AmountCents = cents - monthly*(months-1)
+	return accruals
+}
+
+// Periodize creates accrual verifications for all documents with a service period of several months
+// Documents without such a period are ignored, the number of periodised documents is returned.
+func (b *Booker) Periodize(docs []document.Document) ([]Verification, int, []Skipped) {
+	var verifications []Verification
+	var skipped []Skipped
+	periodized := 0
+
+	for _, doc := range docs {
+		entries, err := b.PeriodizeDocument(doc)
+		if errors.Is(err, ErrNoPeriod) {
+			continue
+		}
+		if err != nil {
+			skipped = append(skipped, Skipped{Path: doc.Path, Reason: err.Error()})
+			continue
+		}
+		verifications = append(verifications, entries...)
+		periodized++
+	}
+
+	sortVerifications(verifications)
+	return verifications, periodized, skipped
+}
+
+// PeriodizeDocument creates the accrual verifications for a document that BookDocument books in full
+// The first verification moves the expense to the prepaid account on the booking date, one
+// verification per month of the service period then moves the month's share back to the expense
+// account. VAT and the supplier debt are not affected.
+func (b *Booker) PeriodizeDocument(doc document.Document) ([]Verification, error) {
+	start, end, ok := document.ParsePeriod(doc.Info)
+	if !ok || PeriodMonths(start, end) < 2 {
+		return nil, ErrNoPeriod
+	}
+
+	booked, err := b.BookDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	// BookDocument posts the expense first
+	expense := booked.Transactions[0]
+	if expense.AmountCents == 0 {
+		return nil, fmt.Errorf("no expense to periodise")
+	}
+
+	prepaid := b.cfg.PrepaidAccount
+	verifications := []Verification{{
+		Date:   booked.Date,
+		Text:   fmt.Sprintf("%s (förutbetald %s - %s)", booked.Text, start.Format(document.DateLayout), end.Format(document.DateLayout)),
+		Source: doc.Path,
+		Transactions: []Transaction{
+			{Account: prepaid, AmountCents: expense.AmountCents},
+			{Account: expense.Account, AmountCents: -expense.AmountCents},
+		},
+	}}
+
+	accruals := SplitPeriod(start, end, booked.Date, expense.AmountCents)
+	for i, accrual := range accruals {
+		verifications = append(verifications, Verification{
+			Date:   accrual.Date,
+			Text:   fmt.Sprintf("%s (periodisering %d/%d)", booked.Text, i+1, len(accruals)),
+			Source: doc.Path,
+			Transactions: []Transaction{
+				{Account: expense.Account, AmountCents: accrual.AmountCents},
+				{Account: prepaid, AmountCents: -accrual.AmountCents},
+			},
+		})
+	}
+	return verifications, nil
+}
+
+// Periodize creates accrual transactions for all documents with a service period of several months
+// Documents without such a period are ignored, the number of periodised documents is returned.
+func (b *LedgerBooker) Periodize(docs []document.Document) ([]LedgerTransaction, int, []Skipped) {
+	var transactions []LedgerTransaction
+	var skipped []Skipped
+	periodized := 0
+
+	for _, doc := range docs {
+		entries, err := b.PeriodizeDocument(doc)
+		if errors.Is(err, ErrNoPeriod) {
+			continue
+		}
+		if err != nil {
+			skipped = append(skipped, Skipped{Path: doc.Path, Reason: err.Error()})
+			continue
+		}
+		transactions = append(transactions, entries...)
+		periodized++
+	}
+
+	sortLedgerTransactions(transactions)
+	return transactions, periodized, skipped
+}
+
+// PeriodizeDocument creates the accrual transactions for a document that BookDocument books in full
+// The transactions are laid out like the verifications of Booker.PeriodizeDocument. Converted
+// amounts are moved in the original currency with their SEK cost, both split over the months, so
+// that the balances of each currency are cleared when the period ends.
+func (b *LedgerBooker) PeriodizeDocument(doc document.Document) ([]LedgerTransaction, error) {
+	start, end, ok := document.ParsePeriod(doc.Info)
+	if !ok || PeriodMonths(start, end) < 2 {
+		return nil, ErrNoPeriod
+	}
+
+	booked, err := b.BookDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	// BookDocument posts the expense first
+	expense := booked.Postings[0]
+	if expense.Amount.Cents == 0 {
+		return nil, fmt.Errorf("no expense to periodise")
+	}
+
+	// posting moves cents of the expense, and the same share of its cost, to an account
+	posting := func(account string, cents, costCents int) LedgerPosting {
+		p := LedgerPosting{Account: account, Amount: LedgerAmount{Cents: cents, Currency: expense.Amount.Currency}}
+		if expense.Cost != nil {
+			p.Cost = &LedgerAmount{Cents: costCents, Currency: expense.Cost.Currency}
+		}
+		return p
+	}
+	costCents := 0
+	if expense.Cost != nil {
+		costCents = expense.Cost.Cents
+	}
+
+	prepaid := b.cfg.PrepaidAccount
+	transactions := []LedgerTransaction{{
+		Date:      booked.Date,
+		Payee:     booked.Payee,
+		Narration: fmt.Sprintf("%s (prepaid %s - %s)", booked.Narration, start.Format(document.DateLayout), end.Format(document.DateLayout)),
+		Meta:      booked.Meta,
+		Postings: []LedgerPosting{
+			posting(prepaid, expense.Amount.Cents, costCents),
+			posting(expense.Account, -expense.Amount.Cents, -costCents),
+		},
+	}}
+
+	accruals := SplitPeriod(start, end, booked.Date, expense.Amount.Cents)
+	costs := SplitPeriod(start, end, booked.Date, costCents)
+	for i, accrual := range accruals {
+		transactions = append(transactions, LedgerTransaction{
+			Date:      accrual.Date,
+			Payee:     booked.Payee,
+			Narration: fmt.Sprintf("%s (accrual %d/%d)", booked.Narration, i+1, len(accruals)),
+			Meta:      booked.Meta,
+			Postings: []LedgerPosting{
+				posting(expense.Account, accrual.AmountCents, costs[i].AmountCents),
+				posting(prepaid, -accrual.AmountCents, -costs[i].AmountCents),
+			},
+		})
+	}
+	return transactions, nil
+}
+
+// sortVerifications orders verifications by date, keeping the order of equal dates
+func sortVerifications(verifications []Verification) {
+	sort.SliceStable(verifications, func(i, j int) bool {
+		return verifications[i].Date.Before(verifications[j].Date)
+	})
+}
+
+// sortLedgerTransactions orders transactions by date, keeping the order of equal dates
+func sortLedgerTransactions(transactions []LedgerTransaction) {
+	sort.SliceStable(transactions, func(i, j int) bool {
+		return transactions[i].Date.Before(transactions[j].Date)
+	})
+}
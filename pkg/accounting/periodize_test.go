@@ -0,0 +1,95 @@
+package accounting
+
+import (
+	"testing"
+	"time"
+)
+
+func date(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		t.Fatalf("invalid date %q: %v", s, err)
+	}
+	return d
+}
+
+func TestPeriodMonths(t *testing.T) {
+	tests := []struct {
+		start, end string
+		want       int
+	}{
+		{"2025-01-01", "2025-12-31", 12},
+		{"2025-01-15", "2026-01-14", 12},
+		{"2025-03-01", "2026-02-28", 12},
+		{"2025-01-31", "2026-01-30", 12},
+		{"2025-01-01", "2025-03-31", 3},
+		{"2025-01-01", "2025-03-30", 3},
+		{"2025-01-31", "2025-02-27", 1},
+		{"2025-01-31", "2025-04-29", 3},
+		{"2024-01-31", "2024-02-28", 1},
+		{"2025-08-02", "2025-09-02", 1},
+		{"2025-03-01", "2025-02-01", 1},
+	}
+	for _, tt := range tests {
+		if got := PeriodMonths(date(t, tt.start), date(t, tt.end)); got != tt.want {
+			t.Errorf("PeriodMonths(%s, %s) = %d, want %d", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+func TestSplitPeriod(t *testing.T) {
+	type accrual struct {
+		date  string
+		cents int
+	}
+	tests := []struct {
+		name               string
+		start, end, booked string
+		cents              int
+		want               []accrual
+	}{
+		{
+			name: "month-end start", start: "2025-01-31", end: "2025-05-30", booked: "2025-01-31", cents: 1000,
+			want: []accrual{{"2025-01-31", 250}, {"2025-02-28", 250}, {"2025-03-31", 250}, {"2025-04-30", 250}},
+		},
+		{
+			name: "30th across february", start: "2024-11-30", end: "2025-02-27", booked: "2024-11-30", cents: 900,
+			want: []accrual{{"2024-11-30", 300}, {"2024-12-31", 300}, {"2025-01-31", 300}},
+		},
+		{
+			name: "twelve months with rounding", start: "2025-01-01", end: "2025-12-31", booked: "2025-01-01", cents: 1000000,
+			want: []accrual{
+				{"2025-01-31", 83333}, {"2025-02-28", 83333}, {"2025-03-31", 83333}, {"2025-04-30", 83333},
+				{"2025-05-31", 83333}, {"2025-06-30", 83333}, {"2025-07-31", 83333}, {"2025-08-31", 83333},
+				{"2025-09-30", 83333}, {"2025-10-31", 83333}, {"2025-11-30", 83333}, {"2025-12-31", 83337},
+			},
+		},
+		{
+			name: "credit note", start: "2025-01-01", end: "2025-03-31", booked: "2025-01-05", cents: -100000,
+			want: []accrual{{"2025-01-31", -33333}, {"2025-02-28", -33333}, {"2025-03-31", -33334}},
+		},
+		{
+			name: "booked after the first month", start: "2025-01-01", end: "2025-03-31", booked: "2025-02-10", cents: 80000,
+			want: []accrual{{"2025-02-10", 26666}, {"2025-02-28", 26666}, {"2025-03-31", 26668}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitPeriod(date(t, tt.start), date(t, tt.end), date(t, tt.booked), tt.cents)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d accruals, want %d", len(got), len(tt.want))
+			}
+			sum := 0
+			for i, a := range got {
+				sum += a.AmountCents
+				if a.Date.Format("2006-01-02") != tt.want[i].date || a.AmountCents != tt.want[i].cents {
+					t.Errorf("accrual %d = %s %d, want %s %d", i+1, a.Date.Format("2006-01-02"), a.AmountCents, tt.want[i].date, tt.want[i].cents)
+				}
+			}
+			if sum != tt.cents {
+				t.Errorf("accruals sum to %d, want %d", sum, tt.cents)
+			}
+		})
+	}
+}
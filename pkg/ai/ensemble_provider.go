@@ -0,0 +1,527 @@
+package ai
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/sourceview"
+)
+
+// EnsembleBackend is one AI provider of an ensemble
+type EnsembleBackend struct {
+	// Name identifies the backend in votes and logs, e.g. "openai:gpt-4o"
+	Name string
+
+	// Provider extracts the information
+	Provider interfaces.AIProvider
+}
+
+// DefaultDisagreementThreshold is the share of voted fields the backends may disagree on by default
+const DefaultDisagreementThreshold = 0.2
+
+// EnsembleOptions configure the merging of an ensemble
+type EnsembleOptions struct {
+	// DisagreementThreshold is the share of voted fields (0-1) the backends may disagree on
+	// before the document needs review, 0 flags every disagreement
+	DisagreementThreshold float64
+
+	// MinSEKCents limits the ensemble to documents of at least this amount in öre, as
+	// extracted by the first backend; smaller documents use the first backend alone
+	MinSEKCents int
+}
+
+// EnsembleAIProvider runs several AI providers on the same document and merges their results
+// field by field by majority vote
+type EnsembleAIProvider struct {
+	backends []EnsembleBackend
+	opts     EnsembleOptions
+	logger   interfaces.Logger
+}
+
+// ensembleResult is the result of one backend
+type ensembleResult struct {
+	backend string
+	info    *interfaces.ReceiptInvoiceInfo
+}
+
+// votedField is a field the backends vote on
+type votedField struct {
+	name string
+
+	// key is the value compared between backends, "" for null
+	key func(info *interfaces.ReceiptInvoiceInfo) string
+
+	// value is the value as shown in the votes
+	value func(info *interfaces.ReceiptInvoiceInfo) string
+
+	// copy sets the field of dst to the field of src
+	copy func(dst, src *interfaces.ReceiptInvoiceInfo)
+}
+
+// votedFields are the fields the backends vote on, se_cent_amount after original_currency
+// description and service_description are free text that no two models word alike, they are
+// taken from the backend that won the most votes, as are recurring and billing_interval, which
+// follow from the classification of that backend. se_cent_amount of foreign documents is
+// converted with each model's own rate and is not voted on, see medianSEKAmount.
+var votedFields = []votedField{
+	{
+		name: "document_type",
+		key: func(info *interfaces.ReceiptInvoiceInfo) string {
+			return strings.ToLower(strings.TrimSpace(info.DocumentType))
+		},
+		value: func(info *interfaces.ReceiptInvoiceInfo) string { return strings.TrimSpace(info.DocumentType) },
+		copy:  func(dst, src *interfaces.ReceiptInvoiceInfo) { dst.DocumentType = src.DocumentType },
+	},
+	{
+		name: "company",
+		key: func(info *interfaces.ReceiptInvoiceInfo) string {
+			return alphanumeric(document.StringValue(info.Company))
+		},
+		value: func(info *interfaces.ReceiptInvoiceInfo) string {
+			return strings.TrimSpace(document.StringValue(info.Company))
+		},
+		copy: func(dst, src *interfaces.ReceiptInvoiceInfo) { dst.Company = src.Company },
+	},
+	{
+		name: "date_issued",
+		key: func(info *interfaces.ReceiptInvoiceInfo) string {
+			if t, ok := document.ParseDate(info); ok {
+				return t.Format(document.DateLayout)
+			}
+			return strings.TrimSpace(document.DateString(info))
+		},
+		value: func(info *interfaces.ReceiptInvoiceInfo) string { return document.DateString(info) },
+		copy:  func(dst, src *interfaces.ReceiptInvoiceInfo) { dst.DateIssued = src.DateIssued },
+	},
+	{
+		name: "period_start",
+		key: func(info *interfaces.ReceiptInvoiceInfo) string {
+			return strings.TrimSpace(document.StringValue(info.PeriodStart))
+		},
+		value: func(info *interfaces.ReceiptInvoiceInfo) string {
+			return strings.TrimSpace(document.StringValue(info.PeriodStart))
+		},
+		copy: func(dst, src *interfaces.ReceiptInvoiceInfo) { dst.PeriodStart = src.PeriodStart },
+	},
+	{
+		name: "period_end",
+		key: func(info *interfaces.ReceiptInvoiceInfo) string {
+			return strings.TrimSpace(document.StringValue(info.PeriodEnd))
+		},
+		value: func(info *interfaces.ReceiptInvoiceInfo) string {
+			return strings.TrimSpace(document.StringValue(info.PeriodEnd))
+		},
+		copy: func(dst, src *interfaces.ReceiptInvoiceInfo) { dst.PeriodEnd = src.PeriodEnd },
+	},
+	{
+		name:  "original_amount",
+		key:   func(info *interfaces.ReceiptInvoiceInfo) string { return formatAmount(info.OriginalAmount) },
+		value: func(info *interfaces.ReceiptInvoiceInfo) string { return formatAmount(info.OriginalAmount) },
+		copy:  func(dst, src *interfaces.ReceiptInvoiceInfo) { dst.OriginalAmount = src.OriginalAmount },
+	},
+	{
+		name: "original_currency",
+		key: func(info *interfaces.ReceiptInvoiceInfo) string {
+			return strings.ToUpper(strings.TrimSpace(document.StringValue(info.OriginalCurrency)))
+		},
+		value: func(info *interfaces.ReceiptInvoiceInfo) string {
+			return strings.ToUpper(strings.TrimSpace(document.StringValue(info.OriginalCurrency)))
+		},
+		copy: func(dst, src *interfaces.ReceiptInvoiceInfo) { dst.OriginalCurrency = src.OriginalCurrency },
+	},
+	{
+		name:  "original_vat_amount",
+		key:   func(info *interfaces.ReceiptInvoiceInfo) string { return formatAmount(info.OriginalVatAmount) },
+		value: func(info *interfaces.ReceiptInvoiceInfo) string { return formatAmount(info.OriginalVatAmount) },
+		copy:  func(dst, src *interfaces.ReceiptInvoiceInfo) { dst.OriginalVatAmount = src.OriginalVatAmount },
+	},
+	{
+		name:  "se_cent_amount",
+		key:   func(info *interfaces.ReceiptInvoiceInfo) string { return formatCents(info.SECentAmount) },
+		value: func(info *interfaces.ReceiptInvoiceInfo) string { return formatCents(info.SECentAmount) },
+		copy:  func(dst, src *interfaces.ReceiptInvoiceInfo) { dst.SECentAmount = src.SECentAmount },
+	},
+}
+
+// NewEnsembleAIProvider creates an ensemble of AI providers
+// The first backend is the primary one, it breaks ties and decides whether a document is large
+// enough for the ensemble.
+func NewEnsembleAIProvider(backends []EnsembleBackend, opts EnsembleOptions, logger interfaces.Logger) (*EnsembleAIProvider, error) {
+	if len(backends) == 0 {
+		return nil, fmt.Errorf("an ensemble needs at least one backend")
+	}
+	seen := make(map[string]bool)
+	for _, backend := range backends {
+		if seen[backend.Name] {
+			return nil, fmt.Errorf("duplicate backend name %q", backend.Name)
+		}
+		seen[backend.Name] = true
+	}
+	if opts.DisagreementThreshold < 0 || opts.DisagreementThreshold > 1 {
+		return nil, fmt.Errorf("disagreement threshold must be between 0 and 1, got %g", opts.DisagreementThreshold)
+	}
+
+	names := make([]string, len(backends))
+	for i, backend := range backends {
+		names[i] = backend.Name
+	}
+	logger.Info("Ensemble initialized with %d backend(s): %s", len(backends), strings.Join(names, ", "))
+
+	return &EnsembleAIProvider{backends: backends, opts: opts, logger: logger}, nil
+}
+
+// GetReceiptInvoiceInfo extracts the information with every backend and merges the results
+func (e *EnsembleAIProvider) GetReceiptInvoiceInfo(content string) (*interfaces.ReceiptInvoiceInfo, error) {
+	backends := e.backends
+	var first *ensembleResult
+	if e.opts.MinSEKCents > 0 {
+		// The primary backend decides whether the document is worth the other calls
+		primary := backends[0]
+		info, err := primary.Provider.GetReceiptInvoiceInfo(content)
+		if err != nil {
+			e.logger.Error("Ensemble backend %s failed: %v", primary.Name, err)
+			return nil, fmt.Errorf("ensemble backend %s failed: %w", primary.Name, err)
+		}
+		if info.SECentAmount == nil || abs(*info.SECentAmount) < e.opts.MinSEKCents {
+			e.logger.Info("Amount is missing or below %.2f SEK, using backend %s alone", float64(e.opts.MinSEKCents)/100, primary.Name)
+			return info, nil
+		}
+		first = &ensembleResult{backend: primary.Name, info: info}
+		backends = backends[1:]
+	}
+
+	return e.collect(backends, first, func(backend EnsembleBackend) (*interfaces.ReceiptInvoiceInfo, error) {
+		return backend.Provider.GetReceiptInvoiceInfo(content)
+	})
+}
+
+// CorrectReceiptInvoiceInfo asks every backend that can correct extractions for a corrected one and merges them
+// Documents below the minimum amount were extracted by the primary backend alone and are
+// corrected by it alone.
+func (e *EnsembleAIProvider) CorrectReceiptInvoiceInfo(content string, previous *interfaces.ReceiptInvoiceInfo, problems []string) (*interfaces.ReceiptInvoiceInfo, error) {
+	backends := e.backends
+	if previous.Ensemble == nil {
+		backends = backends[:1]
+	}
+
+	var correcting []EnsembleBackend
+	for _, backend := range backends {
+		if _, ok := backend.Provider.(interfaces.CorrectingAIProvider); ok {
+			correcting = append(correcting, backend)
+		}
+	}
+	if len(correcting) == 0 {
+		return nil, fmt.Errorf("no ensemble backend can correct extractions")
+	}
+
+	return e.collect(correcting, nil, func(backend EnsembleBackend) (*interfaces.ReceiptInvoiceInfo, error) {
+		return backend.Provider.(interfaces.CorrectingAIProvider).CorrectReceiptInvoiceInfo(content, previous, problems)
+	})
+}
+
+// collect runs extract on the backends in parallel and merges the results with first, if given
+// Failing backends are recorded and skipped, the extraction fails only if all of them fail.
+func (e *EnsembleAIProvider) collect(backends []EnsembleBackend, first *ensembleResult, extract func(backend EnsembleBackend) (*interfaces.ReceiptInvoiceInfo, error)) (*interfaces.ReceiptInvoiceInfo, error) {
+	e.logger.Info("Running %d ensemble backend(s) in parallel", len(backends))
+
+	infos := make([]*interfaces.ReceiptInvoiceInfo, len(backends))
+	errs := make([]error, len(backends))
+	var wg sync.WaitGroup
+	for i, backend := range backends {
+		wg.Add(1)
+		go func(i int, backend EnsembleBackend) {
+			defer wg.Done()
+			infos[i], errs[i] = extract(backend)
+		}(i, backend)
+	}
+	wg.Wait()
+
+	var results []ensembleResult
+	if first != nil {
+		results = append(results, *first)
+	}
+	var failed []string
+	for i, backend := range backends {
+		if errs[i] != nil {
+			e.logger.Warn("Ensemble backend %s failed: %v", backend.Name, errs[i])
+			failed = append(failed, backend.Name)
+			continue
+		}
+		results = append(results, ensembleResult{backend: backend.Name, info: infos[i]})
+	}
+	if len(results) == 0 {
+		e.logger.Error("All %d ensemble backend(s) failed", len(backends))
+		return nil, fmt.Errorf("all ensemble backends failed: %w", errs[0])
+	}
+
+	merged := e.merge(results)
+	merged.Ensemble.Failed = failed
+	return merged, nil
+}
+
+// merge votes on every field of the results and builds the merged result
+func (e *EnsembleAIProvider) merge(results []ensembleResult) *interfaces.ReceiptInvoiceInfo {
+	winners := make([]int, len(results))
+	var votes []interfaces.FieldVote
+	winnerOf := make(map[string]int)
+
+	foreign := false
+	for _, field := range votedFields {
+		if field.name == "se_cent_amount" && foreign {
+			continue
+		}
+		keys := make([]string, len(results))
+		values := make([]string, len(results))
+		for i, r := range results {
+			keys[i] = field.key(r.info)
+			values[i] = field.value(r.info)
+		}
+		vote, winner := countVotes(field.name, results, keys, values)
+		votes = append(votes, vote)
+		winners[winner]++
+		winnerOf[field.name] = winner
+		if field.name == "original_currency" {
+			foreign = vote.Value != "" && vote.Value != "SEK"
+		}
+	}
+
+	idNames, idFields := idFieldVotes(results)
+	for _, name := range idNames {
+		keys := make([]string, len(results))
+		values := make([]string, len(results))
+		for i := range results {
+			keys[i] = idKey(idFields[i][name])
+			values[i] = strings.TrimSpace(idFields[i][name].Value)
+		}
+		vote, winner := countVotes(sourceview.IdFieldPrefix+displayIdName(results, idFields, name), results, keys, values)
+		votes = append(votes, vote)
+		winners[winner]++
+		winnerOf[vote.Field] = winner
+	}
+
+	// The backend that won most votes supplies the free text fields
+	representative := 0
+	for i, wins := range winners {
+		if wins > winners[representative] {
+			representative = i
+		}
+	}
+	merged := *results[representative].info
+	merged.Evidence = nil
+	merged.IdFields = nil
+	merged.CrossChecks = nil
+	merged.Verification = nil
+
+	for _, field := range votedFields {
+		if winner, voted := winnerOf[field.name]; voted {
+			field.copy(&merged, results[winner].info)
+		}
+	}
+	if foreign {
+		merged.SECentAmount = medianSEKAmount(results)
+	}
+
+	for _, vote := range votes {
+		if strings.HasPrefix(vote.Field, sourceview.IdFieldPrefix) && vote.Value != "" {
+			merged.IdFields = append(merged.IdFields, interfaces.IdField{
+				Name:  strings.TrimPrefix(vote.Field, sourceview.IdFieldPrefix),
+				Value: vote.Value,
+			})
+		}
+	}
+
+	// Evidence is taken from the backend whose value was chosen
+	for _, fe := range results[representative].info.Evidence {
+		if _, voted := winnerOf[strings.TrimSpace(fe.Field)]; !voted && !strings.HasPrefix(fe.Field, sourceview.IdFieldPrefix) {
+			merged.Evidence = append(merged.Evidence, fe)
+		}
+	}
+	for _, vote := range votes {
+		if vote.Value == "" {
+			continue
+		}
+		for _, fe := range results[winnerOf[vote.Field]].info.Evidence {
+			if strings.EqualFold(strings.TrimSpace(fe.Field), vote.Field) {
+				merged.Evidence = append(merged.Evidence, fe)
+				break
+			}
+		}
+	}
+
+	names := make([]string, len(results))
+	for i, r := range results {
+		names[i] = r.backend
+	}
+	disagreements := 0
+	noMajority := false
+	for _, vote := range votes {
+		if !vote.Unanimous {
+			disagreements++
+			e.logger.Warn("Ensemble backends disagree on %s: %s", vote.Field, formatVotes(vote, names))
+		}
+		noMajority = noMajority || !vote.Majority
+	}
+	result := &interfaces.EnsembleResult{Backends: names, Votes: votes}
+	if len(votes) > 0 {
+		result.Disagreement = float64(disagreements) / float64(len(votes))
+	}
+	result.NeedsReview = result.Disagreement > e.opts.DisagreementThreshold || noMajority
+	merged.Ensemble = result
+
+	if result.NeedsReview {
+		e.logger.Warn("Ensemble backends disagree on %d of %d field(s), the document needs review", disagreements, len(votes))
+	} else {
+		e.logger.Info("Ensemble backends agree on %d of %d field(s)", len(votes)-disagreements, len(votes))
+	}
+	return &merged
+}
+
+// medianSEKAmount returns the median se_cent_amount of the results, nil if none has one
+// Foreign amounts are converted with each backend's own exchange rate and would never agree.
+func medianSEKAmount(results []ensembleResult) *int {
+	var cents []int
+	for _, r := range results {
+		if r.info.SECentAmount != nil {
+			cents = append(cents, *r.info.SECentAmount)
+		}
+	}
+	if len(cents) == 0 {
+		return nil
+	}
+	sort.Ints(cents)
+	median := cents[len(cents)/2]
+	if len(cents)%2 == 0 {
+		median = int(math.Round(float64(cents[len(cents)/2-1]+cents[len(cents)/2]) / 2))
+	}
+	return &median
+}
+
+// countVotes counts the keys of the results and returns the vote and the index of the winning result
+// Ties are broken by backend order, the first backend is the primary one.
+func countVotes(field string, results []ensembleResult, keys []string, values []string) (interfaces.FieldVote, int) {
+	counts := make(map[string]int)
+	for _, key := range keys {
+		counts[key]++
+	}
+	winner := 0
+	for i, key := range keys {
+		if counts[key] > counts[keys[winner]] {
+			winner = i
+		}
+	}
+
+	vote := interfaces.FieldVote{
+		Field:     field,
+		Votes:     make(map[string]string, len(results)),
+		Unanimous: counts[keys[winner]] == len(results),
+		Majority:  counts[keys[winner]]*2 > len(results),
+	}
+	if keys[winner] != "" {
+		vote.Value = values[winner]
+	}
+	for i, r := range results {
+		vote.Votes[r.backend] = values[i]
+	}
+	return vote, winner
+}
+
+// idFieldVotes returns the normalised names of all ID fields in order of first appearance,
+// and the ID fields of every result by normalised name
+func idFieldVotes(results []ensembleResult) ([]string, []map[string]interfaces.IdField) {
+	var names []string
+	fields := make([]map[string]interfaces.IdField, len(results))
+	for i, r := range results {
+		fields[i] = make(map[string]interfaces.IdField)
+		for _, idField := range r.info.IdFields {
+			name := strings.ToLower(strings.Join(strings.Fields(idField.Name), " "))
+			if _, seen := fields[i][name]; seen || strings.TrimSpace(idField.Value) == "" {
+				continue
+			}
+			fields[i][name] = idField
+			if !containsString(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names, fields
+}
+
+// displayIdName returns the spelling of an ID field name used by the first backend that has it
+func displayIdName(results []ensembleResult, fields []map[string]interfaces.IdField, name string) string {
+	for i := range results {
+		if idField, ok := fields[i][name]; ok {
+			return strings.TrimSpace(idField.Name)
+		}
+	}
+	return name
+}
+
+// idKey compares ID values by their letters and digits, "-" and spaces vary between models
+func idKey(idField interfaces.IdField) string {
+	return strings.ToUpper(alphanumeric(idField.Value))
+}
+
+// alphanumeric returns the lower case letters and digits of s
+func alphanumeric(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// formatCents formats an amount in öre, "" for null
+func formatCents(cents *int) string {
+	if cents == nil {
+		return ""
+	}
+	return strconv.Itoa(*cents)
+}
+
+// formatAmount formats an amount with two decimals, "" for null
+func formatAmount(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*f, 'f', 2, 64)
+}
+
+// formatVotes formats the votes of a field in backend order, e.g. `openai:gpt-4o="95.37", local=null`
+func formatVotes(vote interfaces.FieldVote, names []string) string {
+	parts := make([]string, len(names))
+	for i, name := range names {
+		if value := vote.Votes[name]; value != "" {
+			parts[i] = fmt.Sprintf("%s=%q", name, value)
+		} else {
+			parts[i] = name + "=null"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// containsString reports whether values contains s
+func containsString(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
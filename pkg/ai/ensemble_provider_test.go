@@ -0,0 +1,282 @@
+package ai
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/logger"
+)
+
+func str(s string) *string { return &s }
+
+func num(f float64) *float64 { return &f }
+
+func cents(c int) *int { return &c }
+
+// staticProvider returns the same extraction for every document
+type staticProvider struct {
+	info  *interfaces.ReceiptInvoiceInfo
+	err   error
+	calls int
+}
+
+func (p *staticProvider) GetReceiptInvoiceInfo(content string) (*interfaces.ReceiptInvoiceInfo, error) {
+	p.calls++
+	return p.info, p.err
+}
+
+// extraction returns an invoice of 95.37 EUR as extracted by a backend
+func extraction(seCents int) *interfaces.ReceiptInvoiceInfo {
+	return &interfaces.ReceiptInvoiceInfo{
+		DocumentType:      interfaces.DocumentTypeInvoice,
+		Description:       "AI Services",
+		Company:           str("Anthropic, PBC"),
+		DateIssued:        str("2025-08-02"),
+		OriginalAmount:    num(95.37),
+		OriginalVatAmount: num(19.07),
+		OriginalCurrency:  str("EUR"),
+		SECentAmount:      cents(seCents),
+		IdFields:          []interfaces.IdField{{Name: "Invoice Number", Value: "D8F67A38-0007"}},
+	}
+}
+
+func testEnsemble(t *testing.T, opts EnsembleOptions, providers ...interfaces.AIProvider) *EnsembleAIProvider {
+	backends := make([]EnsembleBackend, len(providers))
+	for i, provider := range providers {
+		backends[i] = EnsembleBackend{Name: fmt.Sprintf("backend%d", i+1), Provider: provider}
+	}
+	ensemble, err := NewEnsembleAIProvider(backends, opts, logger.NewColorLoggerTo(io.Discard))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ensemble
+}
+
+func TestCountVotes(t *testing.T) {
+	tests := []struct {
+		name      string
+		keys      []string
+		winner    int
+		value     string
+		unanimous bool
+		majority  bool
+	}{
+		{"unanimous", []string{"a", "a", "a"}, 0, "A", true, true},
+		{"majority", []string{"a", "b", "b"}, 1, "B", false, true},
+		{"tie goes to the primary backend", []string{"a", "b"}, 0, "A", false, false},
+		{"three way tie", []string{"a", "b", "c"}, 0, "A", false, false},
+		{"tie between later backends", []string{"a", "b", "c", "c", "b"}, 1, "B", false, false},
+		{"null wins", []string{"", "", "a"}, 0, "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results := make([]ensembleResult, len(tt.keys))
+			values := make([]string, len(tt.keys))
+			for i, key := range tt.keys {
+				results[i] = ensembleResult{backend: fmt.Sprintf("backend%d", i+1)}
+				if key != "" {
+					values[i] = string(rune(key[0] - 'a' + 'A'))
+				}
+			}
+			vote, winner := countVotes("company", results, tt.keys, values)
+			if winner != tt.winner || vote.Value != tt.value || vote.Unanimous != tt.unanimous || vote.Majority != tt.majority {
+				t.Errorf("got winner %d value %q unanimous %v majority %v, want %d %q %v %v",
+					winner, vote.Value, vote.Unanimous, vote.Majority, tt.winner, tt.value, tt.unanimous, tt.majority)
+			}
+			if len(vote.Votes) != len(tt.keys) {
+				t.Errorf("votes = %v, want one per backend", vote.Votes)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		name        string
+		infos       func() []*interfaces.ReceiptInvoiceInfo
+		check       func(t *testing.T, merged *interfaces.ReceiptInvoiceInfo)
+		needsReview bool
+	}{
+		{
+			name: "foreign SEK amount is the median",
+			infos: func() []*interfaces.ReceiptInvoiceInfo {
+				return []*interfaces.ReceiptInvoiceInfo{extraction(109677), extraction(104000), extraction(110000)}
+			},
+			check: func(t *testing.T, merged *interfaces.ReceiptInvoiceInfo) {
+				if *merged.SECentAmount != 109677 {
+					t.Errorf("se_cent_amount = %d, want 109677", *merged.SECentAmount)
+				}
+				for _, vote := range merged.Ensemble.Votes {
+					if vote.Field == "se_cent_amount" {
+						t.Errorf("se_cent_amount of a foreign document was voted on")
+					}
+				}
+			},
+		},
+		{
+			name: "foreign SEK amount of two backends is the rounded mean",
+			infos: func() []*interfaces.ReceiptInvoiceInfo {
+				return []*interfaces.ReceiptInvoiceInfo{extraction(109600), extraction(109701)}
+			},
+			check: func(t *testing.T, merged *interfaces.ReceiptInvoiceInfo) {
+				if *merged.SECentAmount != 109651 {
+					t.Errorf("se_cent_amount = %d, want 109651", *merged.SECentAmount)
+				}
+			},
+		},
+		{
+			name: "SEK amount is voted on",
+			infos: func() []*interfaces.ReceiptInvoiceInfo {
+				infos := []*interfaces.ReceiptInvoiceInfo{extraction(9537), extraction(9537), extraction(9538)}
+				for _, info := range infos {
+					info.OriginalCurrency = str("SEK")
+				}
+				return infos
+			},
+			check: func(t *testing.T, merged *interfaces.ReceiptInvoiceInfo) {
+				if *merged.SECentAmount != 9537 {
+					t.Errorf("se_cent_amount = %d, want 9537", *merged.SECentAmount)
+				}
+				votes := map[string]bool{}
+				for _, vote := range merged.Ensemble.Votes {
+					votes[vote.Field] = vote.Unanimous
+				}
+				if unanimous, ok := votes["se_cent_amount"]; !ok || unanimous {
+					t.Errorf("se_cent_amount vote missing or unanimous: %+v", merged.Ensemble.Votes)
+				}
+			},
+		},
+		{
+			name: "tie is broken by the primary backend",
+			infos: func() []*interfaces.ReceiptInvoiceInfo {
+				second := extraction(109677)
+				second.Company = str("Anthropic Ireland")
+				return []*interfaces.ReceiptInvoiceInfo{extraction(109677), second}
+			},
+			check: func(t *testing.T, merged *interfaces.ReceiptInvoiceInfo) {
+				if *merged.Company != "Anthropic, PBC" {
+					t.Errorf("company = %q, want the primary backend's", *merged.Company)
+				}
+			},
+			needsReview: true,
+		},
+		{
+			name: "ID fields are matched by name and letters",
+			infos: func() []*interfaces.ReceiptInvoiceInfo {
+				second := extraction(109677)
+				second.IdFields = []interfaces.IdField{{Name: "invoice  number", Value: "D8F67A380007"}}
+				return []*interfaces.ReceiptInvoiceInfo{extraction(109677), second}
+			},
+			check: func(t *testing.T, merged *interfaces.ReceiptInvoiceInfo) {
+				want := []interfaces.IdField{{Name: "Invoice Number", Value: "D8F67A38-0007"}}
+				if !reflect.DeepEqual(merged.IdFields, want) {
+					t.Errorf("id fields = %+v, want %+v", merged.IdFields, want)
+				}
+			},
+		},
+		{
+			name: "free text comes from the backend that won most votes",
+			infos: func() []*interfaces.ReceiptInvoiceInfo {
+				// The primary backend is outvoted on most fields
+				first := extraction(109677)
+				first.DocumentType = interfaces.DocumentTypeReceipt
+				first.Description = "Software"
+				first.Company = str("Anthropic")
+				first.DateIssued = str("2025-08-03")
+				first.OriginalAmount, first.OriginalVatAmount = num(76.30), num(0)
+				first.IdFields = []interfaces.IdField{{Name: "Invoice Number", Value: "2831-4417"}}
+				second, third := extraction(109677), extraction(109677)
+				third.Description = "AI Subscription"
+				return []*interfaces.ReceiptInvoiceInfo{first, second, third}
+			},
+			check: func(t *testing.T, merged *interfaces.ReceiptInvoiceInfo) {
+				if merged.Description != "AI Services" || *merged.Company != "Anthropic, PBC" || *merged.DateIssued != "2025-08-02" {
+					t.Errorf("merged %q, %q, %q, want the second backend's values", merged.Description, *merged.Company, *merged.DateIssued)
+				}
+			},
+			needsReview: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var results []ensembleResult
+			for i, info := range tt.infos() {
+				results = append(results, ensembleResult{backend: fmt.Sprintf("backend%d", i+1), info: info})
+			}
+			ensemble := &EnsembleAIProvider{opts: EnsembleOptions{DisagreementThreshold: DefaultDisagreementThreshold}, logger: logger.NewColorLoggerTo(io.Discard)}
+			merged := ensemble.merge(results)
+			tt.check(t, merged)
+			if merged.Ensemble.NeedsReview != tt.needsReview {
+				t.Errorf("needs review = %v, want %v (disagreement %.2f)", merged.Ensemble.NeedsReview, tt.needsReview, merged.Ensemble.Disagreement)
+			}
+		})
+	}
+}
+
+func TestEnsembleGetReceiptInvoiceInfo(t *testing.T) {
+	t.Run("failing backend is skipped", func(t *testing.T) {
+		failing := &staticProvider{err: fmt.Errorf("timeout")}
+		ensemble := testEnsemble(t, EnsembleOptions{}, &staticProvider{info: extraction(109677)}, failing, &staticProvider{info: extraction(109677)})
+		merged, err := ensemble.GetReceiptInvoiceInfo("document")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(merged.Ensemble.Failed, []string{"backend2"}) || len(merged.Ensemble.Backends) != 2 {
+			t.Errorf("backends %v failed %v", merged.Ensemble.Backends, merged.Ensemble.Failed)
+		}
+	})
+
+	t.Run("all backends failing", func(t *testing.T) {
+		ensemble := testEnsemble(t, EnsembleOptions{}, &staticProvider{err: fmt.Errorf("timeout")})
+		if _, err := ensemble.GetReceiptInvoiceInfo("document"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+
+	t.Run("small document uses the primary backend alone", func(t *testing.T) {
+		secondary := &staticProvider{info: extraction(50000)}
+		ensemble := testEnsemble(t, EnsembleOptions{MinSEKCents: 100000}, &staticProvider{info: extraction(50000)}, secondary)
+		merged, err := ensemble.GetReceiptInvoiceInfo("document")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if merged.Ensemble != nil || secondary.calls != 0 {
+			t.Errorf("secondary backend was called %d time(s)", secondary.calls)
+		}
+	})
+
+	t.Run("large document uses every backend", func(t *testing.T) {
+		primary, secondary := &staticProvider{info: extraction(109677)}, &staticProvider{info: extraction(109677)}
+		ensemble := testEnsemble(t, EnsembleOptions{MinSEKCents: 100000}, primary, secondary)
+		merged, err := ensemble.GetReceiptInvoiceInfo("document")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if merged.Ensemble == nil || primary.calls != 1 || secondary.calls != 1 {
+			t.Errorf("primary called %d and secondary %d time(s)", primary.calls, secondary.calls)
+		}
+	})
+}
+
+func TestNewEnsembleAIProviderRejectsInvalidOptions(t *testing.T) {
+	provider := &staticProvider{}
+	tests := []struct {
+		name     string
+		backends []EnsembleBackend
+		opts     EnsembleOptions
+	}{
+		{"no backends", nil, EnsembleOptions{}},
+		{"duplicate names", []EnsembleBackend{{Name: "a", Provider: provider}, {Name: "a", Provider: provider}}, EnsembleOptions{}},
+		{"threshold above one", []EnsembleBackend{{Name: "a", Provider: provider}}, EnsembleOptions{DisagreementThreshold: 1.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewEnsembleAIProvider(tt.backends, tt.opts, logger.NewColorLoggerTo(io.Discard)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -12,18 +12,51 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/examples"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/extra"
 	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/prompts"
 )
 
 // OpenAIAIProvider implements the AIProvider interface using OpenAI's API
 type OpenAIAIProvider struct {
-	client *openai.Client
-	logger interfaces.Logger
-	model  string
+	client   *openai.Client
+	logger   interfaces.Logger
+	model    string
+	prompt   *prompts.Prompt
+	examples *examples.Store
+	schema   interface{}
+}
+
+// OpenAIOptions select the model and endpoint of an OpenAI provider
+// Empty fields fall back to the environment: OPENAI_KEY, OPENAI_MODEL and the OpenAI API.
+type OpenAIOptions struct {
+	// Model is the chat model to use
+	Model string
+
+	// BaseURL is the endpoint of an OpenAI compatible API
+	BaseURL string
+
+	// APIKeyEnv is the environment variable holding the API key
+	APIKeyEnv string
+
+	// Prompt is the prompt to use, nil selects the default built-in prompt
+	Prompt *prompts.Prompt
+
+	// Examples are the few-shot examples to choose from, nil includes none
+	Examples *examples.Store
+
+	// ExtraFields are user-defined fields extracted into the extra map
+	ExtraFields []extra.Field
 }
 
 // NewOpenAIAIProvider creates a new OpenAI AI provider
 func NewOpenAIAIProvider(logger interfaces.Logger) (*OpenAIAIProvider, error) {
+	return NewOpenAIAIProviderWithOptions(logger, OpenAIOptions{})
+}
+
+// NewOpenAIAIProviderWithOptions creates a new OpenAI AI provider for a model and endpoint
+func NewOpenAIAIProviderWithOptions(logger interfaces.Logger, opts OpenAIOptions) (*OpenAIAIProvider, error) {
 	// Try to load .env file from current directory
 	// It's okay if the file doesn't exist
 	err := godotenv.Load()
@@ -35,17 +68,24 @@ func NewOpenAIAIProvider(logger interfaces.Logger) (*OpenAIAIProvider, error) {
 	}
 
 	// Get OpenAI API key from environment
-	apiKey := os.Getenv("OPENAI_KEY")
+	keyEnv := opts.APIKeyEnv
+	if keyEnv == "" {
+		keyEnv = "OPENAI_KEY"
+	}
+	apiKey := os.Getenv(keyEnv)
 	if apiKey == "" {
-		logger.Error("OPENAI_KEY environment variable is not set")
-		logger.Error("Please set OPENAI_KEY in your environment or create a .env file with OPENAI_KEY=your-api-key")
-		return nil, fmt.Errorf("OPENAI_KEY environment variable is required")
+		logger.Error("%s environment variable is not set", keyEnv)
+		logger.Error("Please set %s in your environment or create a .env file with %s=your-api-key", keyEnv, keyEnv)
+		return nil, fmt.Errorf("%s environment variable is required", keyEnv)
 	}
 
 	logger.Debug("Initializing OpenAI provider with API key: sk-...%s", apiKey[len(apiKey)-4:])
 	
 	// Get model from environment or use default
-	model := os.Getenv("OPENAI_MODEL")
+	model := opts.Model
+	if model == "" {
+		model = os.Getenv("OPENAI_MODEL")
+	}
 	if model == "" {
 		model = string(openai.ChatModelGPT4o2024_08_06)
 		logger.Warn("OPENAI_MODEL environment variable is not set, defaulting to %s", model)
@@ -53,19 +93,47 @@ func NewOpenAIAIProvider(logger interfaces.Logger) (*OpenAIAIProvider, error) {
 		logger.Info("Using OpenAI model: %s", model)
 	}
 	
-	client := openai.NewClient(
-		option.WithAPIKey(apiKey),
-	)
+	clientOptions := []option.RequestOption{option.WithAPIKey(apiKey)}
+	if opts.BaseURL != "" {
+		logger.Info("Using OpenAI compatible API at %s", opts.BaseURL)
+		clientOptions = append(clientOptions, option.WithBaseURL(opts.BaseURL))
+	}
+	client := openai.NewClient(clientOptions...)
+
+	prompt := opts.Prompt
+	if prompt == nil {
+		if prompt, err = prompts.Load(""); err != nil {
+			logger.Error("Failed to load the default prompt: %v", err)
+			return nil, err
+		}
+	}
+	logger.Info("Using prompt %s (sha256 %s)", prompt.Version(), prompt.Hash()[:12])
+
+	if err := extra.Check(opts.ExtraFields); err != nil {
+		logger.Error("Invalid extra fields: %v", err)
+		return nil, err
+	}
+	if len(opts.ExtraFields) > 0 {
+		logger.Info("Extracting %d extra field(s)", len(opts.ExtraFields))
+	}
 
 	logger.Info("OpenAI provider initialized successfully")
 
 	return &OpenAIAIProvider{
-		client: &client,
-		logger: logger,
-		model:  model,
+		client:   &client,
+		logger:   logger,
+		model:    model,
+		prompt:   prompt,
+		examples: opts.Examples,
+		schema:   SchemaWithExtraFields(opts.ExtraFields),
 	}, nil
 }
 
+// Model returns the name of the model the provider uses
+func (p *OpenAIAIProvider) Model() string {
+	return p.model
+}
+
 // GenerateSchema generates a JSON schema for structured outputs
 func GenerateSchema[T any]() interface{} {
 	// Structured Outputs uses a subset of JSON schema
@@ -82,10 +150,21 @@ func GenerateSchema[T any]() interface{} {
 // Generate the JSON schema at initialization time
 var ReceiptInvoiceInfoSchema = GenerateSchema[interfaces.ReceiptInvoiceInfo]()
 
+// SchemaWithExtraFields returns the schema of ReceiptInvoiceInfo with the user-defined fields
+// added as the required "extra" object, or ReceiptInvoiceInfoSchema if there are none
+func SchemaWithExtraFields(fields []extra.Field) interface{} {
+	if len(fields) == 0 {
+		return ReceiptInvoiceInfoSchema
+	}
+	schema := GenerateSchema[interfaces.ReceiptInvoiceInfo]().(*jsonschema.Schema)
+	schema.Properties.Set(extra.PropertyName, extra.Schema(fields))
+	schema.Required = append(schema.Required, extra.PropertyName)
+	return schema
+}
+
 // GetReceiptInvoiceInfo extracts structured information from receipt/invoice text
 func (p *OpenAIAIProvider) GetReceiptInvoiceInfo(content string) (*interfaces.ReceiptInvoiceInfo, error) {
 	startTime := time.Now()
-	ctx := context.Background()
 
 	p.logger.Info("Starting OpenAI API request for receipt/invoice extraction")
 	p.logger.Debug("Document content length: %d characters", len(content))
@@ -97,45 +176,124 @@ func (p *OpenAIAIProvider) GetReceiptInvoiceInfo(content string) (*interfaces.Re
 	}
 	p.logger.Debug("Document preview: %s", strings.ReplaceAll(contentPreview, "\n", " "))
 
-	// System prompt for the AI to act as an accountant
-	systemPrompt := `You are an experienced accountant reviewing financial documents. Your task is to:
-1. Classify the document as either "None" (not a financial document), "Invoice", or "Receipt"
-2. Create a mandatory Description field (max 50 characters) by analyzing the ENTIRE document:
-   - For "None" documents: describe what the document is about (e.g., "Security notification email")
-   - For "Invoice/Receipt": provide generic accountant-friendly service category (e.g., "AI Services", "Cloud Services", "Computer Parts")
-   - Look at ALL context: document headers, company name, item rows, service names, branding
-   - Transform specific services to generic categories (e.g., "Claude Code MAX Plan" → "AI coding service")
-   - Use company identity as hints (e.g., "Anthropic" → AI services, "AWS" → Cloud services)
-   - Make holistic judgment from all available information in the document
-   - If unclear, reformat the service description more nicely but keep it generic and accountant-friendly
-3. Extract the company name that is offering the service and requesting payment
-4. Extract the date the document was issued (in YYYY-MM-DD format) 
-5. Extract a concise description of the service or items paid for
-6. Extract the total amount in Swedish currency (SEK) and convert it to Swedish cents (öre)
-   - For amounts in SEK: multiply by 100 (e.g., 95.37 SEK = 9537)
-   - For amounts in EUR or other currencies: convert to SEK first using approximate rates (1 EUR ≈ 11.5 SEK), then to cents
-   - Return null if no amount is found or if conversion is not possible
-7. Extract the original amount and currency information:
-   - OriginalAmount: The total amount as it appears in the document (e.g., 95.37 for "€95.37")
-   - OriginalCurrency: The ISO 3-letter currency code (e.g., "EUR", "USD", "SEK", "GBP")
-   - OriginalVatAmount: The VAT/tax amount in the original currency (e.g., 19.07 for "€19.07")
-8. Extract identification fields from the document:
-   - Look for invoice numbers, receipt numbers, customer IDs, order numbers, reference numbers, etc.
-   - Create IdField entries with descriptive names like "Invoice Number", "Receipt Number", "Customer ID"
-   - Extract the actual values associated with these identifiers
-   - Common patterns: "Invoice #123", "Receipt: ABC-456", "Order ID: 789", "Ref: XYZ"
-
-Be precise and extract only information that is clearly present in the document. The Description field is mandatory and must always be provided based on your analysis of the entire document. All other fields are optional and should be null/empty if not found.`
-
-	userPrompt := fmt.Sprintf("Please analyze the following document and extract the required information:\n\n%s", content)
+	systemPrompt, err := p.prompt.System()
+	if err != nil {
+		p.logger.Error("Failed to render the system prompt: %v", err)
+		return nil, err
+	}
+	userPrompt, err := p.prompt.User(content)
+	if err != nil {
+		p.logger.Error("Failed to render the user prompt: %v", err)
+		return nil, err
+	}
 
 	p.logger.Debug("System prompt length: %d characters", len(systemPrompt))
 	p.logger.Debug("User prompt length: %d characters", len(userPrompt))
 
+	exampleMessages, exampleNames, err := p.exampleMessages(content)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []openai.ChatCompletionMessageParamUnion{openai.SystemMessage(systemPrompt)}
+	messages = append(messages, exampleMessages...)
+	messages = append(messages, openai.UserMessage(userPrompt))
+	return p.complete(messages, exampleNames, startTime)
+}
+
+// CorrectReceiptInvoiceInfo asks for a corrected extraction, given the previous extraction and its problems
+// The conversation of the first extraction is replayed with the previous result as the answer,
+// followed by the problems, so the model sees the document and what it got wrong.
+func (p *OpenAIAIProvider) CorrectReceiptInvoiceInfo(content string, previous *interfaces.ReceiptInvoiceInfo, problems []string) (*interfaces.ReceiptInvoiceInfo, error) {
+	startTime := time.Now()
+
+	p.logger.Info("Starting OpenAI API request for a corrected extraction of %d problem(s)", len(problems))
+
+	// Only the fields the model produced are replayed, not those added by post-processing
+	answer := *previous
+	answer.CrossChecks = nil
+	answer.Verification = nil
+	answer.SuggestedFileName = ""
+	answer.SourceFile = ""
+	answer.Source = ""
+	answer.Prompt = nil
+	answer.Ensemble = nil
+	answer.Vendor = nil
+	previousJSON, err := json.Marshal(answer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal previous extraction: %w", err)
+	}
+
+	systemPrompt, err := p.prompt.System()
+	if err != nil {
+		p.logger.Error("Failed to render the system prompt: %v", err)
+		return nil, err
+	}
+	userPrompt, err := p.prompt.User(content)
+	if err != nil {
+		p.logger.Error("Failed to render the user prompt: %v", err)
+		return nil, err
+	}
+	correctionPrompt, err := p.prompt.Correction(problems)
+	if err != nil {
+		p.logger.Error("Failed to render the correction prompt: %v", err)
+		return nil, err
+	}
+	p.logger.Debug("Correction prompt: %s", strings.ReplaceAll(correctionPrompt, "\n", " "))
+
+	exampleMessages, exampleNames, err := p.exampleMessages(content)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := []openai.ChatCompletionMessageParamUnion{openai.SystemMessage(systemPrompt)}
+	messages = append(messages, exampleMessages...)
+	messages = append(messages,
+		openai.UserMessage(userPrompt),
+		openai.AssistantMessage(string(previousJSON)),
+		openai.UserMessage(correctionPrompt),
+	)
+	return p.complete(messages, exampleNames, startTime)
+}
+
+// exampleMessages returns the few-shot examples chosen for a document as pairs of user and
+// assistant messages, and the names of the examples
+func (p *OpenAIAIProvider) exampleMessages(content string) ([]openai.ChatCompletionMessageParamUnion, []string, error) {
+	if p.examples == nil {
+		return nil, nil, nil
+	}
+
+	var messages []openai.ChatCompletionMessageParamUnion
+	var names []string
+	for _, selected := range p.examples.Select(content) {
+		userPrompt, err := p.prompt.User(selected.Example.Text)
+		if err != nil {
+			p.logger.Error("Failed to render example %s: %v", selected.Example.Name, err)
+			return nil, nil, err
+		}
+		answer, err := examples.Answer(selected.Example)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to marshal example %s: %w", selected.Example.Name, err)
+		}
+		messages = append(messages, openai.UserMessage(userPrompt), openai.AssistantMessage(string(answer)))
+		names = append(names, selected.Example.Name)
+		p.logger.Debug("Including example %s (vendor match: %t, similarity %.2f, ~%d tokens)",
+			selected.Example.Name, selected.VendorMatch, selected.Similarity, selected.Tokens)
+	}
+	if len(names) > 0 {
+		p.logger.Info("Including %d few-shot example(s): %s", len(names), strings.Join(names, ", "))
+	}
+	return messages, names, nil
+}
+
+// complete sends messages to the Chat Completions API with structured output and parses the result
+func (p *OpenAIAIProvider) complete(messages []openai.ChatCompletionMessageParamUnion, exampleNames []string, startTime time.Time) (*interfaces.ReceiptInvoiceInfo, error) {
+	ctx := context.Background()
+
 	schemaParam := openai.ResponseFormatJSONSchemaJSONSchemaParam{
 		Name:        "receipt_invoice_info",
 		Description: openai.String("Structured information extracted from a receipt or invoice"),
-		Schema:      ReceiptInvoiceInfoSchema,
+		Schema:      p.schema,
 		Strict:      openai.Bool(true),
 	}
 
@@ -145,10 +303,7 @@ Be precise and extract only information that is clearly present in the document.
 
 	// Query the Chat Completions API with structured output
 	chat, err := p.client.Chat.Completions.New(ctx, openai.ChatCompletionNewParams{
-		Messages: []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage(systemPrompt),
-			openai.UserMessage(userPrompt),
-		},
+		Messages: messages,
 		ResponseFormat: openai.ChatCompletionNewParamsResponseFormatUnion{
 			OfJSONSchema: &openai.ResponseFormatJSONSchemaParam{
 				JSONSchema: schemaParam,
@@ -193,6 +348,7 @@ Be precise and extract only information that is clearly present in the document.
 	}
 
 	p.logger.Info("Successfully parsed OpenAI response")
+	result.Prompt = &interfaces.PromptInfo{Version: p.prompt.Version(), Hash: p.prompt.Hash(), Examples: exampleNames}
 	p.logger.Info("Extracted document type: %s", result.DocumentType)
 	p.logger.Info("Extracted description: %s", result.Description)
 	
@@ -245,6 +401,10 @@ Be precise and extract only information that is clearly present in the document.
 		p.logger.Debug("No ID fields found in document")
 	}
 
+	for _, e := range result.Evidence {
+		p.logger.Debug("Evidence for %s (%s confidence): %q", e.Field, e.Confidence, e.Snippet)
+	}
+
 	p.logger.Info("Total processing time: %v", time.Since(startTime))
 
 	return &result, nil
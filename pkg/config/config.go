@@ -1,14 +1,427 @@
 package config
 
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFileName is the name of the config file looked up when no explicit path is given
+const DefaultConfigFileName = ".reciept-invoice-ai-tool.yaml"
+
 // Config holds the application configuration
-// This is now a generic config that can be extended for future needs
-// AI providers handle their own configuration internally
+// AI providers still handle their credentials internally (OPENAI_KEY etc.),
+// the config file only carries settings for commands that need them
 type Config struct {
-	// Add any global application configuration here in the future
+	// Path is the file the configuration was loaded from, empty if defaults are used
+	Path string `yaml:"-"`
+
+	// Company describes our own company (the buyer in extracted documents)
+	Company CompanyConfig `yaml:"company"`
+
+	// Accounting holds the bookkeeping settings used by accounting exports
+	Accounting AccountingConfig `yaml:"accounting"`
+
+	// Ledger holds the account mapping for plain-text accounting exports
+	Ledger LedgerConfig `yaml:"ledger"`
+
+	// Export holds settings for the export commands
+	Export ExportConfig `yaml:"export"`
+
+	// HTML holds settings for the HTML commands (htmloverview, report)
+	HTML HTMLConfig `yaml:"html"`
+
+	// Organize holds settings for the organize command
+	Organize OrganizeConfig `yaml:"organize"`
+
+	// Naming controls the suggested_filename written by the extract command
+	Naming NamingConfig `yaml:"naming"`
+
+	// Validate configures the rules of the validate command
+	Validate ValidateConfig `yaml:"validate"`
+
+	// Verify controls the self-verification of AI extractions by the extract command
+	Verify VerifyConfig `yaml:"verify"`
+
+	// Prompt selects the prompt of the AI provider
+	Prompt PromptConfig `yaml:"prompt"`
+
+	// Examples configures the few-shot examples included in the prompt
+	Examples ExamplesConfig `yaml:"examples"`
+
+	// Ensemble runs extractions through several AI backends and merges the results by vote
+	Ensemble EnsembleConfig `yaml:"ensemble"`
+
+	// Vendors configures the vendor registry that normalises extracted documents
+	Vendors VendorsConfig `yaml:"vendors"`
+
+	// ExtraFields are user-defined fields extracted in addition to the built-in ones
+	ExtraFields []ExtraFieldConfig `yaml:"extra_fields"`
+}
+
+// CompanyConfig describes our own company
+type CompanyConfig struct {
+	// Name is the registered company name
+	Name string `yaml:"name"`
+
+	// OrgNumber is the Swedish organisation number (e.g. "556677-8899")
+	OrgNumber string `yaml:"org_number"`
+
+	// VATNumber is the VAT registration number (e.g. "SE556677889901")
+	VATNumber string `yaml:"vat_number"`
+
+	// PeppolID is the electronic address as "<scheme>:<id>" (e.g. "0007:5566778899")
+	PeppolID string `yaml:"peppol_id"`
+
+	// BuyerReference is written as Peppol buyer reference (BT-10) on e-invoices
+	BuyerReference string `yaml:"buyer_reference"`
+
+	// Street, PostalCode, City and Country (ISO 3166-1 alpha-2, default "SE") form the postal address
+	Street     string `yaml:"street"`
+	PostalCode string `yaml:"postal_code"`
+	City       string `yaml:"city"`
+	Country    string `yaml:"country"`
+}
+
+// AccountingConfig maps extracted documents to BAS accounts
+type AccountingConfig struct {
+	// ExpenseAccounts maps a Description category to a BAS expense account (matched case-insensitively)
+	ExpenseAccounts map[string]int `yaml:"expense_accounts"`
+
+	// DefaultExpenseAccount is used for categories without a mapping (default 6990)
+	DefaultExpenseAccount int `yaml:"default_expense_account"`
+
+	// ReceiptAccount is credited for receipts, i.e. already paid documents (default 1930)
+	ReceiptAccount int `yaml:"receipt_account"`
+
+	// InvoiceAccount is credited for invoices, i.e. supplier debts (default 2440)
+	InvoiceAccount int `yaml:"invoice_account"`
+
+	// PrepaidAccount holds the cost of service periods until it is periodised (default 1790)
+	PrepaidAccount int `yaml:"prepaid_account"`
+
+	// VAT holds the input VAT accounts
+	VAT VATConfig `yaml:"vat"`
+
+	// AccountNames adds or overrides account names written to exports
+	AccountNames map[int]string `yaml:"account_names"`
+
+	// SIE holds settings for the SIE exporter
+	SIE SIEConfig `yaml:"sie"`
+}
+
+// VATConfig holds the accounts and treatment used when splitting VAT
+type VATConfig struct {
+	// DomesticAccount receives VAT on documents in SEK (default 2641)
+	DomesticAccount int `yaml:"domestic_account"`
+
+	// ForeignAccount receives VAT charged on documents in foreign currency (default 2645)
+	ForeignAccount int `yaml:"foreign_account"`
+
+	// ReverseChargeOutputAccount is credited with calculated VAT for reverse charge purchases (default 2614)
+	ReverseChargeOutputAccount int `yaml:"reverse_charge_output_account"`
+
+	// ReverseChargeRate is the VAT rate in percent used for reverse charge purchases (default 25)
+	ReverseChargeRate float64 `yaml:"reverse_charge_rate"`
+
+	// ForeignTreatment is the VAT treatment for foreign currency documents of foreign sellers:
+	// "none" (keep the VAT in the expense, default), "foreign" (book charged VAT on ForeignAccount)
+	// or "reverse_charge" (calculate VAT on the net amount)
+	ForeignTreatment string `yaml:"foreign_treatment"`
+}
+
+// SIEConfig holds settings for the SIE exporter
+type SIEConfig struct {
+	// Series is the verification series (default "A")
+	Series string `yaml:"series"`
+
+	// StartNumber is the number of the first verification, 0 leaves numbering to the importing program
+	StartNumber int `yaml:"start_number"`
+
+	// FiscalYearStartMonth is the first month (1-12) of the fiscal year (default 1)
+	FiscalYearStartMonth int `yaml:"fiscal_year_start_month"`
+}
+
+// LedgerConfig maps extracted documents to beancount/hledger accounts
+type LedgerConfig struct {
+	// BaseCurrency is the currency of the SEK amount postings (default "SEK")
+	BaseCurrency string `yaml:"base_currency"`
+
+	// Rules are tried in order, the first matching rule decides the expense account
+	Rules []LedgerRule `yaml:"rules"`
+
+	// ExpenseAccounts maps a Description category to an expense account (matched case-insensitively)
+	ExpenseAccounts map[string]string `yaml:"expense_accounts"`
+
+	// DefaultExpenseAccount is used when no rule or category matches (default "Expenses:Uncategorized")
+	DefaultExpenseAccount string `yaml:"default_expense_account"`
+
+	// ReceiptAccount is credited for receipts (default "Assets:Bank")
+	ReceiptAccount string `yaml:"receipt_account"`
+
+	// InvoiceAccount is credited for invoices (default "Liabilities:AccountsPayable")
+	InvoiceAccount string `yaml:"invoice_account"`
+
+	// PrepaidAccount holds the cost of service periods until it is periodised (default "Assets:PrepaidExpenses")
+	PrepaidAccount string `yaml:"prepaid_account"`
+
+	// VATAccount receives the VAT part of the amount, empty keeps VAT in the expense posting
+	VATAccount string `yaml:"vat_account"`
+}
+
+// LedgerRule selects an expense account with regular expressions
+// All non-empty patterns must match for the rule to apply.
+type LedgerRule struct {
+	// Company is matched against the extracted company name
+	Company string `yaml:"company"`
+
+	// Description is matched against the Description category
+	Description string `yaml:"description"`
+
+	// Service is matched against the service description
+	Service string `yaml:"service"`
+
+	// Account is the expense account used when the rule matches
+	Account string `yaml:"account"`
+}
+
+// ExportConfig holds settings shared by the export commands
+type ExportConfig struct {
+	// CSV holds settings for the CSV exporter
+	CSV CSVConfig `yaml:"csv"`
+}
+
+// CSVConfig holds settings for the CSV exporter
+type CSVConfig struct {
+	// Columns is the ordered list of columns to write, empty means the default column set
+	Columns []string `yaml:"columns"`
+
+	// Locale controls the decimal separator and default delimiter (e.g. "en", "sv-SE")
+	Locale string `yaml:"locale"`
+
+	// Delimiter overrides the field delimiter implied by the locale
+	Delimiter string `yaml:"delimiter"`
+
+	// BOM writes a UTF-8 byte order mark so Excel detects the encoding
+	BOM bool `yaml:"bom"`
+}
+
+// HTMLConfig holds settings for the HTML commands
+type HTMLConfig struct {
+	// Template is a custom htmloverview template file or a directory with the template and its partials
+	Template string `yaml:"template"`
+
+	// ReportTemplate is a custom report template file or directory
+	ReportTemplate string `yaml:"report_template"`
+
+	// EmbedSource embeds the source document in htmloverview output
+	EmbedSource bool `yaml:"embed_source"`
+
+	// Lang selects the message catalogue for template labels (en, sv)
+	Lang string `yaml:"lang"`
+
+	// Locale is used by the money, number and date template functions (en, sv-SE), defaults to the locale of Lang
+	Locale string `yaml:"locale"`
+}
+
+// OrganizeConfig holds settings for the organize command
+type OrganizeConfig struct {
+	// Destination is the root directory documents are organised into
+	Destination string `yaml:"destination"`
+
+	// Pattern is the path of each file below Destination (default "{year}/{month}/{suggested_filename}.{ext}")
+	Pattern string `yaml:"pattern"`
+
+	// Copy copies files instead of moving them
+	Copy bool `yaml:"copy"`
+}
+
+// NamingConfig controls how suggested filenames are generated
+type NamingConfig struct {
+	// Pattern is the naming pattern (default "{date}-{company}-{description}-{sek:%dsek}")
+	Pattern string `yaml:"pattern"`
+
+	// MaxLength is the maximum length of a name in characters (default 100)
+	MaxLength int `yaml:"max_length"`
+
+	// Missing replaces fields without a value (default "unknown")
+	Missing string `yaml:"missing"`
+
+	// CompanySuffixes are removed from the end of company names, unset selects the
+	// built-in list (AB, Inc, PBC, Ltd, ...) and an empty list disables stripping
+	CompanySuffixes []string `yaml:"company_suffixes"`
+}
+
+// ValidateConfig configures the rules of the validate command
+type ValidateConfig struct {
+	// FailOn is the lowest severity that makes validate fail: "error" (default), "warning" or "off"
+	FailOn string `yaml:"fail_on"`
+
+	// Rules sets the severity of rules by name: "error", "warning" or "off"
+	Rules map[string]string `yaml:"rules"`
+
+	// MaxFutureDays is the number of days an issue date may lie in the future (default 0)
+	MaxFutureDays int `yaml:"max_future_days"`
+
+	// PeriodToleranceDays is the number of days an issue date may lie outside the service period (default 31)
+	PeriodToleranceDays *int `yaml:"period_tolerance_days"`
+
+	// VATRates are the plausible VAT rates in percent (default 25, 12, 6 and 0)
+	VATRates []float64 `yaml:"vat_rates"`
+
+	// VATTolerance is the allowed deviation from a VAT rate in percentage points (default 0.5)
+	VATTolerance float64 `yaml:"vat_tolerance"`
+
+	// SEKTolerance is the allowed difference in öre between the SEK amount and the original
+	// amount of documents in SEK (default 1)
+	SEKTolerance int `yaml:"sek_tolerance"`
+
+	// ExchangeRates are the expected SEK per unit of foreign currency (e.g. EUR: 11.2),
+	// currencies without a rate are not checked against a rate
+	ExchangeRates map[string]float64 `yaml:"exchange_rates"`
+
+	// ExchangeRateTolerance is the allowed deviation from ExchangeRates in percent (default 10)
+	ExchangeRateTolerance float64 `yaml:"exchange_rate_tolerance"`
+
+	// RequiredFields replaces the required fields of document types (Invoice, Receipt, None)
+	RequiredFields map[string][]string `yaml:"required_fields"`
+}
+
+// VerifyConfig controls the self-verification of AI extractions
+// Extractions are checked with the rules of the validate section, problems are sent back to the
+// AI provider with a request for a corrected extraction.
+type VerifyConfig struct {
+	// MaxRounds is the maximum number of correction requests per document, unset means 1 and 0 disables them
+	MaxRounds *int `yaml:"max_rounds"`
+
+	// Trigger is the lowest validate severity that is sent for correction: "error" or "warning" (default)
+	Trigger string `yaml:"trigger"`
+}
+
+// PromptConfig selects the prompt of the AI provider
+type PromptConfig struct {
+	// Version is a built-in prompt (default "accountant-v2")
+	Version string `yaml:"version"`
+
+	// File is a custom prompt file, it takes precedence over Version and its version is the file name
+	File string `yaml:"file"`
+}
+
+// ExamplesConfig configures the curated few-shot examples included in the prompt
+type ExamplesConfig struct {
+	// Dir is the directory of example JSON files, empty disables examples
+	Dir string `yaml:"dir"`
+
+	// MaxExamples is the maximum number of examples per prompt, unset means 3 and 0 disables examples
+	MaxExamples *int `yaml:"max_examples"`
+
+	// TokenBudget is the estimated number of tokens all examples of a prompt may use (default 2000)
+	TokenBudget int `yaml:"token_budget"`
+
+	// MinSimilarity is the text similarity (0-1) an example needs if its vendor is not in the
+	// document, unset means 0.2 and 0 accepts any example
+	MinSimilarity *float64 `yaml:"min_similarity"`
+}
+
+// VendorsConfig configures the vendor registry
+// Extracted documents are matched to a vendor by organisation number or company name, which
+// normalises the company name and sets the category, account and VAT treatment of the vendor.
+type VendorsConfig struct {
+	// File is the YAML file of the registry, empty disables the registry
+	File string `yaml:"file"`
+
+	// AutoLearn adds the vendors of accepted extractions, those without problems found by the
+	// validate rules, to the registry
+	AutoLearn bool `yaml:"auto_learn"`
+}
+
+// EnsembleConfig runs extractions through several AI backends and merges the results field by
+// field by majority vote, without backends the extract command uses the OpenAI provider alone
+type EnsembleConfig struct {
+	// Backends are the AI backends, the first one is the primary one that breaks ties
+	Backends []EnsembleBackendConfig `yaml:"backends"`
+
+	// DisagreementThreshold is the share of fields (0-1) the backends may disagree on before
+	// the document is flagged for review, unset means 0.2 and 0 flags every disagreement
+	DisagreementThreshold *float64 `yaml:"disagreement_threshold"`
+
+	// MinSEKAmount limits the ensemble to documents of at least this amount in SEK, as extracted
+	// by the primary backend; smaller documents use the primary backend alone (default 0, all documents)
+	MinSEKAmount float64 `yaml:"min_sek_amount"`
+}
+
+// EnsembleBackendConfig configures one AI backend of an ensemble
+type EnsembleBackendConfig struct {
+	// Name identifies the backend in votes and logs (default "<provider>:<model>")
+	Name string `yaml:"name"`
+
+	// Provider is the kind of backend, only "openai" (default) is supported
+	Provider string `yaml:"provider"`
+
+	// Model is the model to use (default OPENAI_MODEL)
+	Model string `yaml:"model"`
+
+	// BaseURL is the endpoint of an OpenAI compatible API (default the OpenAI API)
+	BaseURL string `yaml:"base_url"`
+
+	// APIKeyEnv is the environment variable holding the API key (default OPENAI_KEY)
+	APIKeyEnv string `yaml:"api_key_env"`
+}
+
+// ExtraFieldConfig defines a field the AI provider extracts into the extra map of the output
+type ExtraFieldConfig struct {
+	// Name is the key in the extra map (lower case letters, digits and _), e.g. "project_code"
+	Name string `yaml:"name"`
+
+	// Type is "string" (default), "number", "integer", "boolean" or "date"
+	Type string `yaml:"type"`
+
+	// Description tells the model what to extract
+	Description string `yaml:"description"`
+
+	// Enum limits a string field to the given values
+	Enum []string `yaml:"enum"`
 }
 
 // LoadConfig loads application-wide configuration
-// Currently returns an empty config as providers handle their own config
-func LoadConfig() (*Config, error) {
-	return &Config{}, nil
-}
\ No newline at end of file
+// If path is empty, ./.reciept-invoice-ai-tool.yaml and then ~/.reciept-invoice-ai-tool.yaml
+// are tried. A missing default file is not an error and yields the default config.
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+
+	if path == "" {
+		path = findDefaultConfigFile()
+		if path == "" {
+			return cfg, nil
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	if err := yaml.Unmarshal(content, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	cfg.Path = path
+	return cfg, nil
+}
+
+// findDefaultConfigFile returns the first existing default config file, or "" if none exists
+func findDefaultConfigFile() string {
+	candidates := []string{DefaultConfigFileName}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates, filepath.Join(home, DefaultConfigFileName))
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package document
+
+import (
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// DocumentTypes lists every document type in the order of the schema enum
+var DocumentTypes = []string{
+	interfaces.DocumentTypeNone,
+	interfaces.DocumentTypeInvoice,
+	interfaces.DocumentTypeReceipt,
+	interfaces.DocumentTypeCreditNote,
+	interfaces.DocumentTypePaymentReminder,
+	interfaces.DocumentTypeOrderConfirmation,
+	interfaces.DocumentTypeQuote,
+	interfaces.DocumentTypeAccountStatement,
+	interfaces.DocumentTypeSubscriptionRenewal,
+}
+
+// Bookable reports whether a document is booked: invoices, receipts and credit notes
+// Reminders, confirmations, quotes, statements and renewal notices only refer to transactions
+// that are booked from another document.
+func Bookable(info *interfaces.ReceiptInvoiceInfo) bool {
+	switch info.DocumentType {
+	case interfaces.DocumentTypeInvoice, interfaces.DocumentTypeReceipt, interfaces.DocumentTypeCreditNote:
+		return true
+	}
+	return false
+}
+
+// IsCreditNote reports whether a document is a credit note
+func IsCreditNote(info *interfaces.ReceiptInvoiceInfo) bool {
+	return info.DocumentType == interfaces.DocumentTypeCreditNote
+}
+
+// ReferencedInvoice returns the number of the invoice a credit note or reminder refers to, "" if unknown
+func ReferencedInvoice(info *interfaces.ReceiptInvoiceInfo) string {
+	return strings.TrimSpace(IdFieldValue(info, interfaces.IdFieldReferencedInvoice))
+}
+
+// NegateCreditNote makes the positive amounts of a credit note negative, as credit notes reduce
+// the costs of the invoice they refer to. It reports whether an amount was changed.
+func NegateCreditNote(info *interfaces.ReceiptInvoiceInfo) bool {
+	if !IsCreditNote(info) {
+		return false
+	}
+	changed := false
+	if info.SECentAmount != nil && *info.SECentAmount > 0 {
+		cents := -*info.SECentAmount
+		info.SECentAmount = &cents
+		changed = true
+	}
+	for _, amount := range []**float64{&info.OriginalAmount, &info.OriginalVatAmount} {
+		if *amount != nil && **amount > 0 {
+			negated := -**amount
+			*amount = &negated
+			changed = true
+		}
+	}
+	return changed
+}
+
+// Signed returns the document with the amounts of a credit note negative
+// The document itself is returned if nothing needs to change, otherwise a copy.
+func Signed(info *interfaces.ReceiptInvoiceInfo) *interfaces.ReceiptInvoiceInfo {
+	signed := *info
+	if !NegateCreditNote(&signed) {
+		return info
+	}
+	return &signed
+}
@@ -0,0 +1,159 @@
+package document
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// Document is an extracted ReceiptInvoiceInfo together with the JSON file it was read from
+type Document struct {
+	// Path is the JSON file the document was loaded from
+	Path string
+
+	// Info is the extracted information
+	Info *interfaces.ReceiptInvoiceInfo
+}
+
+// Load reads and parses a single JSON file produced by the extract command
+func Load(path string) (Document, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	var info interfaces.ReceiptInvoiceInfo
+	if err := json.Unmarshal(content, &info); err != nil {
+		return Document{}, fmt.Errorf("failed to parse JSON from %s: %w", path, err)
+	}
+
+	return Document{Path: path, Info: &info}, nil
+}
+
+// LoadAll loads every document found in paths
+// Each path may be a JSON file or a directory, directories are searched recursively
+// for *.json files. The result is sorted by issue date and then by path.
+func LoadAll(paths []string) ([]Document, error) {
+	files, err := ExpandPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make([]Document, 0, len(files))
+	for _, file := range files {
+		doc, err := Load(file)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	Sort(docs)
+	return docs, nil
+}
+
+// ExpandPaths resolves files and directories into a de-duplicated list of JSON files
+func ExpandPaths(paths []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var files []string
+
+	add := func(file string) {
+		clean := filepath.Clean(file)
+		if !seen[clean] {
+			seen[clean] = true
+			files = append(files, clean)
+		}
+	}
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("input path does not exist: %s", path)
+		}
+
+		if !info.IsDir() {
+			add(path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && strings.EqualFold(filepath.Ext(file), ".json") {
+				add(file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan directory %s: %w", path, err)
+		}
+	}
+
+	return files, nil
+}
+
+// Sort orders documents by issue date (documents without a date last) and then by path
+func Sort(docs []Document) {
+	sort.SliceStable(docs, func(i, j int) bool {
+		di, dj := DateString(docs[i].Info), DateString(docs[j].Info)
+		if di != dj {
+			if di == "" || dj == "" {
+				return dj == ""
+			}
+			return di < dj
+		}
+		return docs[i].Path < docs[j].Path
+	})
+}
+
+// DateString returns the issue date or "" if it is missing
+func DateString(info *interfaces.ReceiptInvoiceInfo) string {
+	if info.DateIssued == nil {
+		return ""
+	}
+	return strings.TrimSpace(*info.DateIssued)
+}
+
+// SourcePath returns the path of the document's source file, "" if source_file is not set
+// A relative source_file is tried from the working directory and then from the directory
+// of the JSON file. If neither exists the path relative to the working directory is returned.
+func SourcePath(doc Document) string {
+	source := doc.Info.SourceFile
+	if source == "" || filepath.IsAbs(source) {
+		return source
+	}
+	if _, err := os.Stat(source); err == nil {
+		return source
+	}
+	if beside := filepath.Join(filepath.Dir(doc.Path), source); beside != source {
+		if _, err := os.Stat(beside); err == nil {
+			return beside
+		}
+	}
+	return source
+}
+
+// StringValue dereferences an optional string, returning "" for nil
+func StringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// IdFieldValue returns the value of the first ID field whose name matches (case-insensitive)
+func IdFieldValue(info *interfaces.ReceiptInvoiceInfo, name string) string {
+	for _, idField := range info.IdFields {
+		if strings.EqualFold(strings.TrimSpace(idField.Name), strings.TrimSpace(name)) {
+			return idField.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,114 @@
+package document
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// DateLayout is the layout of DateIssued as requested from the AI provider
+const DateLayout = "2006-01-02"
+
+// ParseDate parses the issue date, returning false if it is missing or malformed
+func ParseDate(info *interfaces.ReceiptInvoiceInfo) (time.Time, bool) {
+	date := DateString(info)
+	if date == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(DateLayout, date)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// MonthKey returns the issue month as "YYYY-MM", or "" if the date is unknown
+func MonthKey(info *interfaces.ReceiptInvoiceInfo) string {
+	t, ok := ParseDate(info)
+	if !ok {
+		return ""
+	}
+	return t.Format("2006-01")
+}
+
+// SEKCents returns the amount in öre, 0 if unknown
+func SEKCents(info *interfaces.ReceiptInvoiceInfo) int {
+	if info.SECentAmount == nil {
+		return 0
+	}
+	return *info.SECentAmount
+}
+
+// VatCents converts the VAT amount to öre using the ratio between the SEK amount and
+// the original amount. It returns false if the VAT amount cannot be determined.
+func VatCents(info *interfaces.ReceiptInvoiceInfo) (int, bool) {
+	if info.OriginalVatAmount == nil || info.SECentAmount == nil {
+		return 0, false
+	}
+
+	// Amounts already in SEK need no conversion
+	if info.OriginalCurrency != nil && *info.OriginalCurrency == "SEK" {
+		return int(math.Round(*info.OriginalVatAmount * 100)), true
+	}
+
+	if info.OriginalAmount == nil || *info.OriginalAmount == 0 {
+		return 0, false
+	}
+
+	rate := float64(*info.SECentAmount) / *info.OriginalAmount
+	return int(math.Round(*info.OriginalVatAmount * rate)), true
+}
+
+// Group is a set of documents sharing a key together with their totals
+type Group struct {
+	// Key is the grouping key (category, company, month, ...)
+	Key string
+
+	// Docs are the documents in the group, in input order
+	Docs []Document
+
+	// SEKCents is the sum of all known SEK amounts of bookable documents in öre
+	SEKCents int
+
+	// VatCents is the sum of all VAT amounts of bookable documents that could be converted to öre
+	VatCents int
+}
+
+// GroupBy groups documents by key, the groups are sorted by key
+// Documents for which key returns "" are collected under fallback. Only bookable documents
+// count towards the totals, credit notes with negative amounts.
+func GroupBy(docs []Document, key func(info *interfaces.ReceiptInvoiceInfo) string, fallback string) []Group {
+	index := make(map[string]int)
+	var groups []Group
+
+	for _, doc := range docs {
+		k := key(doc.Info)
+		if k == "" {
+			k = fallback
+		}
+
+		i, ok := index[k]
+		if !ok {
+			i = len(groups)
+			index[k] = i
+			groups = append(groups, Group{Key: k})
+		}
+
+		groups[i].Docs = append(groups[i].Docs, doc)
+		info := Signed(doc.Info)
+		if !Bookable(info) {
+			continue
+		}
+		groups[i].SEKCents += SEKCents(info)
+		if vat, ok := VatCents(info); ok {
+			groups[i].VatCents += vat
+		}
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Key < groups[j].Key
+	})
+	return groups
+}
@@ -0,0 +1,62 @@
+package document
+
+import (
+	"strings"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// BillingIntervals maps the billing intervals to their length in months
+var BillingIntervals = map[string]int{
+	interfaces.BillingIntervalMonthly:    1,
+	interfaces.BillingIntervalQuarterly:  3,
+	interfaces.BillingIntervalSemiannual: 6,
+	interfaces.BillingIntervalYearly:     12,
+}
+
+// ParsePeriod parses the service period, returning false if a date is missing or malformed
+func ParsePeriod(info *interfaces.ReceiptInvoiceInfo) (time.Time, time.Time, bool) {
+	start, err := time.Parse(DateLayout, strings.TrimSpace(StringValue(info.PeriodStart)))
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	end, err := time.Parse(DateLayout, strings.TrimSpace(StringValue(info.PeriodEnd)))
+	if err != nil {
+		return time.Time{}, time.Time{}, false
+	}
+	return start, end, true
+}
+
+// PeriodString returns the service period as "2025-08-02 – 2025-09-02", "" if it is unknown
+// A period with only one date shows that date.
+func PeriodString(info *interfaces.ReceiptInvoiceInfo) string {
+	start := strings.TrimSpace(StringValue(info.PeriodStart))
+	end := strings.TrimSpace(StringValue(info.PeriodEnd))
+	switch {
+	case start == "":
+		return end
+	case end == "":
+		return start
+	}
+	return start + " – " + end
+}
+
+// ServiceDate returns the date a charge is for: the start of the service period if known,
+// otherwise the issue date
+func ServiceDate(info *interfaces.ReceiptInvoiceInfo) (time.Time, bool) {
+	if start, err := time.Parse(DateLayout, strings.TrimSpace(StringValue(info.PeriodStart))); err == nil {
+		return start, true
+	}
+	return ParseDate(info)
+}
+
+// IsRecurring reports whether the document was classified as a recurring charge
+func IsRecurring(info *interfaces.ReceiptInvoiceInfo) bool {
+	return info.Recurring != nil && *info.Recurring
+}
+
+// BillingInterval returns the billing interval in lower case, "" if it is unknown
+func BillingInterval(info *interfaces.ReceiptInvoiceInfo) string {
+	return strings.ToLower(strings.TrimSpace(StringValue(info.BillingInterval)))
+}
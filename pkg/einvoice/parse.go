@@ -0,0 +1,427 @@
+package einvoice
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Format is a structured e-invoice format
+type Format string
+
+// Supported structured formats
+const (
+	FormatUnknown        Format = ""
+	FormatUBLInvoice     Format = "UBL 2.1 Invoice"
+	FormatUBLCreditNote  Format = "UBL 2.1 CreditNote"
+	FormatCII            Format = "UN/CEFACT CII (EN 16931)"
+	ublCreditNoteNS             = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+	ciiNamespace                = "urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"
+	descriptionMaxLength        = 50
+	ciiDateFormat               = "20060102"
+)
+
+// Detect returns the structured format of content by looking at the XML root element
+// Anything that is not well-formed XML with a known root element is FormatUnknown.
+func Detect(content []byte) Format {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(content, []byte("\ufeff")))
+	if !bytes.HasPrefix(trimmed, []byte("<")) {
+		return FormatUnknown
+	}
+
+	decoder := xml.NewDecoder(bytes.NewReader(trimmed))
+	decoder.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		// The root element is ASCII, Parse reports unsupported charsets
+		if reader, err := charsetReader(charset, input); err == nil {
+			return reader, nil
+		}
+		return input, nil
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return FormatUnknown
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case start.Name.Local == "Invoice" && start.Name.Space == ubl21InvoiceNamespace:
+			return FormatUBLInvoice
+		case start.Name.Local == "CreditNote" && start.Name.Space == ublCreditNoteNS:
+			return FormatUBLCreditNote
+		case start.Name.Local == "CrossIndustryInvoice" && start.Name.Space == ciiNamespace:
+			return FormatCII
+		}
+		return FormatUnknown
+	}
+}
+
+// Parse maps a UBL or CII e-invoice directly to ReceiptInvoiceInfo without calling an AI provider
+// The result is marked with Source "structured".
+func Parse(content []byte) (*interfaces.ReceiptInvoiceInfo, Format, error) {
+	format := Detect(content)
+
+	var parsed *parsedInvoice
+	var err error
+	switch format {
+	case FormatUBLInvoice, FormatUBLCreditNote:
+		parsed, err = parseUBL(content)
+	case FormatCII:
+		parsed, err = parseCII(content)
+	default:
+		return nil, format, fmt.Errorf("content is not a UBL or CII e-invoice")
+	}
+	if err != nil {
+		return nil, format, err
+	}
+
+	parsed.creditNote = parsed.creditNote || format == FormatUBLCreditNote
+	return parsed.toInfo(), format, nil
+}
+
+// parsedInvoice is the format-independent result of parsing an e-invoice
+type parsedInvoice struct {
+	id              string
+	issueDate       string
+	periodStart     string
+	periodEnd       string
+	currency        string
+	taxCurrency     string
+	sellerName      string
+	sellerOrgNumber string
+	sellerVATNumber string
+	buyerReference  string
+	orderReference  string
+	paymentRef      string
+	invoiceRef      string
+	itemNames       []string
+	total           *float64
+	vat             *float64
+	vatInTaxCcy     *float64
+	creditNote      bool
+}
+
+// toInfo maps the parsed invoice to ReceiptInvoiceInfo
+func (p *parsedInvoice) toInfo() *interfaces.ReceiptInvoiceInfo {
+	info := &interfaces.ReceiptInvoiceInfo{
+		DocumentType: interfaces.DocumentTypeInvoice,
+		Source:       interfaces.SourceStructured,
+		IdFields:     []interfaces.IdField{},
+	}
+
+	optional := func(s string) *string {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return nil
+		}
+		return &s
+	}
+
+	info.Company = optional(p.sellerName)
+	info.DateIssued = optional(p.issueDate)
+	info.PeriodStart = optional(p.periodStart)
+	info.PeriodEnd = optional(p.periodEnd)
+	info.OriginalCurrency = optional(strings.ToUpper(p.currency))
+	info.OriginalAmount = p.total
+	info.OriginalVatAmount = p.vat
+
+	if len(p.itemNames) > 0 {
+		info.ServiceDescription = optional(strings.Join(p.itemNames, "; "))
+	}
+
+	// Without an AI there is no categorisation, the first item name is the best description
+	info.Description = "E-invoice"
+	if len(p.itemNames) > 0 && strings.TrimSpace(p.itemNames[0]) != "" {
+		info.Description = truncate(strings.TrimSpace(p.itemNames[0]), descriptionMaxLength)
+	}
+
+	// SEK amount: exact for SEK invoices, otherwise derived from the VAT in tax currency
+	if p.total != nil {
+		switch {
+		case strings.EqualFold(p.currency, "SEK"):
+			cents := int(math.Round(*p.total * 100))
+			info.SECentAmount = &cents
+		case strings.EqualFold(p.taxCurrency, "SEK") && p.vat != nil && *p.vat != 0 && p.vatInTaxCcy != nil:
+			cents := int(math.Round(*p.total * *p.vatInTaxCcy / *p.vat * 100))
+			info.SECentAmount = &cents
+		}
+	}
+
+	addID := func(name, value string) {
+		if value = strings.TrimSpace(value); value != "" {
+			info.IdFields = append(info.IdFields, interfaces.IdField{Name: name, Value: value})
+		}
+	}
+	if p.creditNote {
+		addID("Credit Note Number", p.id)
+		addID(interfaces.IdFieldReferencedInvoice, p.invoiceRef)
+	} else {
+		addID("Invoice Number", p.id)
+	}
+	addID("Order Number", p.orderReference)
+	addID("Buyer Reference", p.buyerReference)
+	addID("Payment Reference", p.paymentRef)
+	addID("Seller Organisation Number", p.sellerOrgNumber)
+	addID("Seller VAT Number", p.sellerVATNumber)
+
+	// Credit notes state positive amounts, they are booked as negative ones
+	if p.creditNote {
+		info.DocumentType = interfaces.DocumentTypeCreditNote
+		document.NegateCreditNote(info)
+	}
+
+	return info
+}
+
+// xmlAmount is an amount element with a currencyID attribute
+type xmlAmount struct {
+	Currency string `xml:"currencyID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// float parses the amount, returning nil if it is empty or invalid
+func (a xmlAmount) float() *float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(a.Value), 64)
+	if err != nil {
+		return nil
+	}
+	return &f
+}
+
+// ublParseParty is the subset of a UBL party needed for the seller
+type ublParseParty struct {
+	Name             string `xml:"PartyName>Name"`
+	RegistrationName string `xml:"PartyLegalEntity>RegistrationName"`
+	LegalCompanyID   string `xml:"PartyLegalEntity>CompanyID"`
+	TaxCompanyID     string `xml:"PartyTaxScheme>CompanyID"`
+}
+
+// ublLine is an invoice or credit note line
+type ublLine struct {
+	ItemName string `xml:"Item>Name"`
+}
+
+// ublDocument maps both UBL Invoice and CreditNote, elements are matched by local name
+type ublDocument struct {
+	ID                   string        `xml:"ID"`
+	IssueDate            string        `xml:"IssueDate"`
+	PeriodStart          string        `xml:"InvoicePeriod>StartDate"`
+	PeriodEnd            string        `xml:"InvoicePeriod>EndDate"`
+	DocumentCurrencyCode string        `xml:"DocumentCurrencyCode"`
+	TaxCurrencyCode      string        `xml:"TaxCurrencyCode"`
+	BuyerReference       string        `xml:"BuyerReference"`
+	OrderReference       string        `xml:"OrderReference>ID"`
+	InvoiceReference     string        `xml:"BillingReference>InvoiceDocumentReference>ID"`
+	Seller               ublParseParty `xml:"AccountingSupplierParty>Party"`
+	PaymentID            string        `xml:"PaymentMeans>PaymentID"`
+	TaxAmounts           []xmlAmount   `xml:"TaxTotal>TaxAmount"`
+	TaxInclusiveAmount   xmlAmount     `xml:"LegalMonetaryTotal>TaxInclusiveAmount"`
+	PayableAmount        xmlAmount     `xml:"LegalMonetaryTotal>PayableAmount"`
+	InvoiceLines         []ublLine     `xml:"InvoiceLine"`
+	CreditNoteLines      []ublLine     `xml:"CreditNoteLine"`
+}
+
+// parseUBL parses a UBL 2.1 Invoice or CreditNote
+func parseUBL(content []byte) (*parsedInvoice, error) {
+	var doc ublDocument
+	if err := decodeXML(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse UBL document: %w", err)
+	}
+
+	parsed := &parsedInvoice{
+		id:              doc.ID,
+		issueDate:       strings.TrimSpace(doc.IssueDate),
+		periodStart:     strings.TrimSpace(doc.PeriodStart),
+		periodEnd:       strings.TrimSpace(doc.PeriodEnd),
+		currency:        strings.TrimSpace(doc.DocumentCurrencyCode),
+		taxCurrency:     strings.TrimSpace(doc.TaxCurrencyCode),
+		sellerName:      firstNonEmpty(doc.Seller.RegistrationName, doc.Seller.Name),
+		sellerOrgNumber: doc.Seller.LegalCompanyID,
+		sellerVATNumber: doc.Seller.TaxCompanyID,
+		buyerReference:  doc.BuyerReference,
+		orderReference:  doc.OrderReference,
+		paymentRef:      doc.PaymentID,
+		invoiceRef:      doc.InvoiceReference,
+	}
+
+	parsed.total = doc.TaxInclusiveAmount.float()
+	if parsed.total == nil {
+		parsed.total = doc.PayableAmount.float()
+	}
+	parsed.vat, parsed.vatInTaxCcy = splitTaxAmounts(doc.TaxAmounts, parsed.currency, parsed.taxCurrency)
+
+	for _, line := range append(doc.InvoiceLines, doc.CreditNoteLines...) {
+		if name := strings.TrimSpace(line.ItemName); name != "" {
+			parsed.itemNames = append(parsed.itemNames, name)
+		}
+	}
+
+	return parsed, nil
+}
+
+// ciiDocument maps the parts of a CII CrossIndustryInvoice needed for the extraction
+type ciiDocument struct {
+	ID            string `xml:"ExchangedDocument>ID"`
+	TypeCode      string `xml:"ExchangedDocument>TypeCode"`
+	IssueDateTime string `xml:"ExchangedDocument>IssueDateTime>DateTimeString"`
+	Transaction   struct {
+		Lines []struct {
+			ProductName string `xml:"SpecifiedTradeProduct>Name"`
+		} `xml:"IncludedSupplyChainTradeLineItem"`
+		Agreement struct {
+			BuyerReference string `xml:"BuyerReference"`
+			SellerName     string `xml:"SellerTradeParty>Name"`
+			SellerLegalID  string `xml:"SellerTradeParty>SpecifiedLegalOrganization>ID"`
+			SellerTaxIDs   []struct {
+				SchemeID string `xml:"schemeID,attr"`
+				Value    string `xml:",chardata"`
+			} `xml:"SellerTradeParty>SpecifiedTaxRegistration>ID"`
+			OrderReference string `xml:"BuyerOrderReferencedDocument>IssuerAssignedID"`
+		} `xml:"ApplicableHeaderTradeAgreement"`
+		Settlement struct {
+			PaymentReference    string      `xml:"PaymentReference"`
+			InvoiceCurrencyCode string      `xml:"InvoiceCurrencyCode"`
+			TaxCurrencyCode     string      `xml:"TaxCurrencyCode"`
+			InvoiceReference    string      `xml:"InvoiceReferencedDocument>IssuerAssignedID"`
+			PeriodStart         string      `xml:"BillingSpecifiedPeriod>StartDateTime>DateTimeString"`
+			PeriodEnd           string      `xml:"BillingSpecifiedPeriod>EndDateTime>DateTimeString"`
+			TaxTotalAmounts     []xmlAmount `xml:"SpecifiedTradeSettlementHeaderMonetarySummation>TaxTotalAmount"`
+			GrandTotalAmount    xmlAmount   `xml:"SpecifiedTradeSettlementHeaderMonetarySummation>GrandTotalAmount"`
+			DuePayableAmount    xmlAmount   `xml:"SpecifiedTradeSettlementHeaderMonetarySummation>DuePayableAmount"`
+		} `xml:"ApplicableHeaderTradeSettlement"`
+	} `xml:"SupplyChainTradeTransaction"`
+}
+
+// parseCII parses a UN/CEFACT Cross Industry Invoice (EN 16931 CII syntax)
+func parseCII(content []byte) (*parsedInvoice, error) {
+	var doc ciiDocument
+	if err := decodeXML(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse CII document: %w", err)
+	}
+
+	agreement := doc.Transaction.Agreement
+	settlement := doc.Transaction.Settlement
+
+	parsed := &parsedInvoice{
+		id:              doc.ID,
+		currency:        strings.TrimSpace(settlement.InvoiceCurrencyCode),
+		taxCurrency:     strings.TrimSpace(settlement.TaxCurrencyCode),
+		sellerName:      agreement.SellerName,
+		sellerOrgNumber: agreement.SellerLegalID,
+		buyerReference:  agreement.BuyerReference,
+		orderReference:  agreement.OrderReference,
+		paymentRef:      settlement.PaymentReference,
+		invoiceRef:      settlement.InvoiceReference,
+		// 381 is the UNTDID 1001 code for a credit note
+		creditNote: strings.TrimSpace(doc.TypeCode) == "381",
+	}
+
+	for _, taxID := range agreement.SellerTaxIDs {
+		if taxID.SchemeID == "VA" || parsed.sellerVATNumber == "" {
+			parsed.sellerVATNumber = taxID.Value
+		}
+	}
+
+	// Dates use format 102 (YYYYMMDD)
+	for _, date := range []struct {
+		value  string
+		target *string
+	}{
+		{doc.IssueDateTime, &parsed.issueDate},
+		{settlement.PeriodStart, &parsed.periodStart},
+		{settlement.PeriodEnd, &parsed.periodEnd},
+	} {
+		if parsedDate, err := time.Parse(ciiDateFormat, strings.TrimSpace(date.value)); err == nil {
+			*date.target = parsedDate.Format("2006-01-02")
+		}
+	}
+
+	parsed.total = settlement.GrandTotalAmount.float()
+	if parsed.total == nil {
+		parsed.total = settlement.DuePayableAmount.float()
+	}
+	parsed.vat, parsed.vatInTaxCcy = splitTaxAmounts(settlement.TaxTotalAmounts, parsed.currency, parsed.taxCurrency)
+
+	for _, line := range doc.Transaction.Lines {
+		if name := strings.TrimSpace(line.ProductName); name != "" {
+			parsed.itemNames = append(parsed.itemNames, name)
+		}
+	}
+
+	return parsed, nil
+}
+
+// splitTaxAmounts picks the VAT total in invoice currency and, if present, in tax currency
+// Amounts without currencyID are assumed to be in invoice currency.
+func splitTaxAmounts(amounts []xmlAmount, currency string, taxCurrency string) (*float64, *float64) {
+	var vat, vatInTaxCurrency *float64
+	for _, a := range amounts {
+		value := a.float()
+		if value == nil {
+			continue
+		}
+		switch {
+		case a.Currency == "" || strings.EqualFold(a.Currency, currency):
+			if vat == nil {
+				vat = value
+			}
+		case taxCurrency != "" && strings.EqualFold(a.Currency, taxCurrency):
+			vatInTaxCurrency = value
+		}
+	}
+	return vat, vatInTaxCurrency
+}
+
+// decodeXML decodes content into v, converting the Latin-1 charsets Swedish senders still use
+func decodeXML(content []byte, v interface{}) error {
+	decoder := xml.NewDecoder(bytes.NewReader(bytes.TrimPrefix(content, []byte("\ufeff"))))
+	decoder.CharsetReader = charsetReader
+	return decoder.Decode(v)
+}
+
+// charsetReader converts a document declared in charset to UTF-8
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1":
+		return charmap.ISO8859_1.NewDecoder().Reader(input), nil
+	case "iso-8859-15", "iso8859-15", "latin9", "latin-9":
+		return charmap.ISO8859_15.NewDecoder().Reader(input), nil
+	case "windows-1252", "cp1252":
+		return charmap.Windows1252.NewDecoder().Reader(input), nil
+	}
+	return nil, fmt.Errorf("unsupported charset %q", charset)
+}
+
+// firstNonEmpty returns the first non-blank value
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// truncate shortens s to at most max runes
+func truncate(s string, max int) string {
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+	return string([]rune(s)[:max])
+}
@@ -0,0 +1,326 @@
+package einvoice
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"golang.org/x/text/encoding/charmap"
+)
+
+const ublInvoiceXML = `<?xml version="1.0" encoding="UTF-8"?>
+<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+  xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+  xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">
+  <cbc:ID>INV-7</cbc:ID>
+  <cbc:IssueDate>2025-08-02</cbc:IssueDate>
+  <cbc:DocumentCurrencyCode>EUR</cbc:DocumentCurrencyCode>
+  <cbc:TaxCurrencyCode>SEK</cbc:TaxCurrencyCode>
+  <cbc:BuyerReference>Team A</cbc:BuyerReference>
+  <cac:InvoicePeriod>
+    <cbc:StartDate>2025-08-02</cbc:StartDate>
+    <cbc:EndDate>2025-09-01</cbc:EndDate>
+  </cac:InvoicePeriod>
+  <cac:OrderReference><cbc:ID>PO-1</cbc:ID></cac:OrderReference>
+  <cac:AccountingSupplierParty><cac:Party>
+    <cac:PartyName><cbc:Name>Cloud</cbc:Name></cac:PartyName>
+    <cac:PartyLegalEntity><cbc:RegistrationName>Cloud Ltd</cbc:RegistrationName></cac:PartyLegalEntity>
+  </cac:Party></cac:AccountingSupplierParty>
+  <cac:TaxTotal><cbc:TaxAmount currencyID="EUR">25.00</cbc:TaxAmount></cac:TaxTotal>
+  <cac:TaxTotal><cbc:TaxAmount currencyID="SEK">287.50</cbc:TaxAmount></cac:TaxTotal>
+  <cac:LegalMonetaryTotal>
+    <cbc:TaxInclusiveAmount currencyID="EUR">125.00</cbc:TaxInclusiveAmount>
+    <cbc:PayableAmount currencyID="EUR">125.00</cbc:PayableAmount>
+  </cac:LegalMonetaryTotal>
+  <cac:InvoiceLine><cac:Item><cbc:Name>Hosting</cbc:Name></cac:Item></cac:InvoiceLine>
+  <cac:InvoiceLine><cac:Item><cbc:Name>Backup</cbc:Name></cac:Item></cac:InvoiceLine>
+</Invoice>`
+
+const ublCreditNoteXML = `<?xml version="1.0" encoding="UTF-8"?>
+<CreditNote xmlns="urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+  xmlns:cac="urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+  xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">
+  <cbc:ID>CN-3</cbc:ID>
+  <cbc:IssueDate>2025-08-10</cbc:IssueDate>
+  <cbc:DocumentCurrencyCode>SEK</cbc:DocumentCurrencyCode>
+  <cac:BillingReference><cac:InvoiceDocumentReference><cbc:ID>F-1001</cbc:ID></cac:InvoiceDocumentReference></cac:BillingReference>
+  <cac:AccountingSupplierParty><cac:Party>
+    <cac:PartyName><cbc:Name>Hosting AB</cbc:Name></cac:PartyName>
+    <cac:PartyTaxScheme><cbc:CompanyID>SE556123456701</cbc:CompanyID></cac:PartyTaxScheme>
+  </cac:Party></cac:AccountingSupplierParty>
+  <cac:TaxTotal><cbc:TaxAmount currencyID="SEK">25.00</cbc:TaxAmount></cac:TaxTotal>
+  <cac:LegalMonetaryTotal><cbc:PayableAmount currencyID="SEK">125.00</cbc:PayableAmount></cac:LegalMonetaryTotal>
+  <cac:CreditNoteLine><cac:Item><cbc:Name>Webbhotell</cbc:Name></cac:Item></cac:CreditNoteLine>
+</CreditNote>`
+
+const ciiInvoiceXML = `<?xml version="1.0" encoding="UTF-8"?>
+<rsm:CrossIndustryInvoice xmlns:rsm="urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"
+  xmlns:ram="urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100"
+  xmlns:udt="urn:un:unece:uncefact:data:standard:UnqualifiedDataType:100">
+  <rsm:ExchangedDocument>
+    <ram:ID>F-1001</ram:ID>
+    <ram:TypeCode>380</ram:TypeCode>
+    <ram:IssueDateTime><udt:DateTimeString format="102">20250915</udt:DateTimeString></ram:IssueDateTime>
+  </rsm:ExchangedDocument>
+  <rsm:SupplyChainTradeTransaction>
+    <ram:IncludedSupplyChainTradeLineItem>
+      <ram:SpecifiedTradeProduct><ram:Name>Webbhotell</ram:Name></ram:SpecifiedTradeProduct>
+    </ram:IncludedSupplyChainTradeLineItem>
+    <ram:ApplicableHeaderTradeAgreement>
+      <ram:SellerTradeParty>
+        <ram:Name>Hosting AB</ram:Name>
+        <ram:SpecifiedLegalOrganization><ram:ID>5561234567</ram:ID></ram:SpecifiedLegalOrganization>
+        <ram:SpecifiedTaxRegistration><ram:ID schemeID="FC">12345</ram:ID></ram:SpecifiedTaxRegistration>
+        <ram:SpecifiedTaxRegistration><ram:ID schemeID="VA">SE556123456701</ram:ID></ram:SpecifiedTaxRegistration>
+      </ram:SellerTradeParty>
+    </ram:ApplicableHeaderTradeAgreement>
+    <ram:ApplicableHeaderTradeSettlement>
+      <ram:PaymentReference>12345</ram:PaymentReference>
+      <ram:InvoiceCurrencyCode>SEK</ram:InvoiceCurrencyCode>
+      <ram:BillingSpecifiedPeriod>
+        <ram:StartDateTime><udt:DateTimeString format="102">20251001</udt:DateTimeString></ram:StartDateTime>
+        <ram:EndDateTime><udt:DateTimeString format="102">20251231</udt:DateTimeString></ram:EndDateTime>
+      </ram:BillingSpecifiedPeriod>
+      <ram:SpecifiedTradeSettlementHeaderMonetarySummation>
+        <ram:TaxTotalAmount currencyID="SEK">25.00</ram:TaxTotalAmount>
+        <ram:GrandTotalAmount>125.00</ram:GrandTotalAmount>
+      </ram:SpecifiedTradeSettlementHeaderMonetarySummation>
+    </ram:ApplicableHeaderTradeSettlement>
+  </rsm:SupplyChainTradeTransaction>
+</rsm:CrossIndustryInvoice>`
+
+// parsed is the subset of ReceiptInvoiceInfo compared by the tests, "" and 0 mean unset
+type parsed struct {
+	DocumentType string
+	Company      string
+	DateIssued   string
+	PeriodStart  string
+	PeriodEnd    string
+	Currency     string
+	Amount       float64
+	Vat          float64
+	SECents      int
+	Description  string
+	IdFields     []interfaces.IdField
+}
+
+func summarize(info *interfaces.ReceiptInvoiceInfo) parsed {
+	deref := func(s *string) string {
+		if s == nil {
+			return ""
+		}
+		return *s
+	}
+	p := parsed{
+		DocumentType: info.DocumentType,
+		Company:      deref(info.Company),
+		DateIssued:   deref(info.DateIssued),
+		PeriodStart:  deref(info.PeriodStart),
+		PeriodEnd:    deref(info.PeriodEnd),
+		Currency:     deref(info.OriginalCurrency),
+		Description:  info.Description,
+		IdFields:     info.IdFields,
+	}
+	if info.OriginalAmount != nil {
+		p.Amount = *info.OriginalAmount
+	}
+	if info.OriginalVatAmount != nil {
+		p.Vat = *info.OriginalVatAmount
+	}
+	if info.SECentAmount != nil {
+		p.SECents = *info.SECentAmount
+	}
+	return p
+}
+
+func latin1(t *testing.T, s string) string {
+	encoded, err := charmap.ISO8859_1.NewEncoder().String(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return encoded
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content func(t *testing.T) string
+		format  Format
+		want    parsed
+	}{
+		{
+			name:    "UBL invoice in EUR with SEK tax currency",
+			content: func(t *testing.T) string { return ublInvoiceXML },
+			format:  FormatUBLInvoice,
+			want: parsed{
+				DocumentType: interfaces.DocumentTypeInvoice,
+				Company:      "Cloud Ltd",
+				DateIssued:   "2025-08-02",
+				PeriodStart:  "2025-08-02",
+				PeriodEnd:    "2025-09-01",
+				Currency:     "EUR",
+				Amount:       125,
+				Vat:          25,
+				SECents:      143750,
+				Description:  "Hosting",
+				IdFields: []interfaces.IdField{
+					{Name: "Invoice Number", Value: "INV-7"},
+					{Name: "Order Number", Value: "PO-1"},
+					{Name: "Buyer Reference", Value: "Team A"},
+				},
+			},
+		},
+		{
+			name:    "UBL credit note",
+			content: func(t *testing.T) string { return ublCreditNoteXML },
+			format:  FormatUBLCreditNote,
+			want: parsed{
+				DocumentType: interfaces.DocumentTypeCreditNote,
+				Company:      "Hosting AB",
+				DateIssued:   "2025-08-10",
+				Currency:     "SEK",
+				Amount:       -125,
+				Vat:          -25,
+				SECents:      -12500,
+				Description:  "Webbhotell",
+				IdFields: []interfaces.IdField{
+					{Name: "Credit Note Number", Value: "CN-3"},
+					{Name: interfaces.IdFieldReferencedInvoice, Value: "F-1001"},
+					{Name: "Seller VAT Number", Value: "SE556123456701"},
+				},
+			},
+		},
+		{
+			name:    "CII invoice",
+			content: func(t *testing.T) string { return ciiInvoiceXML },
+			format:  FormatCII,
+			want: parsed{
+				DocumentType: interfaces.DocumentTypeInvoice,
+				Company:      "Hosting AB",
+				DateIssued:   "2025-09-15",
+				PeriodStart:  "2025-10-01",
+				PeriodEnd:    "2025-12-31",
+				Currency:     "SEK",
+				Amount:       125,
+				Vat:          25,
+				SECents:      12500,
+				Description:  "Webbhotell",
+				IdFields: []interfaces.IdField{
+					{Name: "Invoice Number", Value: "F-1001"},
+					{Name: "Payment Reference", Value: "12345"},
+					{Name: "Seller Organisation Number", Value: "5561234567"},
+					{Name: "Seller VAT Number", Value: "SE556123456701"},
+				},
+			},
+		},
+		{
+			name: "CII credit note",
+			content: func(t *testing.T) string {
+				return strings.Replace(ciiInvoiceXML, "<ram:TypeCode>380", "<ram:TypeCode>381", 1)
+			},
+			format: FormatCII,
+			want: parsed{
+				DocumentType: interfaces.DocumentTypeCreditNote,
+				Company:      "Hosting AB",
+				DateIssued:   "2025-09-15",
+				PeriodStart:  "2025-10-01",
+				PeriodEnd:    "2025-12-31",
+				Currency:     "SEK",
+				Amount:       -125,
+				Vat:          -25,
+				SECents:      -12500,
+				Description:  "Webbhotell",
+				IdFields: []interfaces.IdField{
+					{Name: "Credit Note Number", Value: "F-1001"},
+					{Name: "Payment Reference", Value: "12345"},
+					{Name: "Seller Organisation Number", Value: "5561234567"},
+					{Name: "Seller VAT Number", Value: "SE556123456701"},
+				},
+			},
+		},
+		{
+			name: "Latin-1 charset",
+			content: func(t *testing.T) string {
+				content := strings.Replace(ublCreditNoteXML, `encoding="UTF-8"`, `encoding="ISO-8859-1"`, 1)
+				return latin1(t, strings.ReplaceAll(content, "Hosting AB", "Åkerö Städ AB"))
+			},
+			format: FormatUBLCreditNote,
+			want: parsed{
+				DocumentType: interfaces.DocumentTypeCreditNote,
+				Company:      "Åkerö Städ AB",
+				DateIssued:   "2025-08-10",
+				Currency:     "SEK",
+				Amount:       -125,
+				Vat:          -25,
+				SECents:      -12500,
+				Description:  "Webbhotell",
+				IdFields: []interfaces.IdField{
+					{Name: "Credit Note Number", Value: "CN-3"},
+					{Name: interfaces.IdFieldReferencedInvoice, Value: "F-1001"},
+					{Name: "Seller VAT Number", Value: "SE556123456701"},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, format, err := Parse([]byte(tt.content(t)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if format != tt.format {
+				t.Errorf("format = %q, want %q", format, tt.format)
+			}
+			if info.Source != interfaces.SourceStructured {
+				t.Errorf("source = %q, want %q", info.Source, interfaces.SourceStructured)
+			}
+			if got := summarize(info); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parsed\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"not XML", "%PDF-1.7", "not a UBL or CII e-invoice"},
+		{"unknown root element", `<?xml version="1.0"?><Order xmlns="urn:example"/>`, "not a UBL or CII e-invoice"},
+		{"unsupported charset", strings.Replace(ublInvoiceXML, `encoding="UTF-8"`, `encoding="KOI8-R"`, 1), `unsupported charset "KOI8-R"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := Parse([]byte(tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    Format
+	}{
+		{"UBL invoice", ublInvoiceXML, FormatUBLInvoice},
+		{"UBL credit note", ublCreditNoteXML, FormatUBLCreditNote},
+		{"CII", ciiInvoiceXML, FormatCII},
+		{"byte order mark", "\ufeff" + ublInvoiceXML, FormatUBLInvoice},
+		{"Invoice in another namespace", `<Invoice xmlns="urn:example"/>`, FormatUnknown},
+		{"malformed XML", "<Invoice", FormatUnknown},
+		{"plain text", "Invoice 123", FormatUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect([]byte(tt.content)); got != tt.want {
+				t.Errorf("Detect = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
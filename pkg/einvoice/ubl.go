@@ -0,0 +1,492 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/config"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// Peppol BIS Billing 3.0 identifiers
+const (
+	PeppolCustomizationID = "urn:cen.eu:en16931:2017#compliant#urn:fdc:peppol.eu:2017:poacc:billing:3.0"
+	PeppolProfileID       = "urn:fdc:peppol.eu:2017:poacc:billing:01:1.0"
+)
+
+// UBL 2.1 namespaces
+const (
+	ubl21InvoiceNamespace    = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	ubl21CreditNoteNamespace = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+	ubl21CACNamespace        = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	ubl21CBCNamespace        = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+)
+
+// MissingField is a mandatory Peppol BIS 3.0 field that could not be filled from the extracted data
+type MissingField struct {
+	// Term is the EN 16931 business term (e.g. "BT-1")
+	Term string `json:"term"`
+
+	// Name is the business term name
+	Name string `json:"name"`
+
+	// Reason explains why the value is missing
+	Reason string `json:"reason"`
+}
+
+// ublAmount is an amount with a currencyID attribute
+type ublAmount struct {
+	Currency string `xml:"currencyID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+// ublID is an identifier with an optional schemeID attribute
+type ublID struct {
+	SchemeID string `xml:"schemeID,attr,omitempty"`
+	Value    string `xml:",chardata"`
+}
+
+type ublTaxScheme struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublTaxCategory struct {
+	ID        string       `xml:"cbc:ID"`
+	Percent   string       `xml:"cbc:Percent,omitempty"`
+	TaxScheme ublTaxScheme `xml:"cac:TaxScheme"`
+}
+
+type ublCountry struct {
+	IdentificationCode string `xml:"cbc:IdentificationCode"`
+}
+
+type ublAddress struct {
+	StreetName string      `xml:"cbc:StreetName,omitempty"`
+	CityName   string      `xml:"cbc:CityName,omitempty"`
+	PostalZone string      `xml:"cbc:PostalZone,omitempty"`
+	Country    *ublCountry `xml:"cac:Country,omitempty"`
+}
+
+type ublPartyName struct {
+	Name string `xml:"cbc:Name"`
+}
+
+type ublPartyTaxScheme struct {
+	CompanyID string       `xml:"cbc:CompanyID"`
+	TaxScheme ublTaxScheme `xml:"cac:TaxScheme"`
+}
+
+type ublPartyLegalEntity struct {
+	RegistrationName string `xml:"cbc:RegistrationName"`
+	CompanyID        string `xml:"cbc:CompanyID,omitempty"`
+}
+
+type ublParty struct {
+	EndpointID       *ublID               `xml:"cbc:EndpointID,omitempty"`
+	PartyName        *ublPartyName        `xml:"cac:PartyName,omitempty"`
+	PostalAddress    ublAddress           `xml:"cac:PostalAddress"`
+	PartyTaxScheme   *ublPartyTaxScheme   `xml:"cac:PartyTaxScheme,omitempty"`
+	PartyLegalEntity *ublPartyLegalEntity `xml:"cac:PartyLegalEntity,omitempty"`
+}
+
+type ublPartyWrapper struct {
+	Party ublParty `xml:"cac:Party"`
+}
+
+type ublOrderReference struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublInvoiceDocumentReference struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublBillingReference struct {
+	InvoiceDocumentReference ublInvoiceDocumentReference `xml:"cac:InvoiceDocumentReference"`
+}
+
+type ublTaxSubtotal struct {
+	TaxableAmount ublAmount      `xml:"cbc:TaxableAmount"`
+	TaxAmount     ublAmount      `xml:"cbc:TaxAmount"`
+	TaxCategory   ublTaxCategory `xml:"cac:TaxCategory"`
+}
+
+type ublTaxTotal struct {
+	TaxAmount    ublAmount        `xml:"cbc:TaxAmount"`
+	TaxSubtotals []ublTaxSubtotal `xml:"cac:TaxSubtotal,omitempty"`
+}
+
+type ublMonetaryTotal struct {
+	LineExtensionAmount ublAmount `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount  ublAmount `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount  ublAmount `xml:"cbc:TaxInclusiveAmount"`
+	PayableAmount       ublAmount `xml:"cbc:PayableAmount"`
+}
+
+type ublQuantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ublItem struct {
+	Name                  string         `xml:"cbc:Name"`
+	ClassifiedTaxCategory ublTaxCategory `xml:"cac:ClassifiedTaxCategory"`
+}
+
+type ublPrice struct {
+	PriceAmount ublAmount `xml:"cbc:PriceAmount"`
+}
+
+// ublInvoiceLine is an invoice line, or a credit note line with a credited quantity
+type ublInvoiceLine struct {
+	ID                  string       `xml:"cbc:ID"`
+	InvoicedQuantity    *ublQuantity `xml:"cbc:InvoicedQuantity,omitempty"`
+	CreditedQuantity    *ublQuantity `xml:"cbc:CreditedQuantity,omitempty"`
+	LineExtensionAmount ublAmount    `xml:"cbc:LineExtensionAmount"`
+	Item                ublItem      `xml:"cac:Item"`
+	Price               ublPrice     `xml:"cac:Price"`
+}
+
+// UBLInvoice is a UBL 2.1 invoice or credit note following the Peppol BIS Billing 3.0 element order
+// XMLName is Invoice or CreditNote, only the type code and lines of the document type are set.
+type UBLInvoice struct {
+	XMLName  xml.Name
+	Xmlns    string `xml:"xmlns,attr"`
+	XmlnsCAC string `xml:"xmlns:cac,attr"`
+	XmlnsCBC string `xml:"xmlns:cbc,attr"`
+
+	CustomizationID         string               `xml:"cbc:CustomizationID"`
+	ProfileID               string               `xml:"cbc:ProfileID"`
+	ID                      string               `xml:"cbc:ID"`
+	IssueDate               string               `xml:"cbc:IssueDate"`
+	InvoiceTypeCode         string               `xml:"cbc:InvoiceTypeCode,omitempty"`
+	CreditNoteTypeCode      string               `xml:"cbc:CreditNoteTypeCode,omitempty"`
+	Note                    string               `xml:"cbc:Note,omitempty"`
+	DocumentCurrencyCode    string               `xml:"cbc:DocumentCurrencyCode"`
+	TaxCurrencyCode         string               `xml:"cbc:TaxCurrencyCode,omitempty"`
+	BuyerReference          string               `xml:"cbc:BuyerReference,omitempty"`
+	OrderReference          *ublOrderReference   `xml:"cac:OrderReference,omitempty"`
+	BillingReference        *ublBillingReference `xml:"cac:BillingReference,omitempty"`
+	AccountingSupplierParty ublPartyWrapper      `xml:"cac:AccountingSupplierParty"`
+	AccountingCustomerParty ublPartyWrapper      `xml:"cac:AccountingCustomerParty"`
+	TaxTotals               []ublTaxTotal        `xml:"cac:TaxTotal"`
+	LegalMonetaryTotal      ublMonetaryTotal     `xml:"cac:LegalMonetaryTotal"`
+	InvoiceLines            []ublInvoiceLine     `xml:"cac:InvoiceLine"`
+	CreditNoteLines         []ublInvoiceLine     `xml:"cac:CreditNoteLine"`
+}
+
+// Standard VAT rates that computed rates are snapped to
+var standardVatRates = []float64{25, 12, 6, 0}
+
+// invoiceNumberPattern matches ID field names that hold the invoice number
+var invoiceNumberPattern = regexp.MustCompile(`(?i)invoice|faktura`)
+
+// creditNoteNumberPattern matches ID field names that hold the credit note number
+var creditNoteNumberPattern = regexp.MustCompile(`(?i)credit|kredit`)
+
+// orderNumberPattern matches ID field names that hold an order or purchase order number
+var orderNumberPattern = regexp.MustCompile(`(?i)order|purchase|beställning`)
+
+// BuildUBLInvoice maps extracted information to a Peppol BIS 3.0 invoice, credit notes to a
+// Peppol BIS 3.0 credit note
+// The buyer is taken from the company config. Mandatory fields that cannot be filled
+// are left out of the XML and returned as MissingField entries. Credit notes are stored with
+// negative amounts, older or edited files may have positive ones, but are always written with
+// positive ones, as the document type carries the sign.
+func BuildUBLInvoice(info *interfaces.ReceiptInvoiceInfo, buyer config.CompanyConfig) (*UBLInvoice, []MissingField) {
+	var missing []MissingField
+	miss := func(term, name, reason string) {
+		missing = append(missing, MissingField{Term: term, Name: name, Reason: reason})
+	}
+
+	info = document.Signed(info)
+	creditNote := document.IsCreditNote(info)
+
+	invoice := &UBLInvoice{
+		XMLName:         xml.Name{Local: "Invoice"},
+		Xmlns:           ubl21InvoiceNamespace,
+		XmlnsCAC:        ubl21CACNamespace,
+		XmlnsCBC:        ubl21CBCNamespace,
+		CustomizationID: PeppolCustomizationID,
+		ProfileID:       PeppolProfileID,
+		InvoiceTypeCode: "380",
+		Note:            strings.TrimSpace(info.Description),
+	}
+	numberPattern := invoiceNumberPattern
+	if creditNote {
+		invoice.XMLName = xml.Name{Local: "CreditNote"}
+		invoice.Xmlns = ubl21CreditNoteNamespace
+		invoice.InvoiceTypeCode = ""
+		invoice.CreditNoteTypeCode = "381"
+		numberPattern = creditNoteNumberPattern
+	}
+
+	// BT-1 Invoice number
+	invoice.ID = findIdField(info, numberPattern)
+	if invoice.ID == "" {
+		for _, idField := range info.IdFields {
+			if idField.Name != interfaces.IdFieldReferencedInvoice && strings.TrimSpace(idField.Value) != "" {
+				invoice.ID = strings.TrimSpace(idField.Value)
+				break
+			}
+		}
+	}
+	if invoice.ID == "" {
+		miss("BT-1", "Invoice number", "no ID fields were extracted")
+	}
+
+	// BT-2 Issue date
+	if date, ok := document.ParseDate(info); ok {
+		invoice.IssueDate = date.Format(document.DateLayout)
+	} else {
+		miss("BT-2", "Invoice issue date", "date_issued is missing or not in YYYY-MM-DD format")
+	}
+
+	// BT-5 Currency
+	currency := strings.ToUpper(strings.TrimSpace(document.StringValue(info.OriginalCurrency)))
+	if currency == "" && info.SECentAmount != nil {
+		currency = "SEK"
+	}
+	if currency == "" {
+		miss("BT-5", "Invoice currency code", "original_currency is missing")
+	}
+	invoice.DocumentCurrencyCode = currency
+
+	// BT-10/BT-13 Buyer reference or order reference (PEPPOL-EN16931-R003)
+	invoice.BuyerReference = buyer.BuyerReference
+	if order := findIdField(info, orderNumberPattern); order != "" {
+		invoice.OrderReference = &ublOrderReference{ID: order}
+	}
+	if invoice.BuyerReference == "" && invoice.OrderReference == nil {
+		miss("BT-10", "Buyer reference", "set company.buyer_reference in the config or extract an order number")
+	}
+
+	// BT-25 Preceding invoice reference of a credit note
+	if reference := document.ReferencedInvoice(info); creditNote && reference != "" {
+		invoice.BillingReference = &ublBillingReference{InvoiceDocumentReference: ublInvoiceDocumentReference{ID: reference}}
+	}
+
+	invoice.AccountingSupplierParty.Party = buildSupplierParty(info, miss)
+	invoice.AccountingCustomerParty.Party = buildCustomerParty(buyer, miss)
+
+	// Amounts: total and VAT in document currency, net derived from them
+	total, hasTotal := documentTotal(info, currency)
+	if !hasTotal {
+		miss("BT-112", "Invoice total amount with VAT", "original_amount and se_cent_amount are missing")
+	}
+
+	vat, hasVat := documentVat(info)
+	if !hasVat {
+		miss("BT-110", "Invoice total VAT amount", "original_vat_amount is missing")
+	}
+	if creditNote {
+		total, vat = abs(total), abs(vat)
+	}
+
+	net := total - vat
+	category := ublTaxCategory{ID: "S", TaxScheme: ublTaxScheme{ID: "VAT"}}
+	if hasVat && vat == 0 {
+		category.ID = "Z"
+	}
+	if net != 0 && hasVat {
+		category.Percent = formatPercent(snapVatRate(float64(vat) / float64(net) * 100))
+	} else {
+		category.Percent = "0"
+		if !hasVat {
+			miss("BT-119", "VAT category rate", "cannot be derived without a VAT amount")
+		}
+	}
+
+	invoice.TaxTotals = []ublTaxTotal{{
+		TaxAmount: amount(vat, currency),
+		TaxSubtotals: []ublTaxSubtotal{{
+			TaxableAmount: amount(net, currency),
+			TaxAmount:     amount(vat, currency),
+			TaxCategory:   category,
+		}},
+	}}
+
+	// BT-111 VAT in accounting currency when the invoice is in foreign currency
+	if currency != "" && currency != "SEK" && hasVat {
+		if vatSEK, ok := document.VatCents(info); ok {
+			if creditNote {
+				vatSEK = abs(vatSEK)
+			}
+			invoice.TaxCurrencyCode = "SEK"
+			invoice.TaxTotals = append(invoice.TaxTotals, ublTaxTotal{TaxAmount: amount(vatSEK, "SEK")})
+		}
+	}
+
+	invoice.LegalMonetaryTotal = ublMonetaryTotal{
+		LineExtensionAmount: amount(net, currency),
+		TaxExclusiveAmount:  amount(net, currency),
+		TaxInclusiveAmount:  amount(total, currency),
+		PayableAmount:       amount(total, currency),
+	}
+
+	// A single line carrying the whole net amount, the extraction has no line items
+	itemName := strings.TrimSpace(document.StringValue(info.ServiceDescription))
+	if itemName == "" {
+		itemName = strings.TrimSpace(info.Description)
+	}
+	if itemName == "" {
+		miss("BT-153", "Item name", "service_description and description are empty")
+	}
+	line := ublInvoiceLine{
+		ID:                  "1",
+		LineExtensionAmount: amount(net, currency),
+		Item: ublItem{
+			Name:                  itemName,
+			ClassifiedTaxCategory: category,
+		},
+		Price: ublPrice{PriceAmount: amount(net, currency)},
+	}
+	quantity := &ublQuantity{UnitCode: "C62", Value: "1"}
+	if creditNote {
+		line.CreditedQuantity = quantity
+		invoice.CreditNoteLines = []ublInvoiceLine{line}
+	} else {
+		line.InvoicedQuantity = quantity
+		invoice.InvoiceLines = []ublInvoiceLine{line}
+	}
+
+	return invoice, missing
+}
+
+// WriteUBL writes the invoice as indented XML with an XML declaration
+func WriteUBL(w io.Writer, invoice *UBLInvoice) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write XML header: %w", err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(invoice); err != nil {
+		return fmt.Errorf("failed to encode UBL invoice: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write UBL invoice: %w", err)
+	}
+	return nil
+}
+
+// buildSupplierParty maps the extracted company to the seller party
+func buildSupplierParty(info *interfaces.ReceiptInvoiceInfo, miss func(term, name, reason string)) ublParty {
+	company := strings.TrimSpace(document.StringValue(info.Company))
+
+	party := ublParty{}
+	if company != "" {
+		party.PartyName = &ublPartyName{Name: company}
+		party.PartyLegalEntity = &ublPartyLegalEntity{RegistrationName: company}
+	} else {
+		miss("BT-27", "Seller name", "company is missing")
+	}
+
+	miss("BT-34", "Seller electronic address", "not part of the extracted data")
+	miss("BT-40", "Seller country code", "the seller address is not part of the extracted data")
+
+	return party
+}
+
+// buildCustomerParty maps the configured company to the buyer party
+func buildCustomerParty(buyer config.CompanyConfig, miss func(term, name, reason string)) ublParty {
+	party := ublParty{}
+
+	if scheme, id, ok := strings.Cut(buyer.PeppolID, ":"); ok && scheme != "" && id != "" {
+		party.EndpointID = &ublID{SchemeID: scheme, Value: id}
+	} else {
+		miss("BT-49", "Buyer electronic address", "set company.peppol_id as <scheme>:<id> in the config")
+	}
+
+	if buyer.Name != "" {
+		party.PartyName = &ublPartyName{Name: buyer.Name}
+		party.PartyLegalEntity = &ublPartyLegalEntity{RegistrationName: buyer.Name, CompanyID: buyer.OrgNumber}
+	} else {
+		miss("BT-44", "Buyer name", "set company.name in the config")
+	}
+
+	country := buyer.Country
+	if country == "" {
+		country = "SE"
+	}
+	party.PostalAddress = ublAddress{
+		StreetName: buyer.Street,
+		CityName:   buyer.City,
+		PostalZone: buyer.PostalCode,
+		Country:    &ublCountry{IdentificationCode: country},
+	}
+
+	if buyer.VATNumber != "" {
+		party.PartyTaxScheme = &ublPartyTaxScheme{CompanyID: buyer.VATNumber, TaxScheme: ublTaxScheme{ID: "VAT"}}
+	}
+
+	return party
+}
+
+// documentTotal returns the total in cents of the document currency
+func documentTotal(info *interfaces.ReceiptInvoiceInfo, currency string) (int, bool) {
+	if info.OriginalAmount != nil {
+		return int(math.Round(*info.OriginalAmount * 100)), true
+	}
+	if currency == "SEK" && info.SECentAmount != nil {
+		return *info.SECentAmount, true
+	}
+	return 0, false
+}
+
+// documentVat returns the VAT in cents of the document currency
+func documentVat(info *interfaces.ReceiptInvoiceInfo) (int, bool) {
+	if info.OriginalVatAmount == nil {
+		return 0, false
+	}
+	return int(math.Round(*info.OriginalVatAmount * 100)), true
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// findIdField returns the value of the first ID field whose name matches pattern
+// The invoice a credit note or reminder refers to is never the number of the document itself.
+func findIdField(info *interfaces.ReceiptInvoiceInfo, pattern *regexp.Regexp) string {
+	for _, idField := range info.IdFields {
+		if idField.Name != interfaces.IdFieldReferencedInvoice && pattern.MatchString(idField.Name) && strings.TrimSpace(idField.Value) != "" {
+			return strings.TrimSpace(idField.Value)
+		}
+	}
+	return ""
+}
+
+// snapVatRate rounds a computed rate to a standard Swedish rate if it is within half a percent
+func snapVatRate(rate float64) float64 {
+	for _, standard := range standardVatRates {
+		if math.Abs(rate-standard) < 0.5 {
+			return standard
+		}
+	}
+	return math.Round(rate*100) / 100
+}
+
+// formatPercent formats a rate without trailing zeros
+func formatPercent(rate float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", rate), "0"), ".")
+}
+
+// amount formats cents as a UBL amount
+func amount(cents int, currency string) ublAmount {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return ublAmount{Currency: currency, Value: fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)}
+}
@@ -0,0 +1,213 @@
+package evidence
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/sourceview"
+)
+
+// IdFieldPrefix selects an ID field by name, e.g. "id:Invoice Number"
+const IdFieldPrefix = sourceview.IdFieldPrefix
+
+// Notes explaining a downgraded confidence
+const (
+	NoteNoEvidence        = "no evidence given"
+	NoteNoSnippet         = "no snippet given"
+	NoteSnippetNotFound   = "snippet not found in the source"
+	NoteValueNotInSnippet = "value not found in the snippet"
+)
+
+// Fields are the fields that need evidence, in the order evidence is kept, followed by the ID fields
+// document_type and description classify the document as a whole and have no single source.
+var Fields = []string{
+	"company",
+	"date_issued",
+	"period_start",
+	"period_end",
+	"service_description",
+	"original_amount",
+	"original_currency",
+	"original_vat_amount",
+	"se_cent_amount",
+}
+
+// markup is removed together with whitespace before snippets are compared, so a snippet
+// matches regardless of line breaks, table columns and emphasis in markdown sources
+const markup = "*_`|>#\\"
+
+// Verify checks the evidence of an extracted document against the source text
+// Evidence is kept for fields with a value only, one entry per field. Fields without evidence,
+// with a snippet that is not in the text, or whose value is not in the snippet are downgraded
+// and get a Note. Verified is set on evidence whose snippet was found.
+func Verify(info *interfaces.ReceiptInvoiceInfo, text string) {
+	source := compact(text)
+	needles := make(map[string]sourceview.Needle)
+	for _, needle := range sourceview.NeedlesFor(info) {
+		needles[needle.Field] = needle
+	}
+
+	given := make(map[string]interfaces.FieldEvidence)
+	for _, e := range info.Evidence {
+		field := canonicalField(info, e.Field)
+		if _, seen := given[field]; !seen {
+			e.Field = field
+			given[field] = e
+		}
+	}
+
+	var verified []interfaces.FieldEvidence
+	for _, field := range fieldsWithValue(info) {
+		e, ok := given[field]
+		if !ok {
+			verified = append(verified, interfaces.FieldEvidence{Field: field, Confidence: interfaces.ConfidenceLow, Note: NoteNoEvidence})
+			continue
+		}
+
+		e.Confidence = normalizeConfidence(e.Confidence)
+		e.Verified = false
+		e.Note = ""
+		snippet := compact(e.Snippet)
+		switch {
+		case snippet == "":
+			e.Confidence = interfaces.ConfidenceLow
+			e.Note = NoteNoSnippet
+		case !strings.Contains(source, snippet):
+			e.Confidence = interfaces.ConfidenceLow
+			e.Note = NoteSnippetNotFound
+		default:
+			e.Verified = true
+			if needle, ok := needles[field]; ok && len(sourceview.Highlight(e.Snippet, []sourceview.Needle{needle}).Found) == 0 {
+				e.Confidence = downgrade(e.Confidence)
+				e.Note = NoteValueNotInSnippet
+			}
+		}
+		verified = append(verified, e)
+	}
+	info.Evidence = verified
+}
+
+// Low returns the evidence with low confidence
+func Low(info *interfaces.ReceiptInvoiceInfo) []interfaces.FieldEvidence {
+	var low []interfaces.FieldEvidence
+	for _, e := range info.Evidence {
+		if e.Confidence == interfaces.ConfidenceLow {
+			low = append(low, e)
+		}
+	}
+	return low
+}
+
+// Find returns the evidence of a field, nil if there is none
+func Find(info *interfaces.ReceiptInvoiceInfo, field string) *interfaces.FieldEvidence {
+	for i := range info.Evidence {
+		if strings.EqualFold(info.Evidence[i].Field, field) {
+			return &info.Evidence[i]
+		}
+	}
+	return nil
+}
+
+// Value returns the value of a field as text, "" if it has none
+func Value(info *interfaces.ReceiptInvoiceInfo, field string) string {
+	if strings.HasPrefix(field, IdFieldPrefix) {
+		return document.IdFieldValue(info, strings.TrimPrefix(field, IdFieldPrefix))
+	}
+
+	formatFloat := func(f *float64) string {
+		if f == nil {
+			return ""
+		}
+		return strconv.FormatFloat(*f, 'f', 2, 64)
+	}
+	switch field {
+	case "company":
+		return strings.TrimSpace(document.StringValue(info.Company))
+	case "date_issued":
+		return document.DateString(info)
+	case "period_start":
+		return strings.TrimSpace(document.StringValue(info.PeriodStart))
+	case "period_end":
+		return strings.TrimSpace(document.StringValue(info.PeriodEnd))
+	case "service_description":
+		return strings.TrimSpace(document.StringValue(info.ServiceDescription))
+	case "original_amount":
+		return formatFloat(info.OriginalAmount)
+	case "original_currency":
+		return strings.TrimSpace(document.StringValue(info.OriginalCurrency))
+	case "original_vat_amount":
+		return formatFloat(info.OriginalVatAmount)
+	case "se_cent_amount":
+		if info.SECentAmount == nil {
+			return ""
+		}
+		return strconv.Itoa(*info.SECentAmount)
+	}
+	return ""
+}
+
+// fieldsWithValue returns the fields that need evidence and have a value
+func fieldsWithValue(info *interfaces.ReceiptInvoiceInfo) []string {
+	var fields []string
+	for _, field := range Fields {
+		if Value(info, field) != "" {
+			fields = append(fields, field)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, idField := range info.IdFields {
+		field := IdFieldPrefix + idField.Name
+		if strings.TrimSpace(idField.Value) != "" && !seen[strings.ToLower(field)] {
+			seen[strings.ToLower(field)] = true
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// canonicalField spells a field as in Fields, ID fields as the name of the ID field
+func canonicalField(info *interfaces.ReceiptInvoiceInfo, field string) string {
+	field = strings.TrimSpace(field)
+	if strings.HasPrefix(strings.ToLower(field), IdFieldPrefix) {
+		name := strings.TrimSpace(field[len(IdFieldPrefix):])
+		for _, idField := range info.IdFields {
+			if strings.EqualFold(strings.TrimSpace(idField.Name), name) {
+				return IdFieldPrefix + idField.Name
+			}
+		}
+		return IdFieldPrefix + name
+	}
+	return strings.ToLower(field)
+}
+
+// normalizeConfidence returns a known confidence level, unknown levels are low
+func normalizeConfidence(confidence string) string {
+	switch c := strings.ToLower(strings.TrimSpace(confidence)); c {
+	case interfaces.ConfidenceHigh, interfaces.ConfidenceMedium:
+		return c
+	}
+	return interfaces.ConfidenceLow
+}
+
+// downgrade lowers a confidence level by one step
+func downgrade(confidence string) string {
+	if confidence == interfaces.ConfidenceHigh {
+		return interfaces.ConfidenceMedium
+	}
+	return interfaces.ConfidenceLow
+}
+
+// compact lower-cases s and removes whitespace, control characters and markdown markup
+func compact(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsSpace(r) || unicode.IsControl(r) || strings.ContainsRune(markup, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
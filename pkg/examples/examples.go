@@ -0,0 +1,284 @@
+package examples
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// Defaults of Options
+const (
+	DefaultMaxExamples   = 3
+	DefaultTokenBudget   = 2000
+	DefaultMinSimilarity = 0.2
+)
+
+// charsPerToken is the rough number of characters per token used to estimate prompt sizes
+const charsPerToken = 4
+
+// minVendorLength keeps very short vendor names from matching inside unrelated words
+const minVendorLength = 3
+
+// Example is a document excerpt with its corrected extraction
+type Example struct {
+	// Name identifies the example, it is the file name without extension
+	Name string `json:"-"`
+
+	// Vendor is the company the example is for, matched against the document text (default Expected.Company)
+	Vendor string `json:"vendor,omitempty"`
+
+	// Aliases are other spellings of the vendor found in documents
+	Aliases []string `json:"aliases,omitempty"`
+
+	// Note tells why the example was added, it is not sent to the provider
+	Note string `json:"note,omitempty"`
+
+	// Text is the excerpt of the document text
+	Text string `json:"text"`
+
+	// Expected is the correct extraction of Text
+	Expected interfaces.ReceiptInvoiceInfo `json:"expected"`
+}
+
+// Options control the selection of examples
+type Options struct {
+	// MaxExamples is the maximum number of examples per prompt, nil means DefaultMaxExamples and
+	// 0 selects none
+	MaxExamples *int
+
+	// TokenBudget is the estimated number of tokens all selected examples may use (default 2000)
+	TokenBudget int
+
+	// MinSimilarity is the text similarity (0-1) an example needs if its vendor is not in the
+	// document, nil means DefaultMinSimilarity and 0 accepts any example
+	MinSimilarity *float64
+}
+
+// Selected is an example chosen for a document
+type Selected struct {
+	Example *Example
+
+	// VendorMatch is true if the vendor or an alias of the example is in the document
+	VendorMatch bool
+
+	// Similarity is the cosine similarity of the words of the document and the example (0-1)
+	Similarity float64
+
+	// Tokens is the estimated size of the example in the prompt
+	Tokens int
+}
+
+// Store holds the curated examples
+type Store struct {
+	examples []*Example
+	opts     Options
+}
+
+// Load reads all *.json examples of a directory
+func Load(dir string, opts Options) (*Store, error) {
+	if opts.MaxExamples == nil {
+		maxExamples := DefaultMaxExamples
+		opts.MaxExamples = &maxExamples
+	}
+	if opts.TokenBudget == 0 {
+		opts.TokenBudget = DefaultTokenBudget
+	}
+	if opts.MinSimilarity == nil {
+		minSimilarity := DefaultMinSimilarity
+		opts.MinSimilarity = &minSimilarity
+	}
+	if *opts.MaxExamples < 0 || opts.TokenBudget < 0 || *opts.MinSimilarity < 0 || *opts.MinSimilarity > 1 {
+		return nil, fmt.Errorf("invalid example options: max_examples and token_budget must not be negative, min_similarity must be between 0 and 1")
+	}
+
+	if _, err := os.Stat(dir); err != nil {
+		return nil, fmt.Errorf("failed to read examples directory: %w", err)
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list examples in %s: %w", dir, err)
+	}
+	sort.Strings(files)
+
+	store := &Store{opts: opts}
+	for _, file := range files {
+		example, err := LoadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		store.examples = append(store.examples, example)
+	}
+	return store, nil
+}
+
+// LoadFile reads one example
+func LoadFile(path string) (*Example, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read example %s: %w", path, err)
+	}
+	var example Example
+	if err := json.Unmarshal(content, &example); err != nil {
+		return nil, fmt.Errorf("failed to parse example %s: %w", path, err)
+	}
+	if strings.TrimSpace(example.Text) == "" {
+		return nil, fmt.Errorf("example %s has no text", path)
+	}
+	example.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if example.Vendor == "" {
+		example.Vendor = strings.TrimSpace(document.StringValue(example.Expected.Company))
+	}
+	return &example, nil
+}
+
+// Save writes an example as <dir>/<name>.json and returns the path
+// An existing example is only overwritten with force.
+func Save(dir string, example *Example, force bool) (string, error) {
+	if example.Name == "" {
+		return "", fmt.Errorf("example has no name")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create examples directory: %w", err)
+	}
+	path := filepath.Join(dir, example.Name+".json")
+	if _, err := os.Stat(path); err == nil && !force {
+		return "", fmt.Errorf("example %s already exists", path)
+	}
+
+	content, err := json.MarshalIndent(example, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal example: %w", err)
+	}
+	if err := os.WriteFile(path, append(content, '\n'), 0644); err != nil {
+		return "", fmt.Errorf("failed to write example %s: %w", path, err)
+	}
+	return path, nil
+}
+
+// Examples returns all examples in name order
+func (s *Store) Examples() []*Example {
+	return s.examples
+}
+
+// Select returns the most relevant examples for a document within the token budget
+// Examples whose vendor is in the document come first, then examples by text similarity.
+// Examples that do not fit in the remaining budget are skipped in favour of smaller ones.
+func (s *Store) Select(text string) []Selected {
+	words := wordCounts(text)
+	compactText := alphanumeric(text)
+
+	var candidates []Selected
+	for _, example := range s.examples {
+		candidate := Selected{
+			Example:     example,
+			VendorMatch: vendorIn(example, compactText),
+			Similarity:  cosine(words, wordCounts(example.Text)),
+			Tokens:      Tokens(example),
+		}
+		if candidate.VendorMatch || candidate.Similarity >= *s.opts.MinSimilarity {
+			candidates = append(candidates, candidate)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].VendorMatch != candidates[j].VendorMatch {
+			return candidates[i].VendorMatch
+		}
+		return candidates[i].Similarity > candidates[j].Similarity
+	})
+
+	var selected []Selected
+	budget := s.opts.TokenBudget
+	for _, candidate := range candidates {
+		if len(selected) == *s.opts.MaxExamples {
+			break
+		}
+		if candidate.Tokens > budget {
+			continue
+		}
+		budget -= candidate.Tokens
+		selected = append(selected, candidate)
+	}
+	return selected
+}
+
+// Answer returns the expected extraction as the JSON answer of the provider
+// Fields added by post-processing are left out, the provider never produces them.
+func Answer(example *Example) ([]byte, error) {
+	answer := example.Expected
+	answer.CrossChecks = nil
+	answer.Verification = nil
+	answer.Prompt = nil
+	answer.Ensemble = nil
+	answer.Vendor = nil
+	answer.SuggestedFileName = ""
+	answer.SourceFile = ""
+	answer.Source = ""
+	answer.Evidence = append([]interfaces.FieldEvidence(nil), answer.Evidence...)
+	for i := range answer.Evidence {
+		answer.Evidence[i].Verified = false
+		answer.Evidence[i].Note = ""
+	}
+	return json.Marshal(answer)
+}
+
+// Tokens estimates the number of prompt tokens of an example, its text and answer
+func Tokens(example *Example) int {
+	answer, _ := Answer(example)
+	return int(math.Ceil(float64(len(example.Text)+len(answer)) / charsPerToken))
+}
+
+// vendorIn reports whether the vendor or an alias of the example is in the compacted text
+func vendorIn(example *Example, compactText string) bool {
+	for _, name := range append([]string{example.Vendor}, example.Aliases...) {
+		if name := alphanumeric(name); len(name) >= minVendorLength && strings.Contains(compactText, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// wordCounts counts the lower case words of at least three letters
+func wordCounts(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if len([]rune(word)) >= 3 {
+			counts[word]++
+		}
+	}
+	return counts
+}
+
+// cosine returns the cosine similarity of two word counts
+func cosine(a, b map[string]int) float64 {
+	var dot, normA, normB float64
+	for word, n := range a {
+		normA += float64(n * n)
+		dot += float64(n * b[word])
+	}
+	for _, n := range b {
+		normB += float64(n * n)
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// alphanumeric returns the lower case letters and digits of s
+func alphanumeric(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
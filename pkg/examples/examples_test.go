@@ -0,0 +1,134 @@
+package examples
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+func str(s string) *string { return &s }
+
+func integer(n int) *int { return &n }
+
+func float(f float64) *float64 { return &f }
+
+// store returns a store with examples of a foreign receipt, a Swedish invoice and a large Swedish invoice
+func store(opts Options) *Store {
+	return &Store{opts: opts, examples: []*Example{
+		{Name: "anthropic", Vendor: "Anthropic", Aliases: []string{"Anthropic Ireland Limited"}, Text: "Receipt from Anthropic\nClaude Pro subscription\nAmount paid $20.00"},
+		{Name: "fortnox", Vendor: "Fortnox", Text: "Fortnox AB\nFaktura\nAtt betala 1 250,00 SEK\nMoms 250,00 SEK"},
+		{Name: "telia", Vendor: "Telia", Text: "Telia Sverige AB\nFaktura\nAtt betala 499,00 SEK\nMoms 99,80 SEK\n" + strings.Repeat("Samtal ", 200)},
+	}}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		opts Options
+		want []string
+	}{
+		{"vendor first", "Faktura från Anthropic Ireland Ltd\nAtt betala 10,00 EUR", Options{MaxExamples: integer(3), TokenBudget: 2000, MinSimilarity: float(0.2)}, []string{"anthropic", "fortnox"}},
+		{"alias", "ANTHROPIC IRELAND LIMITED\nInvoice", Options{MaxExamples: integer(3), TokenBudget: 2000, MinSimilarity: float(0.2)}, []string{"anthropic"}},
+		{"similarity threshold", "Elektronikbutik\nFaktura\nAtt betala 99,00 SEK", Options{MaxExamples: integer(3), TokenBudget: 2000, MinSimilarity: float(0.5)}, []string{"fortnox"}},
+		{"max examples", "Fortnox AB\nFaktura\nAtt betala 99,00 SEK", Options{MaxExamples: integer(1), TokenBudget: 2000, MinSimilarity: float(0)}, []string{"fortnox"}},
+		{"no examples", "Fortnox AB\nFaktura", Options{MaxExamples: integer(0), TokenBudget: 2000, MinSimilarity: float(0)}, nil},
+		{"larger example skipped for the budget", "Telia Sverige AB\nFaktura", Options{MaxExamples: integer(3), TokenBudget: 200, MinSimilarity: float(0)}, []string{"fortnox", "anthropic"}},
+		{"unrelated document", "Receipt\nSubscription", Options{MaxExamples: integer(3), TokenBudget: 2000, MinSimilarity: float(0.9)}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, selected := range store(tt.opts).Select(tt.text) {
+				got = append(got, selected.Example.Name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selected %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnswer(t *testing.T) {
+	example := &Example{Text: "Receipt", Expected: interfaces.ReceiptInvoiceInfo{
+		DocumentType:      interfaces.DocumentTypeReceipt,
+		Company:           str("Anthropic"),
+		SuggestedFileName: "2025-08-02-anthropic",
+		SourceFile:        "receipt.pdf",
+		Vendor:            &interfaces.VendorMatch{Name: "Anthropic"},
+		Evidence:          []interfaces.FieldEvidence{{Field: "company", Snippet: "Anthropic", Verified: true, Note: "found"}},
+	}}
+
+	content, err := Answer(example)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var answer interfaces.ReceiptInvoiceInfo
+	if err := json.Unmarshal(content, &answer); err != nil {
+		t.Fatal(err)
+	}
+	if answer.SuggestedFileName != "" || answer.SourceFile != "" || answer.Vendor != nil {
+		t.Errorf("post-processing fields were not removed: %s", content)
+	}
+	if want := []interfaces.FieldEvidence{{Field: "company", Snippet: "Anthropic"}}; !reflect.DeepEqual(answer.Evidence, want) {
+		t.Errorf("evidence = %+v, want %+v", answer.Evidence, want)
+	}
+	if !example.Expected.Evidence[0].Verified {
+		t.Error("Answer changed the evidence of the example")
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "examples")
+	example := &Example{Name: "anthropic", Text: "Receipt from Anthropic", Expected: interfaces.ReceiptInvoiceInfo{Company: str(" Anthropic ")}}
+	if _, err := Save(dir, example, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Save(dir, example, false); err == nil {
+		t.Error("expected an error for an existing example")
+	}
+	if _, err := Save(dir, example, true); err != nil {
+		t.Errorf("Save with force = %v", err)
+	}
+
+	loaded, err := Load(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Examples()) != 1 || loaded.Examples()[0].Name != "anthropic" || loaded.Examples()[0].Vendor != "Anthropic" {
+		t.Errorf("loaded %+v, want the example with vendor Anthropic", loaded.Examples())
+	}
+	if *loaded.opts.MaxExamples != DefaultMaxExamples || loaded.opts.TokenBudget != DefaultTokenBudget || *loaded.opts.MinSimilarity != DefaultMinSimilarity {
+		t.Errorf("options = %+v, want the defaults", loaded.opts)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "empty.json"), []byte(`{"text": " "}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(dir, Options{}); err == nil {
+		t.Error("expected an error for an example without text")
+	}
+}
+
+func TestLoadRejectsInvalidOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"negative max examples", Options{MaxExamples: integer(-1)}},
+		{"negative budget", Options{TokenBudget: -1}},
+		{"similarity above one", Options{MinSimilarity: float(1.5)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Load(t.TempDir(), tt.opts); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
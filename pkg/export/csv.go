@@ -0,0 +1,219 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/extra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/locale"
+)
+
+// IdColumnPrefix selects a single ID field by name, e.g. "id:Invoice Number"
+const IdColumnPrefix = "id:"
+
+// ExtraColumnPrefix selects a user-defined field by name, e.g. "extra:project_code"
+const ExtraColumnPrefix = extra.Prefix
+
+// DefaultCSVColumns is the column set used when none is configured
+var DefaultCSVColumns = []string{
+	"date_issued",
+	"document_type",
+	"company",
+	"description",
+	"service_description",
+	"sek_amount",
+	"original_amount",
+	"original_currency",
+	"original_vat_amount",
+	"id_fields",
+	"suggested_filename",
+	"file",
+}
+
+// CSVOptions controls how documents are written as CSV
+type CSVOptions struct {
+	// Columns is the ordered list of columns, see CSVColumnNames for the available names
+	Columns []string
+
+	// Locale controls the decimal separator
+	Locale locale.Locale
+
+	// Delimiter is the field delimiter, 0 means the locale's list separator
+	Delimiter rune
+
+	// BOM writes a UTF-8 byte order mark before the header
+	BOM bool
+}
+
+// csvColumn renders one column value for a document
+type csvColumn func(doc document.Document, loc locale.Locale) string
+
+// csvColumns holds all columns that can be selected by name
+var csvColumns = map[string]csvColumn{
+	"file": func(doc document.Document, loc locale.Locale) string {
+		return doc.Path
+	},
+	"document_type": func(doc document.Document, loc locale.Locale) string {
+		return doc.Info.DocumentType
+	},
+	"description": func(doc document.Document, loc locale.Locale) string {
+		return doc.Info.Description
+	},
+	"company": func(doc document.Document, loc locale.Locale) string {
+		return document.StringValue(doc.Info.Company)
+	},
+	"date_issued": func(doc document.Document, loc locale.Locale) string {
+		return document.DateString(doc.Info)
+	},
+	"period_start": func(doc document.Document, loc locale.Locale) string {
+		return document.StringValue(doc.Info.PeriodStart)
+	},
+	"period_end": func(doc document.Document, loc locale.Locale) string {
+		return document.StringValue(doc.Info.PeriodEnd)
+	},
+	"recurring": func(doc document.Document, loc locale.Locale) string {
+		if doc.Info.Recurring == nil {
+			return ""
+		}
+		return strconv.FormatBool(*doc.Info.Recurring)
+	},
+	"billing_interval": func(doc document.Document, loc locale.Locale) string {
+		return document.BillingInterval(doc.Info)
+	},
+	"service_description": func(doc document.Document, loc locale.Locale) string {
+		return document.StringValue(doc.Info.ServiceDescription)
+	},
+	"se_cent_amount": func(doc document.Document, loc locale.Locale) string {
+		if doc.Info.SECentAmount == nil {
+			return ""
+		}
+		return strconv.Itoa(*doc.Info.SECentAmount)
+	},
+	"sek_amount": func(doc document.Document, loc locale.Locale) string {
+		if doc.Info.SECentAmount == nil {
+			return ""
+		}
+		return loc.FormatCents(*doc.Info.SECentAmount, false)
+	},
+	"original_amount": func(doc document.Document, loc locale.Locale) string {
+		return formatOptionalFloat(doc.Info.OriginalAmount, loc)
+	},
+	"original_currency": func(doc document.Document, loc locale.Locale) string {
+		return document.StringValue(doc.Info.OriginalCurrency)
+	},
+	"original_vat_amount": func(doc document.Document, loc locale.Locale) string {
+		return formatOptionalFloat(doc.Info.OriginalVatAmount, loc)
+	},
+	"id_fields": func(doc document.Document, loc locale.Locale) string {
+		return FlattenIdFields(doc)
+	},
+	"suggested_filename": func(doc document.Document, loc locale.Locale) string {
+		return doc.Info.SuggestedFileName
+	},
+}
+
+// CSVColumnNames returns the sorted names of all selectable columns
+func CSVColumnNames() []string {
+	names := make([]string, 0, len(csvColumns))
+	for name := range csvColumns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteCSV writes one header row and one row per document
+func WriteCSV(w io.Writer, docs []document.Document, opts CSVOptions) error {
+	columns := opts.Columns
+	if len(columns) == 0 {
+		columns = DefaultCSVColumns
+	}
+
+	renderers := make([]csvColumn, len(columns))
+	for i, name := range columns {
+		renderer, err := lookupCSVColumn(name)
+		if err != nil {
+			return err
+		}
+		renderers[i] = renderer
+	}
+
+	if opts.BOM {
+		if _, err := io.WriteString(w, "\ufeff"); err != nil {
+			return fmt.Errorf("failed to write BOM: %w", err)
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Comma = opts.Delimiter
+	if writer.Comma == 0 {
+		writer.Comma = opts.Locale.ListSeparator
+	}
+	if writer.Comma == 0 {
+		writer.Comma = ','
+	}
+	// Excel on Windows expects CRLF line endings
+	writer.UseCRLF = true
+
+	if err := writer.Write(columns); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, doc := range docs {
+		row := make([]string, len(renderers))
+		for i, render := range renderers {
+			row[i] = render(doc, opts.Locale)
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row for %s: %w", doc.Path, err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// FlattenIdFields renders all ID fields as "Name=Value" pairs separated by "; "
+func FlattenIdFields(doc document.Document) string {
+	parts := make([]string, 0, len(doc.Info.IdFields))
+	for _, idField := range doc.Info.IdFields {
+		parts = append(parts, fmt.Sprintf("%s=%s", idField.Name, idField.Value))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// lookupCSVColumn resolves a column name, including "id:<name>" and "extra:<name>" columns
+func lookupCSVColumn(name string) (csvColumn, error) {
+	if strings.HasPrefix(name, IdColumnPrefix) {
+		idName := strings.TrimPrefix(name, IdColumnPrefix)
+		return func(doc document.Document, loc locale.Locale) string {
+			return document.IdFieldValue(doc.Info, idName)
+		}, nil
+	}
+	if strings.HasPrefix(name, ExtraColumnPrefix) {
+		extraName := strings.TrimPrefix(name, ExtraColumnPrefix)
+		return func(doc document.Document, loc locale.Locale) string {
+			return extra.Value(doc.Info, extraName)
+		}, nil
+	}
+
+	renderer, ok := csvColumns[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown CSV column: %s (available: %s, %s<name> or %s<name>)",
+			name, strings.Join(CSVColumnNames(), ", "), IdColumnPrefix, ExtraColumnPrefix)
+	}
+	return renderer, nil
+}
+
+// formatOptionalFloat formats an optional amount with two decimals, "" for nil
+func formatOptionalFloat(f *float64, loc locale.Locale) string {
+	if f == nil {
+		return ""
+	}
+	return loc.FormatDecimal(*f, 2, false)
+}
@@ -0,0 +1,157 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/accounting"
+)
+
+// LedgerOptions holds settings shared by the plain-text accounting writers
+type LedgerOptions struct {
+	// BaseCurrency is the operating currency (beancount option operating_currency)
+	BaseCurrency string
+
+	// OpenAccounts writes open directives (beancount) or account declarations (hledger)
+	OpenAccounts bool
+
+	// Header is written as a comment at the top of the file
+	Header string
+}
+
+// WriteBeancount writes transactions in beancount syntax
+func WriteBeancount(w io.Writer, transactions []accounting.LedgerTransaction, opts LedgerOptions) error {
+	var b strings.Builder
+
+	writeLedgerHeader(&b, opts.Header)
+	if opts.BaseCurrency != "" {
+		fmt.Fprintf(&b, "option \"operating_currency\" %s\n\n", beancountString(opts.BaseCurrency))
+	}
+
+	if opts.OpenAccounts && len(transactions) > 0 {
+		openDate := transactions[0].Date
+		for _, transaction := range transactions {
+			if transaction.Date.Before(openDate) {
+				openDate = transaction.Date
+			}
+		}
+		for _, account := range ledgerAccounts(transactions) {
+			fmt.Fprintf(&b, "%s open %s\n", openDate.Format("2006-01-02"), account)
+		}
+		b.WriteString("\n")
+	}
+
+	for _, transaction := range transactions {
+		fmt.Fprintf(&b, "%s * %s %s\n", transaction.Date.Format("2006-01-02"),
+			beancountString(transaction.Payee), beancountString(transaction.Narration))
+		for _, meta := range transaction.Meta {
+			fmt.Fprintf(&b, "  %s: %s\n", meta.Key, beancountString(meta.Value))
+		}
+		for _, posting := range transaction.Postings {
+			fmt.Fprintf(&b, "  %-40s %s\n", posting.Account, ledgerPostingAmount(posting))
+		}
+		b.WriteString("\n")
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write beancount file: %w", err)
+	}
+	return nil
+}
+
+// WriteHledger writes transactions in hledger journal syntax
+// Payee and narration are separated with "|", metadata is written as tags.
+func WriteHledger(w io.Writer, transactions []accounting.LedgerTransaction, opts LedgerOptions) error {
+	var b strings.Builder
+
+	writeLedgerHeader(&b, opts.Header)
+
+	if opts.OpenAccounts && len(transactions) > 0 {
+		for _, account := range ledgerAccounts(transactions) {
+			fmt.Fprintf(&b, "account %s\n", account)
+		}
+		b.WriteString("\n")
+	}
+
+	for _, transaction := range transactions {
+		description := hledgerText(transaction.Payee)
+		if transaction.Narration != "" {
+			description += " | " + hledgerText(transaction.Narration)
+		}
+		fmt.Fprintf(&b, "%s * %s\n", transaction.Date.Format("2006-01-02"), description)
+		for _, meta := range transaction.Meta {
+			// Tag values end at a comma, so commas are replaced to keep the value intact
+			fmt.Fprintf(&b, "    ; %s: %s\n", meta.Key, strings.ReplaceAll(strings.Join(strings.Fields(meta.Value), " "), ",", ";"))
+		}
+		for _, posting := range transaction.Postings {
+			fmt.Fprintf(&b, "    %-40s  %s\n", posting.Account, ledgerPostingAmount(posting))
+		}
+		b.WriteString("\n")
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write hledger journal: %w", err)
+	}
+	return nil
+}
+
+// writeLedgerHeader writes each header line as a ";" comment
+func writeLedgerHeader(b *strings.Builder, header string) {
+	if header == "" {
+		return
+	}
+	for _, line := range strings.Split(header, "\n") {
+		fmt.Fprintf(b, "; %s\n", line)
+	}
+	b.WriteString("\n")
+}
+
+// ledgerAccounts returns the sorted accounts used by the transactions
+func ledgerAccounts(transactions []accounting.LedgerTransaction) []string {
+	seen := make(map[string]bool)
+	var accounts []string
+	for _, transaction := range transactions {
+		for _, posting := range transaction.Postings {
+			if !seen[posting.Account] {
+				seen[posting.Account] = true
+				accounts = append(accounts, posting.Account)
+			}
+		}
+	}
+	sort.Strings(accounts)
+	return accounts
+}
+
+// ledgerPostingAmount formats "95.37 EUR" or "76.30 EUR @@ 877.46 SEK"
+func ledgerPostingAmount(posting accounting.LedgerPosting) string {
+	amount := ledgerAmount(posting.Amount)
+	if posting.Cost != nil {
+		// The total price is always positive, the sign is carried by the amount
+		cost := *posting.Cost
+		if cost.Cents < 0 {
+			cost.Cents = -cost.Cents
+		}
+		amount += " @@ " + ledgerAmount(cost)
+	}
+	return amount
+}
+
+// ledgerAmount formats an amount with a dot decimal separator
+func ledgerAmount(amount accounting.LedgerAmount) string {
+	return decimalCents(amount.Cents) + " " + amount.Currency
+}
+
+// beancountString quotes a string for beancount
+func beancountString(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.Join(strings.Fields(s), " ") + `"`
+}
+
+// hledgerText collapses whitespace and removes characters with special meaning in descriptions
+func hledgerText(s string) string {
+	s = strings.NewReplacer("|", "/", ";", ",").Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,242 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/extra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// Number format codes used in the workbook
+const (
+	xlsxDateFormat   = "yyyy-mm-dd"
+	xlsxAmountFormat = "#,##0.00"
+	xlsxSEKFormat    = `#,##0.00\ "SEK"`
+)
+
+// UndatedSheetName is the month sheet for documents without a usable issue date
+const UndatedSheetName = "Undated"
+
+// extraColumnWidth is the width of the columns of extra fields
+const extraColumnWidth = 18
+
+// xlsxStyles holds the cell style indexes used by the document sheets
+type xlsxStyles struct {
+	header   int
+	date     int
+	sek      int
+	sekBold  int
+	amount   int
+	integer  int
+	currency map[string]int
+}
+
+// documentColumns are the headers and widths of the document sheets
+var documentColumns = []struct {
+	header string
+	width  float64
+}{
+	{"Date", 12},
+	{"Type", 10},
+	{"Company", 28},
+	{"Description", 24},
+	{"Service", 36},
+	{"Amount (SEK)", 16},
+	{"VAT (SEK)", 14},
+	{"Original Amount", 16},
+	{"Currency", 10},
+	{"Original VAT", 14},
+	{"IDs", 48},
+	{"File", 48},
+}
+
+// WriteXLSX writes an Excel workbook with a sheet of all documents, one sheet per
+// issue month and a summary sheet with totals by category and by company
+func WriteXLSX(w io.Writer, docs []document.Document) error {
+	wb := newXLSXWorkbook()
+	styles := &xlsxStyles{
+		header:   wb.style("", true),
+		date:     wb.style(xlsxDateFormat, false),
+		sek:      wb.style(xlsxSEKFormat, false),
+		sekBold:  wb.style(xlsxSEKFormat, true),
+		amount:   wb.style(xlsxAmountFormat, false),
+		integer:  wb.style(fmt.Sprint(xlsxFmtInteger), false),
+		currency: make(map[string]int),
+	}
+
+	extraNames := extraFieldNames(docs)
+	summary := wb.addSheet("Summary")
+	writeDocumentSheet(wb, wb.addSheet("Documents"), docs, extraNames, styles)
+
+	for _, month := range document.GroupBy(docs, document.MonthKey, UndatedSheetName) {
+		writeDocumentSheet(wb, wb.addSheet(month.Key), month.Docs, extraNames, styles)
+	}
+
+	writeSummarySheet(summary, docs, styles)
+
+	if err := wb.write(w); err != nil {
+		return fmt.Errorf("failed to write workbook: %w", err)
+	}
+	return nil
+}
+
+// writeDocumentSheet writes one row per document, with a column per extra field at the end
+func writeDocumentSheet(wb *xlsxWorkbook, sheet *xlsxSheet, docs []document.Document, extraNames []string, styles *xlsxStyles) {
+	sheet.freezeHeader = true
+	sheet.autoFilter = true
+
+	header := make([]xlsxCell, len(documentColumns))
+	for i, column := range documentColumns {
+		header[i] = stringCell(column.header, styles.header)
+		sheet.widths = append(sheet.widths, column.width)
+	}
+	for _, name := range extraNames {
+		header = append(header, stringCell(name, styles.header))
+		sheet.widths = append(sheet.widths, extraColumnWidth)
+	}
+	sheet.addRow(header...)
+
+	for _, doc := range docs {
+		info := doc.Info
+		row := []xlsxCell{
+			dateOrTextCell(info, styles),
+			stringCell(info.DocumentType, 0),
+			stringCell(document.StringValue(info.Company), 0),
+			stringCell(info.Description, 0),
+			stringCell(document.StringValue(info.ServiceDescription), 0),
+			optionalCentsCell(info.SECentAmount, styles.sek),
+			vatCentsCell(info, styles),
+			optionalFloatCell(info.OriginalAmount, currencyStyle(wb, styles, info.OriginalCurrency)),
+			stringCell(document.StringValue(info.OriginalCurrency), 0),
+			optionalFloatCell(info.OriginalVatAmount, currencyStyle(wb, styles, info.OriginalCurrency)),
+			stringCell(FlattenIdFields(doc), 0),
+			stringCell(doc.Path, 0),
+		}
+		for _, name := range extraNames {
+			row = append(row, extraCell(info, name))
+		}
+		sheet.addRow(row...)
+	}
+}
+
+// extraFieldNames returns the sorted names of the extra fields of all documents
+func extraFieldNames(docs []document.Document) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, doc := range docs {
+		for _, name := range extra.Names(doc.Info) {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// extraCell writes numbers of extra fields as numbers and everything else as text
+func extraCell(info *interfaces.ReceiptInvoiceInfo, name string) xlsxCell {
+	if value, ok := info.Extra[name].(float64); ok {
+		return numberCell(value, 0)
+	}
+	return stringCell(extra.Value(info, name), 0)
+}
+
+// writeSummarySheet writes totals by category (Description) and by company
+func writeSummarySheet(sheet *xlsxSheet, docs []document.Document, styles *xlsxStyles) {
+	sheet.widths = []float64{36, 12, 18, 16}
+
+	writeGroups := func(title string, groups []document.Group) {
+		if len(sheet.rows) > 0 {
+			sheet.addRow(emptyCell())
+		}
+		sheet.addRow(stringCell(title, styles.header), stringCell("Documents", styles.header),
+			stringCell("Amount (SEK)", styles.header), stringCell("VAT (SEK)", styles.header))
+
+		count, sek, vat := 0, 0, 0
+		for _, group := range groups {
+			sheet.addRow(
+				stringCell(group.Key, 0),
+				numberCell(float64(len(group.Docs)), styles.integer),
+				numberCell(float64(group.SEKCents)/100, styles.sek),
+				numberCell(float64(group.VatCents)/100, styles.sek),
+			)
+			count += len(group.Docs)
+			sek += group.SEKCents
+			vat += group.VatCents
+		}
+
+		sheet.addRow(
+			stringCell("Total", styles.header),
+			numberCell(float64(count), styles.integer),
+			numberCell(float64(sek)/100, styles.sekBold),
+			numberCell(float64(vat)/100, styles.sekBold),
+		)
+	}
+
+	writeGroups("Category", document.GroupBy(docs, func(info *interfaces.ReceiptInvoiceInfo) string {
+		return strings.TrimSpace(info.Description)
+	}, "(none)"))
+
+	writeGroups("Company", document.GroupBy(docs, func(info *interfaces.ReceiptInvoiceInfo) string {
+		return strings.TrimSpace(document.StringValue(info.Company))
+	}, "(unknown)"))
+}
+
+// currencyStyle returns an amount style showing the currency code, creating it on first use
+func currencyStyle(wb *xlsxWorkbook, styles *xlsxStyles, currency *string) int {
+	code := strings.ToUpper(strings.TrimSpace(document.StringValue(currency)))
+	if code == "" {
+		return styles.amount
+	}
+	if style, ok := styles.currency[code]; ok {
+		return style
+	}
+
+	// Only plain letter codes are safe inside a quoted number format
+	safe := strings.IndexFunc(code, func(r rune) bool { return r < 'A' || r > 'Z' }) < 0
+	style := styles.amount
+	if safe {
+		style = wb.style(fmt.Sprintf(`#,##0.00\ "%s"`, code), false)
+	}
+	styles.currency[code] = style
+	return style
+}
+
+// dateOrTextCell writes a parseable date as a date cell, anything else as text
+func dateOrTextCell(info *interfaces.ReceiptInvoiceInfo, styles *xlsxStyles) xlsxCell {
+	if t, ok := document.ParseDate(info); ok {
+		return dateCell(t, styles.date)
+	}
+	return stringCell(document.DateString(info), 0)
+}
+
+// optionalCentsCell writes an öre amount as kronor
+func optionalCentsCell(cents *int, style int) xlsxCell {
+	if cents == nil {
+		return emptyCell()
+	}
+	return numberCell(float64(*cents)/100, style)
+}
+
+// vatCentsCell writes the VAT converted to SEK, empty if it cannot be determined
+func vatCentsCell(info *interfaces.ReceiptInvoiceInfo, styles *xlsxStyles) xlsxCell {
+	vat, ok := document.VatCents(info)
+	if !ok {
+		return emptyCell()
+	}
+	return numberCell(float64(vat)/100, styles.sek)
+}
+
+// optionalFloatCell writes an optional amount
+func optionalFloatCell(f *float64, style int) xlsxCell {
+	if f == nil {
+		return emptyCell()
+	}
+	return numberCell(*f, style)
+}
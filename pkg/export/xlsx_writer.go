@@ -0,0 +1,390 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// This file contains a minimal Office Open XML (SpreadsheetML) writer.
+// It supports what the exporters need: inline strings, numbers, dates,
+// number formats, bold headers, column widths, a frozen header row and autofilters.
+
+// xlsxCellKind is the type of value stored in a cell
+type xlsxCellKind int
+
+const (
+	xlsxEmpty xlsxCellKind = iota
+	xlsxString
+	xlsxNumber
+)
+
+// xlsxCell is a single worksheet cell
+type xlsxCell struct {
+	kind  xlsxCellKind
+	str   string
+	num   float64
+	style int
+}
+
+// xlsxSheet is a worksheet with rows of cells
+type xlsxSheet struct {
+	name         string
+	rows         [][]xlsxCell
+	widths       []float64
+	freezeHeader bool
+	autoFilter   bool
+}
+
+// xlsxWorkbook collects sheets and cell styles and writes them as an .xlsx file
+type xlsxWorkbook struct {
+	sheets  []*xlsxSheet
+	numFmts []string
+	xfs     []xlsxXf
+	names   map[string]bool
+}
+
+// xlsxXf is a cell format: a number format and whether the font is bold
+type xlsxXf struct {
+	numFmtID int
+	bold     bool
+}
+
+// Built-in number format IDs from the OOXML specification
+const (
+	xlsxFmtGeneral = 0
+	xlsxFmtInteger = 1
+)
+
+// customNumFmtBase is the first ID available for custom number formats
+const customNumFmtBase = 164
+
+// excelEpoch is day zero of the 1900 date system as used by Excel (with the 1900 leap year bug)
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// newXLSXWorkbook creates an empty workbook with the default cell style
+func newXLSXWorkbook() *xlsxWorkbook {
+	return &xlsxWorkbook{
+		xfs:   []xlsxXf{{numFmtID: xlsxFmtGeneral}},
+		names: make(map[string]bool),
+	}
+}
+
+// style returns the index of a cell style with the given number format, creating it if needed
+// numFmt is either a built-in format ID as a decimal string or a custom format code.
+func (wb *xlsxWorkbook) style(numFmt string, bold bool) int {
+	numFmtID := xlsxFmtGeneral
+	if id, err := strconv.Atoi(numFmt); err == nil {
+		numFmtID = id
+	} else if numFmt != "" {
+		numFmtID = -1
+		for i, existing := range wb.numFmts {
+			if existing == numFmt {
+				numFmtID = customNumFmtBase + i
+			}
+		}
+		if numFmtID < 0 {
+			numFmtID = customNumFmtBase + len(wb.numFmts)
+			wb.numFmts = append(wb.numFmts, numFmt)
+		}
+	}
+
+	xf := xlsxXf{numFmtID: numFmtID, bold: bold}
+	for i, existing := range wb.xfs {
+		if existing == xf {
+			return i
+		}
+	}
+	wb.xfs = append(wb.xfs, xf)
+	return len(wb.xfs) - 1
+}
+
+// addSheet adds a worksheet, the name is sanitised and made unique as Excel requires
+func (wb *xlsxWorkbook) addSheet(name string) *xlsxSheet {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		name = "Sheet"
+	}
+
+	base := []rune(name)
+	if len(base) > 31 {
+		base = base[:31]
+	}
+	unique := string(base)
+	for n := 2; wb.names[strings.ToLower(unique)]; n++ {
+		suffix := fmt.Sprintf(" (%d)", n)
+		trimmed := base
+		if len(trimmed)+len(suffix) > 31 {
+			trimmed = trimmed[:31-len(suffix)]
+		}
+		unique = string(trimmed) + suffix
+	}
+	wb.names[strings.ToLower(unique)] = true
+
+	sheet := &xlsxSheet{name: unique}
+	wb.sheets = append(wb.sheets, sheet)
+	return sheet
+}
+
+// addRow appends a row of cells
+func (s *xlsxSheet) addRow(cells ...xlsxCell) {
+	s.rows = append(s.rows, cells)
+}
+
+// stringCell creates a text cell, empty strings produce an empty cell
+func stringCell(value string, style int) xlsxCell {
+	if value == "" {
+		return xlsxCell{kind: xlsxEmpty, style: style}
+	}
+	return xlsxCell{kind: xlsxString, str: value, style: style}
+}
+
+// numberCell creates a numeric cell
+func numberCell(value float64, style int) xlsxCell {
+	return xlsxCell{kind: xlsxNumber, num: value, style: style}
+}
+
+// dateCell creates a date cell stored as an Excel serial day number
+func dateCell(t time.Time, style int) xlsxCell {
+	days := t.Sub(excelEpoch).Hours() / 24
+	return xlsxCell{kind: xlsxNumber, num: days, style: style}
+}
+
+// emptyCell creates a cell without value
+func emptyCell() xlsxCell {
+	return xlsxCell{kind: xlsxEmpty}
+}
+
+// columnName converts a zero-based column index into a column letter (0 -> A, 26 -> AA)
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// write writes the complete workbook as a zip archive
+func (wb *xlsxWorkbook) write(w io.Writer) error {
+	if len(wb.sheets) == 0 {
+		wb.addSheet("Sheet1")
+	}
+
+	zw := zip.NewWriter(w)
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", wb.contentTypesXML()},
+		{"_rels/.rels", rootRelsXML},
+		{"xl/workbook.xml", wb.workbookXML()},
+		{"xl/_rels/workbook.xml.rels", wb.workbookRelsXML()},
+		{"xl/styles.xml", wb.stylesXML()},
+	}
+	for i, sheet := range wb.sheets {
+		files = append(files, struct {
+			name    string
+			content string
+		}{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), sheet.xml()})
+	}
+
+	for _, file := range files {
+		fw, err := zw.Create(file.name)
+		if err != nil {
+			return fmt.Errorf("failed to create %s in workbook: %w", file.name, err)
+		}
+		if _, err := io.WriteString(fw, file.content); err != nil {
+			return fmt.Errorf("failed to write %s in workbook: %w", file.name, err)
+		}
+	}
+
+	return zw.Close()
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+const rootRelsXML = xmlHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+func (wb *xlsxWorkbook) contentTypesXML() string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range wb.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func (wb *xlsxWorkbook) workbookXML() string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, sheet := range wb.sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sheet.name), i+1, i+1)
+	}
+	b.WriteString(`</sheets>`)
+
+	// Autofilters need a hidden defined name per sheet to be recognised by Excel
+	var definedNames strings.Builder
+	for i, sheet := range wb.sheets {
+		if ref := sheet.autoFilterRef(); ref != "" {
+			fmt.Fprintf(&definedNames, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">'%s'!%s</definedName>`,
+				i, xmlEscape(strings.ReplaceAll(sheet.name, "'", "''")), absoluteRef(ref))
+		}
+	}
+	if definedNames.Len() > 0 {
+		b.WriteString(`<definedNames>` + definedNames.String() + `</definedNames>`)
+	}
+
+	b.WriteString(`</workbook>`)
+	return b.String()
+}
+
+func (wb *xlsxWorkbook) workbookRelsXML() string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := range wb.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(wb.sheets)+1)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+func (wb *xlsxWorkbook) stylesXML() string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+
+	if len(wb.numFmts) > 0 {
+		fmt.Fprintf(&b, `<numFmts count="%d">`, len(wb.numFmts))
+		for i, code := range wb.numFmts {
+			fmt.Fprintf(&b, `<numFmt numFmtId="%d" formatCode="%s"/>`, customNumFmtBase+i, xmlEscape(code))
+		}
+		b.WriteString(`</numFmts>`)
+	}
+
+	b.WriteString(`<fonts count="2">`)
+	b.WriteString(`<font><sz val="11"/><name val="Calibri"/><family val="2"/></font>`)
+	b.WriteString(`<font><b/><sz val="11"/><name val="Calibri"/><family val="2"/></font>`)
+	b.WriteString(`</fonts>`)
+	b.WriteString(`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>`)
+	b.WriteString(`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>`)
+	b.WriteString(`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>`)
+
+	fmt.Fprintf(&b, `<cellXfs count="%d">`, len(wb.xfs))
+	for _, xf := range wb.xfs {
+		fontID := 0
+		if xf.bold {
+			fontID = 1
+		}
+		fmt.Fprintf(&b, `<xf numFmtId="%d" fontId="%d" fillId="0" borderId="0" xfId="0"`, xf.numFmtID, fontID)
+		if xf.numFmtID != xlsxFmtGeneral {
+			b.WriteString(` applyNumberFormat="1"`)
+		}
+		if xf.bold {
+			b.WriteString(` applyFont="1"`)
+		}
+		b.WriteString(`/>`)
+	}
+	b.WriteString(`</cellXfs>`)
+
+	b.WriteString(`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>`)
+	b.WriteString(`</styleSheet>`)
+	return b.String()
+}
+
+// autoFilterRef returns the range covered by the autofilter, or "" if disabled
+func (s *xlsxSheet) autoFilterRef() string {
+	if !s.autoFilter || len(s.rows) == 0 || len(s.rows[0]) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("A1:%s%d", columnName(len(s.rows[0])-1), len(s.rows))
+}
+
+// absoluteRef turns "A1:L10" into "$A$1:$L$10"
+func absoluteRef(ref string) string {
+	parts := strings.Split(ref, ":")
+	for i, part := range parts {
+		split := strings.IndexAny(part, "0123456789")
+		if split > 0 {
+			parts[i] = "$" + part[:split] + "$" + part[split:]
+		}
+	}
+	return strings.Join(parts, ":")
+}
+
+func (s *xlsxSheet) xml() string {
+	var b strings.Builder
+	b.WriteString(xmlHeader)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+
+	if s.freezeHeader && len(s.rows) > 1 {
+		b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+
+	if len(s.widths) > 0 {
+		b.WriteString(`<cols>`)
+		for i, width := range s.widths {
+			fmt.Fprintf(&b, `<col min="%d" max="%d" width="%s" customWidth="1"/>`, i+1, i+1, strconv.FormatFloat(width, 'f', -1, 64))
+		}
+		b.WriteString(`</cols>`)
+	}
+
+	b.WriteString(`<sheetData>`)
+	for r, row := range s.rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			ref := fmt.Sprintf("%s%d", columnName(c), r+1)
+			style := ""
+			if cell.style != 0 {
+				style = fmt.Sprintf(` s="%d"`, cell.style)
+			}
+			switch cell.kind {
+			case xlsxString:
+				fmt.Fprintf(&b, `<c r="%s"%s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, style, xmlEscape(cell.str))
+			case xlsxNumber:
+				fmt.Fprintf(&b, `<c r="%s"%s><v>%s</v></c>`, ref, style, strconv.FormatFloat(cell.num, 'f', -1, 64))
+			default:
+				if style != "" {
+					fmt.Fprintf(&b, `<c r="%s"%s/>`, ref, style)
+				}
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+	b.WriteString(`</sheetData>`)
+
+	if ref := s.autoFilterRef(); ref != "" {
+		fmt.Fprintf(&b, `<autoFilter ref="%s"/>`, ref)
+	}
+
+	b.WriteString(`</worksheet>`)
+	return b.String()
+}
+
+// xmlEscape escapes text for use in XML content and attribute values
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
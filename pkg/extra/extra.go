@@ -0,0 +1,136 @@
+package extra
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/invopop/jsonschema"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// Prefix selects an extra field by name in naming patterns and CSV columns, e.g. "extra:project_code"
+const Prefix = "extra:"
+
+// PropertyName is the JSON name of the object holding the extra fields
+const PropertyName = "extra"
+
+// Types of extra fields
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeInteger = "integer"
+	TypeBoolean = "boolean"
+	TypeDate    = "date"
+)
+
+// namePattern keeps field names usable as JSON keys, pattern fields and ledger metadata
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// Field is a user-defined field extracted in addition to the built-in ones
+type Field struct {
+	// Name is the key in the extra map, lower case letters, digits and "_"
+	Name string
+
+	// Type is TypeString (default), TypeNumber, TypeInteger, TypeBoolean or TypeDate
+	Type string
+
+	// Description tells the model what to extract
+	Description string
+
+	// Enum limits a string field to the given values
+	Enum []string
+}
+
+// Check returns an error if a field has an invalid name or type, or a name is used twice
+func Check(fields []Field) error {
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		if !namePattern.MatchString(field.Name) {
+			return fmt.Errorf("invalid extra field name %q: use lower case letters, digits and _", field.Name)
+		}
+		if seen[field.Name] {
+			return fmt.Errorf("extra field %q is defined twice", field.Name)
+		}
+		seen[field.Name] = true
+
+		switch field.Type {
+		case "", TypeString, TypeNumber, TypeInteger, TypeBoolean, TypeDate:
+		default:
+			return fmt.Errorf("extra field %q: unknown type %q (use %s, %s, %s, %s or %s)", field.Name, field.Type,
+				TypeString, TypeNumber, TypeInteger, TypeBoolean, TypeDate)
+		}
+		if len(field.Enum) > 0 && field.Type != "" && field.Type != TypeString {
+			return fmt.Errorf("extra field %q: enum is only supported for string fields", field.Name)
+		}
+	}
+	return nil
+}
+
+// Schema returns the JSON schema of the extra object
+// Every field is required and nullable, as structured outputs in strict mode require.
+func Schema(fields []Field) *jsonschema.Schema {
+	properties := jsonschema.NewProperties()
+	var required []string
+	for _, field := range fields {
+		value := &jsonschema.Schema{Type: field.Type}
+		description := strings.TrimSpace(field.Description)
+		switch field.Type {
+		case "":
+			value.Type = TypeString
+		case TypeDate:
+			value.Type = TypeString
+			description += " in YYYY-MM-DD format"
+		}
+		if description != "" {
+			description += ", "
+		}
+		description += "null if not found"
+		for _, option := range field.Enum {
+			value.Enum = append(value.Enum, option)
+		}
+
+		properties.Set(field.Name, &jsonschema.Schema{
+			AnyOf:       []*jsonschema.Schema{value, {Type: "null"}},
+			Description: description,
+		})
+		required = append(required, field.Name)
+	}
+
+	return &jsonschema.Schema{
+		Type:                 "object",
+		Description:          "Additional fields, null if not found in the document",
+		Properties:           properties,
+		Required:             required,
+		AdditionalProperties: jsonschema.FalseSchema,
+	}
+}
+
+// Names returns the sorted names of the extra fields of a document
+func Names(info *interfaces.ReceiptInvoiceInfo) []string {
+	names := make([]string, 0, len(info.Extra))
+	for name := range info.Extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Value returns an extra field of a document as text, "" if it is missing or null
+// Numbers are written without exponent and without trailing zeros.
+func Value(info *interfaces.ReceiptInvoiceInfo, name string) string {
+	switch value := info.Extra[name].(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	default:
+		return fmt.Sprint(value)
+	}
+}
@@ -0,0 +1,108 @@
+package extra
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []Field
+		valid  bool
+	}{
+		{"valid", []Field{{Name: "project_code"}, {Name: "cost_center2", Type: TypeInteger}, {Name: "kind", Enum: []string{"a", "b"}}}, true},
+		{"upper case name", []Field{{Name: "ProjectCode"}}, false},
+		{"name starting with a digit", []Field{{Name: "2nd"}}, false},
+		{"name used twice", []Field{{Name: "project"}, {Name: "project", Type: TypeNumber}}, false},
+		{"unknown type", []Field{{Name: "project", Type: "array"}}, false},
+		{"enum of a number", []Field{{Name: "vat_rate", Type: TypeNumber, Enum: []string{"25"}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Check(tt.fields); (err == nil) != tt.valid {
+				t.Errorf("Check = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	schema := Schema([]Field{
+		{Name: "project_code", Description: "Project code"},
+		{Name: "delivered", Type: TypeDate, Description: "Delivery date"},
+		{Name: "kind", Enum: []string{"hardware", "software"}},
+	})
+
+	content, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Required             []string `json:"required"`
+		AdditionalProperties bool     `json:"additionalProperties"`
+		Properties           map[string]struct {
+			AnyOf []struct {
+				Type string   `json:"type"`
+				Enum []string `json:"enum"`
+			} `json:"anyOf"`
+			Description string `json:"description"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(content, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"project_code", "delivered", "kind"}; !reflect.DeepEqual(got.Required, want) || got.AdditionalProperties {
+		t.Errorf("required %v and additional properties %v, want %v and false", got.Required, got.AdditionalProperties, want)
+	}
+	tests := []struct {
+		name        string
+		typ         string
+		enum        []string
+		description string
+	}{
+		{"project_code", TypeString, nil, "Project code, null if not found"},
+		{"delivered", TypeString, nil, "Delivery date in YYYY-MM-DD format, null if not found"},
+		{"kind", TypeString, []string{"hardware", "software"}, "null if not found"},
+	}
+	for _, tt := range tests {
+		property := got.Properties[tt.name]
+		if len(property.AnyOf) != 2 || property.AnyOf[0].Type != tt.typ || property.AnyOf[1].Type != "null" ||
+			!reflect.DeepEqual(property.AnyOf[0].Enum, tt.enum) || property.Description != tt.description {
+			t.Errorf("%s = %+v, want a nullable %s with enum %v and description %q", tt.name, property, tt.typ, tt.enum, tt.description)
+		}
+	}
+}
+
+func TestNamesAndValue(t *testing.T) {
+	info := &interfaces.ReceiptInvoiceInfo{Extra: map[string]interface{}{
+		"project": "P-7",
+		"hours":   float64(12.50),
+		"billed":  true,
+		"count":   float64(1e21),
+		"missing": nil,
+	}}
+
+	if want := []string{"billed", "count", "hours", "missing", "project"}; !reflect.DeepEqual(Names(info), want) {
+		t.Errorf("Names = %v, want %v", Names(info), want)
+	}
+	tests := []struct {
+		name, want string
+	}{
+		{"project", "P-7"},
+		{"hours", "12.5"},
+		{"billed", "true"},
+		{"count", "1000000000000000000000"},
+		{"missing", ""},
+		{"unknown", ""},
+	}
+	for _, tt := range tests {
+		if got := Value(info, tt.name); got != tt.want {
+			t.Errorf("Value(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+package heuristics
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/sourceview"
+)
+
+// IdFieldPrefix selects an ID field by name, e.g. "id:Invoice Number"
+const IdFieldPrefix = sourceview.IdFieldPrefix
+
+// maxFound limits the values recorded per field, documents can hold many dates and amounts
+const maxFound = 10
+
+// amountSlack is the difference accepted between two amounts in the same currency
+const amountSlack = 0.005
+
+// idFieldNames are the words in ID field names given by the AI provider, per kind
+var idFieldNames = []struct {
+	kind  string
+	words []string
+}{
+	{KindInvoiceNumber, []string{"invoice", "faktura"}},
+	{KindReceiptNumber, []string{"receipt", "kvitto"}},
+	{KindOrderNumber, []string{"order"}},
+	{KindOCR, []string{"ocr"}},
+	{KindOrgNumber, []string{"org", "organisation", "organization", "corporate id", "registration"}},
+	{KindBankgiro, []string{"bankgiro", "bg"}},
+}
+
+// Compare checks the extracted fields against the findings of the rules
+// Fields the rules cannot find, like company and description, are not compared. Identifiers
+// the provider did not extract are reported as CheckRulesOnly.
+func Compare(f *Findings, info *interfaces.ReceiptInvoiceInfo) []interfaces.FieldCheck {
+	var checks []interfaces.FieldCheck
+	add := func(check *interfaces.FieldCheck) {
+		if check != nil {
+			if len(check.Found) > maxFound {
+				check.Found = check.Found[:maxFound]
+			}
+			checks = append(checks, *check)
+		}
+	}
+
+	add(compareDate(f, info))
+	currency := strings.ToUpper(strings.TrimSpace(document.StringValue(info.OriginalCurrency)))
+	add(compareAmount(f, "original_amount", info.OriginalAmount, currency, LabelTotal))
+	add(compareCurrency(f, currency))
+	add(compareAmount(f, "original_vat_amount", info.OriginalVatAmount, currency, LabelVAT))
+	if currency == "SEK" && info.SECentAmount != nil {
+		amount := float64(*info.SECentAmount) / 100
+		add(compareAmount(f, "se_cent_amount", &amount, currency, LabelTotal))
+	}
+	for _, check := range compareIdentifiers(f, info) {
+		add(&check)
+	}
+	return checks
+}
+
+// Conflicts returns the checks with status CheckConflict
+func Conflicts(checks []interfaces.FieldCheck) []interfaces.FieldCheck {
+	var conflicts []interfaces.FieldCheck
+	for _, check := range checks {
+		if check.Status == interfaces.CheckConflict {
+			conflicts = append(conflicts, check)
+		}
+	}
+	return conflicts
+}
+
+// Count returns the number of checks with a status
+func Count(checks []interfaces.FieldCheck, status string) int {
+	n := 0
+	for _, check := range checks {
+		if check.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// compareDate checks date_issued against the dates of the text
+func compareDate(f *Findings, info *interfaces.ReceiptInvoiceInfo) *interfaces.FieldCheck {
+	found := f.DateStrings()
+	date := document.DateString(info)
+	if date == "" {
+		if len(found) == 0 {
+			return nil
+		}
+		return &interfaces.FieldCheck{Field: "date_issued", Status: interfaces.CheckRulesOnly, Found: found}
+	}
+	check := &interfaces.FieldCheck{Field: "date_issued", Value: date, Found: found}
+	if t, ok := document.ParseDate(info); ok {
+		date = t.Format(document.DateLayout)
+	}
+	check.Status = status(len(found) > 0, contains(found, date))
+	return check
+}
+
+// compareAmount checks an amount against the amounts of the text in the same currency
+// Labels are not reliable enough to compare with, PDF text often separates them from their
+// amounts, they only select the amounts reported for a field the provider left empty.
+func compareAmount(f *Findings, field string, value *float64, currency string, label string) *interfaces.FieldCheck {
+	var candidates, labelled []Amount
+	for _, amount := range f.Amounts {
+		if amount.Currency != "" && currency != "" && amount.Currency != currency {
+			continue
+		}
+		candidates = append(candidates, amount)
+		if amount.Label == label {
+			labelled = append(labelled, amount)
+		}
+	}
+
+	if value == nil {
+		if len(labelled) == 0 {
+			return nil
+		}
+		return &interfaces.FieldCheck{Field: field, Status: interfaces.CheckRulesOnly, Found: formatAmounts(labelled)}
+	}
+
+	v := math.Abs(*value)
+	if v == 0 && len(labelled) == 0 {
+		// Documents without VAT rarely say so
+		return nil
+	}
+	check := &interfaces.FieldCheck{Field: field, Value: strconv.FormatFloat(*value, 'f', 2, 64), Found: formatAmounts(candidates)}
+	matched := false
+	for _, amount := range candidates {
+		if math.Abs(amount.Value-v) < amountSlack {
+			matched = true
+			break
+		}
+	}
+	check.Status = status(len(candidates) > 0, matched)
+	return check
+}
+
+// compareCurrency checks original_currency against the currencies of the amounts in the text
+func compareCurrency(f *Findings, currency string) *interfaces.FieldCheck {
+	var found []string
+	for _, amount := range f.Amounts {
+		if amount.Currency != "" && !contains(found, amount.Currency) {
+			found = append(found, amount.Currency)
+		}
+	}
+	if currency == "" {
+		if len(found) == 0 {
+			return nil
+		}
+		return &interfaces.FieldCheck{Field: "original_currency", Status: interfaces.CheckRulesOnly, Found: found}
+	}
+	return &interfaces.FieldCheck{
+		Field:  "original_currency",
+		Status: status(len(found) > 0, contains(found, currency)),
+		Value:  currency,
+		Found:  found,
+	}
+}
+
+// compareIdentifiers checks the ID fields against the identifiers of the same kind
+func compareIdentifiers(f *Findings, info *interfaces.ReceiptInvoiceInfo) []interfaces.FieldCheck {
+	var checks []interfaces.FieldCheck
+	for _, kind := range idFieldNames {
+		found := f.IdentifierValues(kind.kind)
+		compared := false
+		for _, idField := range info.IdFields {
+			if strings.TrimSpace(idField.Value) == "" || !matchesKind(idField.Name, kind.words) {
+				continue
+			}
+			compared = true
+			matched := false
+			for _, value := range found {
+				if normalizeID(value) == normalizeID(idField.Value) {
+					matched = true
+				}
+			}
+			checks = append(checks, interfaces.FieldCheck{
+				Field:  IdFieldPrefix + idField.Name,
+				Status: status(len(found) > 0, matched),
+				Value:  idField.Value,
+				Found:  found,
+			})
+		}
+		if !compared && len(found) > 0 {
+			checks = append(checks, interfaces.FieldCheck{Field: IdFieldPrefix + kind.kind, Status: interfaces.CheckRulesOnly, Found: found})
+		}
+	}
+	return checks
+}
+
+// matchesKind reports whether an ID field name contains one of the words of a kind
+func matchesKind(name string, words []string) bool {
+	name = strings.ToLower(name)
+	for _, word := range words {
+		if containsWord(name, word) || len(word) > 3 && strings.Contains(name, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// status returns the status of a value that was or was not among the values found
+func status(anyFound, matched bool) string {
+	switch {
+	case matched:
+		return interfaces.CheckAgree
+	case anyFound:
+		return interfaces.CheckConflict
+	}
+	return interfaces.CheckAIOnly
+}
+
+// formatAmounts formats amounts as "95.37 EUR", de-duplicated
+func formatAmounts(amounts []Amount) []string {
+	var formatted []string
+	for _, amount := range amounts {
+		s := strconv.FormatFloat(amount.Value, 'f', 2, 64)
+		if amount.Currency != "" {
+			s += " " + amount.Currency
+		}
+		if !contains(formatted, s) {
+			formatted = append(formatted, s)
+		}
+	}
+	return formatted
+}
+
+// contains reports whether values contains s
+func contains(values []string, s string) bool {
+	for _, value := range values {
+		if value == s {
+			return true
+		}
+	}
+	return false
+}
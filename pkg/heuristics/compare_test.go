@@ -0,0 +1,81 @@
+package heuristics
+
+import (
+	"testing"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+func TestCompare(t *testing.T) {
+	text := "Invoice number D8F67A38-0007\nDate paid August 2, 2025\nVAT €19.07\nTotal €95.37"
+	str := func(s string) *string { return &s }
+	num := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name string
+		info interfaces.ReceiptInvoiceInfo
+		want map[string]string
+	}{
+		{
+			name: "everything agrees",
+			info: interfaces.ReceiptInvoiceInfo{
+				DateIssued:        str("2025-08-02"),
+				OriginalAmount:    num(95.37),
+				OriginalVatAmount: num(19.07),
+				OriginalCurrency:  str("EUR"),
+				IdFields:          []interfaces.IdField{{Name: "Invoice Number", Value: "D8F67A380007"}},
+			},
+			want: map[string]string{
+				"date_issued":         interfaces.CheckAgree,
+				"original_amount":     interfaces.CheckAgree,
+				"original_currency":   interfaces.CheckAgree,
+				"original_vat_amount": interfaces.CheckAgree,
+				"id:Invoice Number":   interfaces.CheckAgree,
+			},
+		},
+		{
+			// Amounts in other currencies are not compared
+			name: "conflicting values",
+			info: interfaces.ReceiptInvoiceInfo{
+				DateIssued:       str("2025-02-08"),
+				OriginalAmount:   num(59.37),
+				OriginalCurrency: str("USD"),
+				IdFields:         []interfaces.IdField{{Name: "Invoice Number", Value: "D8F67A38-0008"}},
+			},
+			want: map[string]string{
+				"date_issued":       interfaces.CheckConflict,
+				"original_amount":   interfaces.CheckAIOnly,
+				"original_currency": interfaces.CheckConflict,
+				"id:Invoice Number": interfaces.CheckConflict,
+			},
+		},
+		{
+			name: "fields left empty",
+			info: interfaces.ReceiptInvoiceInfo{},
+			want: map[string]string{
+				"date_issued":         interfaces.CheckRulesOnly,
+				"original_amount":     interfaces.CheckRulesOnly,
+				"original_currency":   interfaces.CheckRulesOnly,
+				"original_vat_amount": interfaces.CheckRulesOnly,
+				"id:Invoice Number":   interfaces.CheckRulesOnly,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checks := Compare(Extract(text), &tt.info)
+			got := make(map[string]string)
+			for _, check := range checks {
+				got[check.Field] = check.Status
+			}
+			for field, want := range tt.want {
+				if got[field] != want {
+					t.Errorf("%s = %q, want %q", field, got[field], want)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got checks %v, want %d", got, len(tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,457 @@
+package heuristics
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/validate"
+)
+
+// Kinds of identifiers, named like the ID fields the AI provider is asked for
+const (
+	KindInvoiceNumber = "Invoice Number"
+	KindReceiptNumber = "Receipt Number"
+	KindOrderNumber   = "Order Number"
+	KindOCR           = "OCR"
+	KindOrgNumber     = "Org Number"
+	KindBankgiro      = "Bankgiro"
+)
+
+// Labels of amounts, taken from the words on the same or the preceding line
+const (
+	LabelTotal = "total"
+	LabelNet   = "net"
+	LabelVAT   = "vat"
+)
+
+// Amount is a money amount found in the text
+type Amount struct {
+	// Value is the amount, always positive
+	Value float64
+
+	// Currency is the ISO 4217 code, "" if the amount has no currency sign or code
+	Currency string
+
+	// Label is LabelTotal, LabelNet, LabelVAT or "" if the amount has no recognised label
+	Label string
+
+	// Text is the amount as written
+	Text string
+}
+
+// Identifier is an ID found in the text
+type Identifier struct {
+	// Kind is one of the Kind constants
+	Kind string
+
+	// Value is the identifier as written, control characters removed
+	Value string
+}
+
+// Findings are the values found by the rules in a text
+type Findings struct {
+	// Dates are all valid dates, in order of first appearance
+	Dates []time.Time
+
+	// Amounts are amounts with a currency or a label, in order of appearance
+	Amounts []Amount
+
+	// Identifiers are invoice, receipt and order numbers, OCR references, org numbers
+	// and bankgiro numbers, in order of appearance
+	Identifiers []Identifier
+}
+
+var (
+	// number matches amounts with optional thousands grouping and decimals, e.g. "1 096,77" or "95.37"
+	number = `\d{1,3}(?:[ \x{00a0}.,]\d{3})+(?:[.,]\d{1,2})?|\d+(?:[.,]\d{1,2})?`
+
+	// currencySymbols maps signs and local spellings to ISO 4217 codes
+	currencySymbols = map[string]string{
+		"€": "EUR", "$": "USD", "us$": "USD", "£": "GBP", "kr": "SEK", "kr.": "SEK", "sek": "SEK", ":-": "SEK",
+	}
+
+	currency        = `€|\$|US\$|£|kr\.?|:-|[A-Z]{3}`
+	amountBefore    = regexp.MustCompile(`(?i)(` + currency + `)\s?(` + number + `)(?:\D|$)`)
+	amountAfter     = regexp.MustCompile(`(?:^|[^\d.,])(` + number + `)\s?(` + currency + `)(?:[^A-Za-z]|$)`)
+	labelledNumber  = regexp.MustCompile(`(?:^|[\s:])(` + number + `)\s*$`)
+	isoDate         = regexp.MustCompile(`\b(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})\b`)
+	dayMonthYear    = regexp.MustCompile(`\b(\d{1,2})[./](\d{1,2})[./](\d{4})\b`)
+	monthNameFirst  = regexp.MustCompile(`(?i)\b([a-zåäö]{3,9})\.?\s+(\d{1,2})(?:st|nd|rd|th)?,?\s+(\d{4})\b`)
+	monthNameSecond = regexp.MustCompile(`(?i)\b(\d{1,2})(?:st|nd|rd|th)?\.?\s+([a-zåäö]{3,9})\.?,?\s+(\d{4})\b`)
+	orgNumber       = regexp.MustCompile(`\b(\d{6})-(\d{4})\b`)
+	labelledOrg     = regexp.MustCompile(`(?i)(?:org(?:anisations|anization)?[\s.]*(?:nr|nummer|no|number)?|corporate\s+id(?:entity)?(?:\s+number)?|reg(?:istration)?[\s.]*(?:nr|no|number))[\s.:#]*(\d{6}-?\d{4})\b`)
+	vatNumber       = regexp.MustCompile(`\bSE\s?(\d{10})\s?01\b`)
+	bankgiro        = regexp.MustCompile(`(?i)(?:bankgiro|bg)[\s.:#]*(?:nr\.?|nummer)?[\s.:#]*(\d{3,4}-\d{4})\b`)
+)
+
+// monthNames maps English and Swedish month names and abbreviations to months
+var monthNames = map[string]time.Month{}
+
+func init() {
+	names := [][]string{
+		{"january", "januari"}, {"february", "februari"}, {"march", "mars"}, {"april"},
+		{"may", "maj"}, {"june", "juni"}, {"july", "juli"}, {"august", "augusti"},
+		{"september", "sept"}, {"october", "oktober"}, {"november"}, {"december"},
+	}
+	for i, spellings := range names {
+		for _, name := range spellings {
+			monthNames[name] = time.Month(i + 1)
+			monthNames[name[:3]] = time.Month(i + 1)
+		}
+	}
+	// "okt" is the Swedish abbreviation of oktober
+	monthNames["okt"] = time.October
+}
+
+// idPatterns find identifiers by their label, the value must contain a digit
+// Control characters are allowed inside values, PDF text extraction leaves them where a
+// hyphen or space was.
+var idPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{KindInvoiceNumber, idPattern(`invoice\s*(?:number|no\.?|nr\.?|#)|fakturanummer|fakturanr\.?|faktura\s*nr\.?`)},
+	{KindReceiptNumber, idPattern(`receipt\s*(?:number|no\.?|nr\.?|#)|kvittonummer|kvittonr\.?|kvitto\s*nr\.?`)},
+	{KindOrderNumber, idPattern(`order\s*(?:number|no\.?|nr\.?|id|#)|ordernummer|ordernr\.?`)},
+	{KindOCR, idPattern(`ocr(?:-nummer|\s*nummer|\s*number|\s*nr\.?)?`)},
+}
+
+// idPattern builds the pattern of a labelled identifier
+func idPattern(label string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)(?:` + label + `)[ \t]*[:#.]?[ \t]*([A-Za-z0-9][A-Za-z0-9\-/\x00-\x08]*[0-9][A-Za-z0-9\-/\x00-\x08]*|[0-9])`)
+}
+
+// Extract finds dates, amounts and identifiers in a text
+func Extract(text string) *Findings {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return &Findings{
+		Dates:       findDates(text),
+		Amounts:     findAmounts(text),
+		Identifiers: findIdentifiers(text),
+	}
+}
+
+// DateStrings returns the dates in YYYY-MM-DD format
+func (f *Findings) DateStrings() []string {
+	dates := make([]string, len(f.Dates))
+	for i, date := range f.Dates {
+		dates[i] = date.Format(document.DateLayout)
+	}
+	return dates
+}
+
+// IdentifierValues returns the values of all identifiers of a kind
+func (f *Findings) IdentifierValues(kind string) []string {
+	var values []string
+	for _, id := range f.Identifiers {
+		if id.Kind == kind {
+			values = append(values, id.Value)
+		}
+	}
+	return values
+}
+
+// findDates returns the valid dates of the text, de-duplicated in order of first appearance
+func findDates(text string) []time.Time {
+	type found struct {
+		pos  int
+		date time.Time
+	}
+	var all []found
+	add := func(pos int, year, month, day int) {
+		if year < 1990 || year > 2100 {
+			return
+		}
+		date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+		// time.Date normalises 31 February to March, such dates are not real
+		if date.Year() == year && date.Month() == time.Month(month) && date.Day() == day {
+			all = append(all, found{pos, date})
+		}
+	}
+
+	for _, m := range isoDate.FindAllStringSubmatchIndex(text, -1) {
+		add(m[0], atoi(text[m[2]:m[3]]), atoi(text[m[4]:m[5]]), atoi(text[m[6]:m[7]]))
+	}
+	for _, m := range dayMonthYear.FindAllStringSubmatchIndex(text, -1) {
+		day, month, year := atoi(text[m[2]:m[3]]), atoi(text[m[4]:m[5]]), atoi(text[m[6]:m[7]])
+		add(m[0], year, month, day)
+		if strings.Contains(text[m[0]:m[1]], "/") && day != month {
+			// Slashes are also used month first (08/02/2025 in the US)
+			add(m[0], year, day, month)
+		}
+	}
+	for _, m := range monthNameFirst.FindAllStringSubmatchIndex(text, -1) {
+		if month, ok := monthNames[strings.ToLower(text[m[2]:m[3]])]; ok {
+			add(m[0], atoi(text[m[6]:m[7]]), int(month), atoi(text[m[4]:m[5]]))
+		}
+	}
+	for _, m := range monthNameSecond.FindAllStringSubmatchIndex(text, -1) {
+		if month, ok := monthNames[strings.ToLower(text[m[4]:m[5]])]; ok {
+			add(m[0], atoi(text[m[6]:m[7]]), int(month), atoi(text[m[2]:m[3]]))
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].pos < all[j].pos })
+	var dates []time.Time
+	seen := make(map[time.Time]bool)
+	for _, f := range all {
+		if !seen[f.date] {
+			seen[f.date] = true
+			dates = append(dates, f.date)
+		}
+	}
+	return dates
+}
+
+// findAmounts returns the amounts with a currency, and amounts alone at the end of a labelled line
+func findAmounts(text string) []Amount {
+	var amounts []Amount
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		label := lineLabel(line)
+		if label == "" {
+			// Columns of PDF text put the label on a preceding line
+			label = lineLabel(previousLine(lines, i))
+		}
+
+		type found struct {
+			pos    int
+			amount Amount
+		}
+		var onLine []found
+		covered := make(map[int]bool)
+		addMatch := func(pos int, numberText string, currencyText string, text string) {
+			code, ok := currencyCode(currencyText)
+			value, valid := parseNumber(numberText)
+			if !ok || !valid || covered[pos] {
+				return
+			}
+			covered[pos] = true
+			onLine = append(onLine, found{pos, Amount{Value: value, Currency: code, Label: label, Text: strings.TrimSpace(text)}})
+		}
+		for _, m := range amountBefore.FindAllStringSubmatchIndex(line, -1) {
+			addMatch(m[4], line[m[4]:m[5]], line[m[2]:m[3]], line[m[2]:m[5]])
+		}
+		for _, m := range amountAfter.FindAllStringSubmatchIndex(line, -1) {
+			addMatch(m[2], line[m[2]:m[3]], line[m[4]:m[5]], line[m[2]:m[5]])
+		}
+		if len(onLine) == 0 && lineLabel(line) != "" {
+			if m := labelledNumber.FindStringSubmatchIndex(line); m != nil {
+				if value, ok := parseNumber(line[m[2]:m[3]]); ok {
+					onLine = append(onLine, found{m[2], Amount{Value: value, Label: label, Text: line[m[2]:m[3]]}})
+				}
+			}
+		}
+
+		sort.SliceStable(onLine, func(a, b int) bool { return onLine[a].pos < onLine[b].pos })
+		for _, f := range onLine {
+			amounts = append(amounts, f.amount)
+		}
+	}
+	return amounts
+}
+
+// findIdentifiers returns labelled identifiers, org numbers and bankgiro numbers
+func findIdentifiers(text string) []Identifier {
+	type found struct {
+		pos int
+		id  Identifier
+	}
+	var all []found
+	seen := make(map[string]bool)
+	add := func(pos int, kind, value string) {
+		value = strings.Map(func(r rune) rune {
+			if unicode.IsControl(r) {
+				return -1
+			}
+			return r
+		}, strings.TrimRight(value, "-/"))
+		key := kind + "\x00" + normalizeID(value)
+		if value == "" || seen[key] {
+			return
+		}
+		seen[key] = true
+		all = append(all, found{pos, Identifier{Kind: kind, Value: value}})
+	}
+
+	for _, p := range idPatterns {
+		for _, m := range p.pattern.FindAllStringSubmatchIndex(text, -1) {
+			add(m[2], p.kind, text[m[2]:m[3]])
+		}
+	}
+
+	for _, m := range labelledOrg.FindAllStringSubmatchIndex(text, -1) {
+		if digits := onlyDigits(text[m[2]:m[3]]); luhn(digits) {
+			add(m[2], KindOrgNumber, digits[:6]+"-"+digits[6:])
+		}
+	}
+	for _, m := range orgNumber.FindAllStringSubmatchIndex(text, -1) {
+		if digits := text[m[2]:m[3]] + text[m[4]:m[5]]; luhn(digits) && !insideBankgiro(text, m[0]) {
+			add(m[0], KindOrgNumber, digits[:6]+"-"+digits[6:])
+		}
+	}
+	for _, m := range vatNumber.FindAllStringSubmatchIndex(text, -1) {
+		if digits := text[m[2]:m[3]]; luhn(digits) {
+			add(m[2], KindOrgNumber, digits[:6]+"-"+digits[6:])
+		}
+	}
+	for _, m := range bankgiro.FindAllStringSubmatchIndex(text, -1) {
+		if luhn(onlyDigits(text[m[2]:m[3]])) {
+			add(m[2], KindBankgiro, text[m[2]:m[3]])
+		}
+	}
+
+	sort.SliceStable(all, func(i, j int) bool { return all[i].pos < all[j].pos })
+	ids := make([]Identifier, len(all))
+	for i, f := range all {
+		ids[i] = f.id
+	}
+	return ids
+}
+
+// lineLabel returns the label of the amounts on a line, "" if it has none
+// Net is tested before VAT and total, "Total excluding tax" is the net amount.
+func lineLabel(line string) string {
+	line = strings.ToLower(line)
+	for _, l := range []struct {
+		label string
+		words []string
+	}{
+		{LabelNet, []string{"subtotal", "excluding", "excl", "exkl", "exklusive", "netto", "net amount", "delsumma"}},
+		{LabelVAT, []string{"vat", "tax", "moms", "mva"}},
+		{LabelTotal, []string{"total", "amount due", "amount paid", "summa", "att betala", "belopp"}},
+	} {
+		for _, word := range l.words {
+			if containsWord(line, word) {
+				return l.label
+			}
+		}
+	}
+	return ""
+}
+
+// previousLine returns the closest non-empty line before line i within two lines
+func previousLine(lines []string, i int) string {
+	for j := i - 1; j >= 0 && j >= i-2; j-- {
+		if strings.TrimSpace(lines[j]) != "" {
+			return lines[j]
+		}
+	}
+	return ""
+}
+
+// containsWord reports whether s contains word not directly preceded or followed by a letter
+func containsWord(s, word string) bool {
+	for start := 0; ; {
+		i := strings.Index(s[start:], word)
+		if i < 0 {
+			return false
+		}
+		i += start
+		end := i + len(word)
+		before := i == 0 || !isLetter(s[i-1])
+		after := end == len(s) || !isLetter(s[end])
+		if before && after {
+			return true
+		}
+		start = i + 1
+	}
+}
+
+// isLetter reports whether b is an ASCII letter
+func isLetter(b byte) bool {
+	return b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// currencyCode returns the ISO 4217 code of a currency sign or code
+func currencyCode(s string) (string, bool) {
+	if code, ok := currencySymbols[strings.ToLower(s)]; ok {
+		return code, true
+	}
+	if len(s) == 3 && s == strings.ToUpper(s) && validate.IsCurrency(s) {
+		return s, true
+	}
+	return "", false
+}
+
+// parseNumber parses an amount written with dot or comma decimals and optional grouping
+// A separator followed by one or two digits at the end is the decimal separator.
+func parseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	decimals := ""
+	if i := strings.LastIndexAny(s, ".,"); i >= 0 && len(s)-i-1 <= 2 {
+		decimals = s[i+1:]
+		s = s[:i]
+	}
+	digits := onlyDigits(s)
+	if digits == "" {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(digits+"."+decimals+"0", 64)
+	return value, err == nil
+}
+
+// normalizeID reduces an identifier to its upper case letters and digits for comparison
+func normalizeID(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(s) {
+		if r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// onlyDigits returns the digits of s
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// luhn reports whether digits pass the Luhn (modulus 10) check used by Swedish org
+// numbers, bankgiro numbers and OCR references
+func luhn(digits string) bool {
+	if len(digits) < 2 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// insideBankgiro reports whether the number at pos is labelled as a bankgiro number
+func insideBankgiro(text string, pos int) bool {
+	start := pos - 20
+	if start < 0 {
+		start = 0
+	}
+	before := strings.ToLower(text[start:pos])
+	return strings.Contains(before, "bankgiro") || containsWord(before, "bg")
+}
+
+// atoi converts digits matched by a pattern
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
@@ -0,0 +1,202 @@
+package heuristics
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractDates(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"iso", "Date: 2025-08-02", []string{"2025-08-02"}},
+		{"iso with slashes", "2025/8/2", []string{"2025-08-02"}},
+		{"day first with dots", "Datum 02.08.2025", []string{"2025-08-02"}},
+		{"slashes both ways", "08/02/2025", []string{"2025-02-08", "2025-08-02"}},
+		{"same day and month", "08/08/2025", []string{"2025-08-08"}},
+		{"english month first", "Date paid August 2, 2025", []string{"2025-08-02"}},
+		{"english ordinal", "2nd Aug 2025", []string{"2025-08-02"}},
+		{"swedish month", "2 augusti 2025", []string{"2025-08-02"}},
+		{"swedish abbreviation", "15 okt. 2025", []string{"2025-10-15"}},
+		{"invalid day", "2025-02-30", nil},
+		{"year out of range", "1899-01-01", nil},
+		{"duplicates in order", "2025-09-02 and 2025-08-02 and 2 Sep 2025", []string{"2025-09-02", "2025-08-02"}},
+		{"unknown month name", "2 Foo 2025", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Extract(tt.text).DateStrings()
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dates of %q = %v, want %v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractAmounts(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Amount
+	}{
+		{
+			name: "symbol before",
+			text: "Total €95.37",
+			want: []Amount{{Value: 95.37, Currency: "EUR", Label: LabelTotal, Text: "€95.37"}},
+		},
+		{
+			name: "code after with grouping",
+			text: "Att betala 1 096,77 SEK",
+			want: []Amount{{Value: 1096.77, Currency: "SEK", Label: LabelTotal, Text: "1 096,77 SEK"}},
+		},
+		{
+			name: "kronor sign",
+			text: "Summa 250:-",
+			want: []Amount{{Value: 250, Currency: "SEK", Label: LabelTotal, Text: "250:-"}},
+		},
+		{
+			name: "comma grouping dot decimals",
+			text: "Amount due $1,234.50",
+			want: []Amount{{Value: 1234.50, Currency: "USD", Label: LabelTotal, Text: "$1,234.50"}},
+		},
+		{
+			name: "net before vat",
+			text: "Total excluding tax 76.30 EUR",
+			want: []Amount{{Value: 76.30, Currency: "EUR", Label: LabelNet, Text: "76.30 EUR"}},
+		},
+		{
+			name: "vat line",
+			text: "Moms 25% 19,07 kr",
+			want: []Amount{{Value: 19.07, Currency: "SEK", Label: LabelVAT, Text: "19,07 kr"}},
+		},
+		{
+			name: "labelled number without currency",
+			text: "Total: 95.37",
+			want: []Amount{{Value: 95.37, Label: LabelTotal, Text: "95.37"}},
+		},
+		{
+			name: "label on the preceding line",
+			text: "Total\n€95.37",
+			want: []Amount{{Value: 95.37, Currency: "EUR", Label: LabelTotal, Text: "€95.37"}},
+		},
+		{
+			name: "unlabelled number without currency",
+			text: "Quantity 3",
+		},
+		{
+			name: "unknown three letter code",
+			text: "Ref ABC 123",
+		},
+		{
+			name: "several amounts on a line",
+			text: "€76.30 + €19.07",
+			want: []Amount{
+				{Value: 76.30, Currency: "EUR", Text: "€76.30"},
+				{Value: 19.07, Currency: "EUR", Text: "€19.07"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Extract(tt.text).Amounts
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("amounts of %q = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractIdentifiers(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []Identifier
+	}{
+		{
+			name: "invoice number",
+			text: "Invoice number D8F67A38-0007",
+			want: []Identifier{{Kind: KindInvoiceNumber, Value: "D8F67A38-0007"}},
+		},
+		{
+			name: "swedish invoice and ocr",
+			text: "Fakturanr: 10045\nOCR: 1004512",
+			want: []Identifier{{Kind: KindInvoiceNumber, Value: "10045"}, {Kind: KindOCR, Value: "1004512"}},
+		},
+		{
+			name: "receipt and order number",
+			text: "Receipt #2831-4417\nOrder ID: A-77",
+			want: []Identifier{{Kind: KindReceiptNumber, Value: "2831-4417"}, {Kind: KindOrderNumber, Value: "A-77"}},
+		},
+		{
+			name: "label without digits",
+			text: "Invoice number pending",
+		},
+		{
+			name: "control characters removed",
+			text: "Invoice number D8F67A38\x020007",
+			want: []Identifier{{Kind: KindInvoiceNumber, Value: "D8F67A380007"}},
+		},
+		{
+			name: "org number with luhn check",
+			text: "Org.nr 556123-4567",
+			want: []Identifier{{Kind: KindOrgNumber, Value: "556123-4567"}},
+		},
+		{
+			name: "org number failing luhn",
+			text: "556123-4568",
+		},
+		{
+			name: "vat number gives org number once",
+			text: "Org nr 556123-4567, VAT SE556123456701",
+			want: []Identifier{{Kind: KindOrgNumber, Value: "556123-4567"}},
+		},
+		{
+			name: "bankgiro is not an org number",
+			text: "Bankgiro 5561-2345",
+			want: []Identifier{{Kind: KindBankgiro, Value: "5561-2345"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Extract(tt.text).Identifiers
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("identifiers of %q = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+		ok   bool
+	}{
+		{"95.37", 95.37, true},
+		{"95,37", 95.37, true},
+		{"1 096,77", 1096.77, true},
+		{"1.096,77", 1096.77, true},
+		{"1,096.77", 1096.77, true},
+		{"1,096", 1096, true},
+		{"12,5", 12.5, true},
+		{"100", 100, true},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseNumber(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("parseNumber(%q) = %v, %v, want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
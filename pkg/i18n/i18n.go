@@ -0,0 +1,140 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/locale"
+)
+
+// DefaultLanguage is used when no language is given and as fallback for missing messages
+const DefaultLanguage = "en"
+
+//go:embed catalogs/*.json
+var catalogFiles embed.FS
+
+// Translator looks up messages in the catalogue of one language
+type Translator struct {
+	lang     string
+	messages map[string]string
+	fallback map[string]string
+}
+
+// New returns a Translator for a language such as "en", "sv" or "sv-SE"
+// An empty language returns the default language.
+func New(lang string) (*Translator, error) {
+	code := baseLanguage(lang)
+	if code == "" {
+		code = DefaultLanguage
+	}
+
+	messages, err := loadCatalog(code)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported language: %s (supported: %s)", lang, strings.Join(Languages(), ", "))
+	}
+
+	fallback := messages
+	if code != DefaultLanguage {
+		if fallback, err = loadCatalog(DefaultLanguage); err != nil {
+			return nil, err
+		}
+	}
+
+	return &Translator{lang: code, messages: messages, fallback: fallback}, nil
+}
+
+// Lang returns the language code of the translator (e.g. "sv")
+func (t *Translator) Lang() string {
+	return t.lang
+}
+
+// T returns the message for key, formatted with args if any are given
+// Missing messages fall back to English and finally to the key itself.
+func (t *Translator) T(key string, args ...interface{}) string {
+	message, ok := t.messages[key]
+	if !ok {
+		message, ok = t.fallback[key]
+	}
+	if !ok {
+		message = key
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+// DocumentType translates a DocumentType enum value (e.g. "Receipt" becomes "Kvitto" in Swedish)
+// Unknown values are returned unchanged.
+func (t *Translator) DocumentType(value string) string {
+	return t.enum("doctype.", value)
+}
+
+// BillingInterval translates a billing interval (e.g. "monthly" becomes "Månadsvis" in Swedish)
+// Unknown values are returned unchanged.
+func (t *Translator) BillingInterval(value string) string {
+	return t.enum("interval.", value)
+}
+
+// enum translates the value of an enum field by its key prefix, unknown values are returned unchanged
+func (t *Translator) enum(prefix, value string) string {
+	key := prefix + value
+	if message, ok := t.messages[key]; ok {
+		return message
+	}
+	if message, ok := t.fallback[key]; ok {
+		return message
+	}
+	return value
+}
+
+// Locale returns the default number and date locale for the language
+func (t *Translator) Locale() locale.Locale {
+	loc, err := locale.Lookup(t.lang)
+	if err != nil {
+		return locale.English
+	}
+	return loc
+}
+
+// Languages returns the codes of all embedded catalogues
+func Languages() []string {
+	entries, err := catalogFiles.ReadDir("catalogs")
+	if err != nil {
+		return nil
+	}
+
+	var languages []string
+	for _, entry := range entries {
+		languages = append(languages, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// loadCatalog reads the embedded catalogue of a language code
+func loadCatalog(code string) (map[string]string, error) {
+	content, err := catalogFiles.ReadFile(path.Join("catalogs", code+".json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var messages map[string]string
+	if err := json.Unmarshal(content, &messages); err != nil {
+		return nil, fmt.Errorf("invalid catalogue %s: %w", code, err)
+	}
+	return messages, nil
+}
+
+// baseLanguage reduces a tag such as "sv-SE" or "sv_SE" to "sv"
+func baseLanguage(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(tag, "-_"); i >= 0 {
+		tag = tag[:i]
+	}
+	return tag
+}
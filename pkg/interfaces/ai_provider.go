@@ -6,6 +6,232 @@ type AIProvider interface {
 	GetReceiptInvoiceInfo(content string) (*ReceiptInvoiceInfo, error)
 }
 
+// CorrectingAIProvider is an AIProvider that can be asked to correct an earlier extraction
+type CorrectingAIProvider interface {
+	AIProvider
+
+	// CorrectReceiptInvoiceInfo extracts the information again, given a previous extraction and the problems found in it
+	CorrectReceiptInvoiceInfo(content string, previous *ReceiptInvoiceInfo, problems []string) (*ReceiptInvoiceInfo, error)
+}
+
+// Sources of extracted information
+const (
+	// SourceAI means the information was extracted from text by an AIProvider
+	SourceAI = "ai"
+	
+	// SourceStructured means the information was parsed deterministically from a structured e-invoice
+	SourceStructured = "structured"
+)
+
+// Document types of ReceiptInvoiceInfo.DocumentType
+const (
+	// DocumentTypeNone is any document that does not belong to another type
+	DocumentTypeNone = "None"
+	
+	// DocumentTypeInvoice requests payment for goods or services
+	DocumentTypeInvoice = "Invoice"
+	
+	// DocumentTypeReceipt confirms a payment that was made
+	DocumentTypeReceipt = "Receipt"
+	
+	// DocumentTypeCreditNote reduces or cancels an earlier invoice, its amounts are negative
+	DocumentTypeCreditNote = "CreditNote"
+	
+	// DocumentTypePaymentReminder reminds of an unpaid invoice, it is not booked
+	DocumentTypePaymentReminder = "PaymentReminder"
+	
+	// DocumentTypeOrderConfirmation confirms an order before it is invoiced, it is not booked
+	DocumentTypeOrderConfirmation = "OrderConfirmation"
+	
+	// DocumentTypeQuote offers goods or services at a price, it is not booked
+	DocumentTypeQuote = "Quote"
+	
+	// DocumentTypeAccountStatement lists the transactions or balance of an account, it is not booked
+	DocumentTypeAccountStatement = "AccountStatement"
+	
+	// DocumentTypeSubscriptionRenewal announces the renewal of a subscription, it is not booked
+	DocumentTypeSubscriptionRenewal = "SubscriptionRenewal"
+)
+
+// Billing intervals of ReceiptInvoiceInfo.BillingInterval
+const (
+	BillingIntervalMonthly    = "monthly"
+	BillingIntervalQuarterly  = "quarterly"
+	BillingIntervalSemiannual = "semiannual"
+	BillingIntervalYearly     = "yearly"
+)
+
+// IdFieldReferencedInvoice is the ID field of a credit note or payment reminder holding the number
+// of the invoice it refers to
+const IdFieldReferencedInvoice = "Referenced Invoice Number"
+
+// Confidence levels of extracted fields
+const (
+	// ConfidenceHigh means the value is written in the document
+	ConfidenceHigh = "high"
+
+	// ConfidenceMedium means the value is derived or interpreted from the document
+	ConfidenceMedium = "medium"
+
+	// ConfidenceLow means the value is guessed or its evidence was not found in the document
+	ConfidenceLow = "low"
+)
+
+// FieldEvidence tells how sure the provider is about one extracted field and where it was found
+type FieldEvidence struct {
+	// Field is the JSON name of the field (e.g. "company", "original_amount"), or "id:" followed by the ID field name
+	Field string `json:"field" jsonschema_description:"The JSON name of the extracted field (e.g. 'company', 'date_issued', 'original_amount', 'original_currency'), or 'id:' followed by the ID field name (e.g. 'id:Invoice Number')"`
+	
+	// Confidence is ConfidenceHigh, ConfidenceMedium or ConfidenceLow
+	Confidence string `json:"confidence" jsonschema:"enum=high,enum=medium,enum=low" jsonschema_description:"high: the value is written in the document, medium: the value is derived or interpreted (e.g. converted to SEK), low: the value is guessed"`
+	
+	// Snippet is the verbatim text of the document the value was taken from
+	Snippet string `json:"snippet" jsonschema_description:"The verbatim text from the document the value was taken from, copied character by character including the label (e.g. 'Total €95.37'), empty if the value is not written in the document"`
+	
+	// Verified is true if the snippet was found in the source text (populated post-processing)
+	Verified bool `json:"verified" jsonschema:"-"`
+	
+	// Note explains why the confidence was downgraded (populated post-processing)
+	Note string `json:"note,omitempty" jsonschema:"-"`
+}
+
+// Cross-check statuses of a field compared with rule-based pre-extraction
+const (
+	// CheckAgree means the value was also found by the rules
+	CheckAgree = "agree"
+
+	// CheckConflict means the rules found values of the field, but not this one
+	CheckConflict = "conflict"
+
+	// CheckAIOnly means the rules found no value of the field to compare with
+	CheckAIOnly = "ai_only"
+
+	// CheckRulesOnly means the rules found values of a field the provider left empty
+	CheckRulesOnly = "rules_only"
+)
+
+// FieldCheck compares one extracted field with the values found by rule-based pre-extraction
+type FieldCheck struct {
+	// Field is the JSON name of the field, or "id:" followed by the ID field name
+	Field string `json:"field"`
+	
+	// Status is CheckAgree, CheckConflict, CheckAIOnly or CheckRulesOnly
+	Status string `json:"status"`
+	
+	// Value is the extracted value, empty for CheckRulesOnly
+	Value string `json:"value,omitempty"`
+	
+	// Found are the values found by the rules
+	Found []string `json:"found,omitempty"`
+}
+
+// Verification records the extractions made when a result was sent back to the AI provider for correction
+type Verification struct {
+	// Rounds are the extractions in the order they were made, the first is the original extraction
+	Rounds []VerificationRound `json:"rounds"`
+	
+	// Selected is the index of the round whose result is used, the one with the fewest problems
+	Selected int `json:"selected"`
+	
+	// Error is the error of a correction request that failed and ended the rounds early
+	Error string `json:"error,omitempty"`
+}
+
+// VerificationRound is one extraction with the problems found in it
+type VerificationRound struct {
+	// Problems are the problems found in Result, sent to the provider in the next round
+	Problems []string `json:"problems"`
+	
+	// Result is the extraction of this round
+	Result *ReceiptInvoiceInfo `json:"result"`
+}
+
+// EnsembleResult records how the results of several AI backends were merged
+type EnsembleResult struct {
+	// Backends are the names of the backends that returned a result
+	Backends []string `json:"backends"`
+	
+	// Failed are the names of the backends that returned an error
+	Failed []string `json:"failed,omitempty"`
+	
+	// Votes are the votes per field, in schema order followed by the ID fields
+	Votes []FieldVote `json:"votes"`
+	
+	// Disagreement is the share of voted fields the backends did not agree on unanimously
+	Disagreement float64 `json:"disagreement"`
+	
+	// NeedsReview is true if Disagreement exceeds the configured threshold or a field had no majority
+	NeedsReview bool `json:"needs_review"`
+}
+
+// FieldVote is the vote of the ensemble backends on one field
+type FieldVote struct {
+	// Field is the JSON name of the field, or "id:" followed by the ID field name
+	Field string `json:"field"`
+	
+	// Value is the value that won the vote, empty if it was null
+	Value string `json:"value"`
+	
+	// Votes are the values of the backends by backend name, empty for null
+	Votes map[string]string `json:"votes"`
+	
+	// Unanimous is true if all backends returned the same value
+	Unanimous bool `json:"unanimous"`
+	
+	// Majority is true if more than half of the backends returned Value
+	Majority bool `json:"majority"`
+}
+
+// PromptInfo identifies the prompt an extraction was made with
+type PromptInfo struct {
+	// Version is the version of the prompt, e.g. "accountant-v1"
+	Version string `json:"version"`
+	
+	// Hash is the SHA-256 of the prompt file, it changes with every edit of the wording
+	Hash string `json:"hash"`
+	
+	// Examples are the names of the few-shot examples included in the prompt
+	Examples []string `json:"examples,omitempty"`
+}
+
+// How a document was matched to a vendor, see VendorMatch.MatchedBy
+const (
+	MatchedByOrgNumber = "org_number"
+	MatchedByName      = "name"
+	MatchedByAlias     = "alias"
+)
+
+// VendorMatch records the vendor of the registry a document was matched to
+type VendorMatch struct {
+	// Name is the registered name of the vendor
+	Name string `json:"name"`
+	
+	// MatchedBy is MatchedByOrgNumber, MatchedByName or MatchedByAlias
+	MatchedBy string `json:"matched_by"`
+	
+	// Account is the BAS expense account of the vendor, 0 if the category mapping applies
+	Account int `json:"account,omitempty"`
+	
+	// VatTreatment is the VAT treatment of the vendor (domestic, foreign, reverse_charge, none),
+	// empty if the treatment follows from the currency
+	VatTreatment string `json:"vat_treatment,omitempty"`
+	
+	// Changes are the fields the registry normalised or filled in
+	Changes []FieldChange `json:"changes,omitempty"`
+}
+
+// FieldChange is a field changed by post-processing
+type FieldChange struct {
+	// Field is the JSON name of the field
+	Field string `json:"field"`
+	
+	// From is the extracted value, empty if the field was null
+	From string `json:"from"`
+	
+	// To is the new value
+	To string `json:"to"`
+}
+
 // IdField represents an identification field found in the document
 type IdField struct {
 	// Name is the type/name of the identifier (e.g., "Invoice Number", "Receipt Number", "Customer ID")
@@ -18,10 +244,10 @@ type IdField struct {
 // ReceiptInvoiceInfo represents the structured information extracted from a receipt or invoice
 type ReceiptInvoiceInfo struct {
 	// DocumentType is always present and classifies the document
-	DocumentType string `json:"document_type" jsonschema:"enum=None,enum=Invoice,enum=Receipt" jsonschema_description:"Classification of the document as None, Invoice, or Receipt"`
+	DocumentType string `json:"document_type" jsonschema:"enum=None,enum=Invoice,enum=Receipt,enum=CreditNote,enum=PaymentReminder,enum=OrderConfirmation,enum=Quote,enum=AccountStatement,enum=SubscriptionRenewal" jsonschema_description:"Classification of the document as None, Invoice, Receipt, CreditNote, PaymentReminder, OrderConfirmation, Quote, AccountStatement or SubscriptionRenewal"`
 	
 	// Description is a mandatory accountant-friendly categorization of the document/service
-	Description string `json:"description" jsonschema:"maxLength=50" jsonschema_description:"Mandatory accountant-friendly description: for None documents describe what it's about, for Invoice/Receipt provide generic service category (e.g., 'AI Services', 'Cloud Services'). Max 50 characters."`
+	Description string `json:"description" jsonschema:"maxLength=50" jsonschema_description:"Mandatory accountant-friendly description: for None documents describe what it's about, for all other types provide generic service category (e.g., 'AI Services', 'Cloud Services'). Max 50 characters."`
 	
 	// Company is the entity offering the service and requesting payment (nullable)
 	Company *string `json:"company" jsonschema_description:"The company that owns the service being offered and is requesting payment, null if not found"`
@@ -29,27 +255,68 @@ type ReceiptInvoiceInfo struct {
 	// DateIssued is the date the invoice/receipt was issued (nullable)
 	DateIssued *string `json:"date_issued" jsonschema_description:"The date the document was issued in YYYY-MM-DD format, null if not found"`
 	
+	// PeriodStart is the first day of the service period the document pays for (nullable)
+	PeriodStart *string `json:"period_start" jsonschema_description:"The first day of the service or subscription period the document covers in YYYY-MM-DD format (e.g. 2025-08-02 for 'Aug 2 – Sep 2, 2025'), null if no period is stated"`
+	
+	// PeriodEnd is the last day of the service period the document pays for (nullable)
+	PeriodEnd *string `json:"period_end" jsonschema_description:"The last day of the service or subscription period the document covers in YYYY-MM-DD format (e.g. 2025-09-02 for 'Aug 2 – Sep 2, 2025'), null if no period is stated"`
+	
+	// Recurring tells whether the document charges a subscription that renews automatically (nullable)
+	Recurring *bool `json:"recurring" jsonschema_description:"true if the document charges a subscription or other recurring service that renews automatically, false for a one-off purchase, null if unclear"`
+	
+	// BillingInterval is how often a recurring charge is made (nullable)
+	BillingInterval *string `json:"billing_interval" jsonschema_description:"How often the recurring charge is made: monthly, quarterly, semiannual or yearly, null if not recurring or unknown"`
+	
 	// ServiceDescription is a description of the service or items paid for (nullable)
 	ServiceDescription *string `json:"service_description" jsonschema_description:"Description of the service or items paid for, null if not found"`
 	
 	// SECentAmount is the amount in Swedish cents (nullable)
 	// Last 2 digits are öre (cents), rest is kronor
 	// For example: 9537 = 95.37 SEK
-	SECentAmount *int `json:"se_cent_amount" jsonschema_description:"Amount in Swedish cents (öre), where last 2 digits are cents and rest is kronor, null if not found"`
+	SECentAmount *int `json:"se_cent_amount" jsonschema_description:"Amount in Swedish cents (öre), where last 2 digits are cents and rest is kronor, negative for credit notes, null if not found"`
 	
 	// OriginalAmount is the total amount in the original currency (nullable)
-	OriginalAmount *float64 `json:"original_amount" jsonschema_description:"The total amount in the original currency, null if not found"`
+	OriginalAmount *float64 `json:"original_amount" jsonschema_description:"The total amount in the original currency, negative for credit notes, null if not found"`
 	
 	// OriginalCurrency is the ISO 3-letter currency code (nullable)
 	OriginalCurrency *string `json:"original_currency" jsonschema_description:"The ISO 3-letter currency code (e.g., 'EUR', 'USD', 'SEK'), null if not found"`
 	
 	// OriginalVatAmount is the VAT amount in the original currency (nullable)
-	OriginalVatAmount *float64 `json:"original_vat_amount" jsonschema_description:"The VAT/tax amount in the original currency, null if not found"`
+	OriginalVatAmount *float64 `json:"original_vat_amount" jsonschema_description:"The VAT/tax amount in the original currency, negative for credit notes, null if not found"`
 	
 	// IdFields is a list of identification fields found in the document
-	IdFields []IdField `json:"id_fields" jsonschema_description:"List of identification fields found in the document (invoice numbers, receipt numbers, customer IDs, etc.). Can be empty."`
+	IdFields []IdField `json:"id_fields" jsonschema_description:"List of identification fields found in the document (invoice numbers, receipt numbers, customer IDs, etc.). A credit note or payment reminder names the invoice it refers to as 'Referenced Invoice Number'. Can be empty."`
+	
+	// Evidence holds the confidence and source snippet of each extracted field
+	// It is only present for information extracted by an AIProvider.
+	Evidence []FieldEvidence `json:"evidence,omitempty" jsonschema:"required" jsonschema_description:"One entry for every extracted field that is not null or empty, except document_type and description, and one entry per ID field"`
+	
+	// Extra holds the user-defined fields of the configuration by name, the values are strings,
+	// numbers, booleans or nil; its schema is added at runtime
+	Extra map[string]interface{} `json:"extra,omitempty" jsonschema:"-"`
+	
+	// CrossChecks compare the extracted fields with rule-based pre-extraction (populated post-processing)
+	CrossChecks []FieldCheck `json:"cross_checks,omitempty" jsonschema:"-"`
+	
+	// Verification records the rounds of self-verification, if problems were found (populated post-processing)
+	Verification *Verification `json:"verification,omitempty" jsonschema:"-"`
+	
+	// Prompt identifies the prompt used by the AI provider (populated post-processing)
+	Prompt *PromptInfo `json:"prompt,omitempty" jsonschema:"-"`
+	
+	// Ensemble records the field votes when several AI backends were used (populated post-processing)
+	Ensemble *EnsembleResult `json:"ensemble,omitempty" jsonschema:"-"`
+	
+	// Vendor is the vendor of the registry the document was matched to (populated post-processing)
+	Vendor *VendorMatch `json:"vendor,omitempty" jsonschema:"-"`
 	
 	// SuggestedFileName is a generated filename based on extracted data (populated post-processing)
-	// Format: <date>-<company>-<description>-<amount>SEK (lowercase, non-alphanumeric chars become _)
+	// The naming pattern is configurable, the default is <date>-<company>-<description>-<amount>sek
 	SuggestedFileName string `json:"suggested_filename" jsonschema:"-"`
+	
+	// SourceFile is the path of the document the information was extracted from (populated post-processing)
+	SourceFile string `json:"source_file,omitempty" jsonschema:"-"`
+	
+	// Source tells how the information was obtained, SourceAI or SourceStructured (populated post-processing)
+	Source string `json:"source,omitempty" jsonschema:"-"`
 }
\ No newline at end of file
@@ -0,0 +1,116 @@
+package locale
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale describes how numbers are written for a given language/region
+type Locale struct {
+	// Tag is the canonical locale tag (e.g. "en", "sv-SE")
+	Tag string
+
+	// DecimalSeparator separates the integer part from the fraction
+	DecimalSeparator string
+
+	// ThousandsSeparator groups the integer part in blocks of three digits
+	ThousandsSeparator string
+
+	// ListSeparator is the CSV delimiter spreadsheet programs expect for this locale
+	ListSeparator rune
+
+	// DateLayout is the Go time layout for dates written for humans
+	DateLayout string
+}
+
+// English is the default locale: dot decimals, comma thousands, comma-separated lists
+var English = Locale{
+	Tag:                "en",
+	DecimalSeparator:   ".",
+	ThousandsSeparator: ",",
+	ListSeparator:      ',',
+	DateLayout:         "2 Jan 2006",
+}
+
+// Swedish uses comma decimals and (non-breaking) space thousands, and Swedish Excel
+// expects semicolon-separated CSV files
+var Swedish = Locale{
+	Tag:                "sv-SE",
+	DecimalSeparator:   ",",
+	ThousandsSeparator: " ",
+	ListSeparator:      ';',
+	DateLayout:         "2006-01-02",
+}
+
+// Lookup returns the locale for a tag such as "en", "en-US", "sv" or "sv-SE"
+// An empty tag returns English.
+func Lookup(tag string) (Locale, error) {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	switch {
+	case normalized == "":
+		return English, nil
+	case normalized == "en" || strings.HasPrefix(normalized, "en-"):
+		return English, nil
+	case normalized == "sv" || strings.HasPrefix(normalized, "sv-"):
+		return Swedish, nil
+	}
+	return Locale{}, fmt.Errorf("unsupported locale: %s (supported: en, sv-SE)", tag)
+}
+
+// FormatDecimal formats f with the given number of decimals
+// If grouping is true, the integer part is split into groups of three digits.
+func (l Locale) FormatDecimal(f float64, decimals int, grouping bool) string {
+	s := strconv.FormatFloat(math.Abs(f), 'f', decimals, 64)
+
+	intPart, fracPart, _ := strings.Cut(s, ".")
+	if grouping {
+		intPart = GroupDigits(intPart, l.ThousandsSeparator)
+	}
+
+	result := intPart
+	if fracPart != "" {
+		result += l.DecimalSeparator + fracPart
+	}
+	if f < 0 && strings.Trim(s, "0.") != "" {
+		result = "-" + result
+	}
+	return result
+}
+
+// FormatCents formats an amount in cents (öre) as a decimal with two fraction digits
+func (l Locale) FormatCents(cents int, grouping bool) string {
+	return l.FormatDecimal(float64(cents)/100.0, 2, grouping)
+}
+
+// FormatDate formats t with the locale's date layout
+// Swedish uses ISO 8601 dates (SS-ISO 8601), English a short day-month-year form.
+func (l Locale) FormatDate(t time.Time) string {
+	layout := l.DateLayout
+	if layout == "" {
+		layout = "2006-01-02"
+	}
+	return t.Format(layout)
+}
+
+// GroupDigits inserts sep between every group of three digits counted from the right
+func GroupDigits(digits string, sep string) string {
+	if len(digits) <= 3 {
+		return digits
+	}
+
+	var b strings.Builder
+	lead := len(digits) % 3
+	if lead > 0 {
+		b.WriteString(digits[:lead])
+	}
+	for i := lead; i < len(digits); i += 3 {
+		if b.Len() > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
@@ -2,6 +2,8 @@ package logger
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"time"
 
 	"github.com/fatih/color"
@@ -10,6 +12,7 @@ import (
 
 // ColorLogger implements the Logger interface with colored output
 type ColorLogger struct {
+	out        io.Writer
 	infoColor  *color.Color
 	errorColor *color.Color
 	warnColor  *color.Color
@@ -17,9 +20,15 @@ type ColorLogger struct {
 	timeColor  *color.Color
 }
 
-// NewColorLogger creates a new ColorLogger instance
+// NewColorLogger creates a new ColorLogger instance writing to stdout
 func NewColorLogger() interfaces.Logger {
+	return NewColorLoggerTo(os.Stdout)
+}
+
+// NewColorLoggerTo creates a new ColorLogger instance writing to out
+func NewColorLoggerTo(out io.Writer) interfaces.Logger {
 	return &ColorLogger{
+		out:        out,
 		infoColor:  color.New(color.FgGreen),
 		errorColor: color.New(color.FgRed),
 		warnColor:  color.New(color.FgYellow),
@@ -33,7 +42,7 @@ func (l *ColorLogger) formatMessage(level string, levelColor *color.Color, msg s
 	timestamp := l.timeColor.Sprintf("[%s]", time.Now().Format("2006-01-02 15:04:05"))
 	levelStr := levelColor.Sprintf("[%s]", level)
 	message := fmt.Sprintf(msg, args...)
-	fmt.Printf("%s %s %s\n", timestamp, levelStr, message)
+	fmt.Fprintf(l.out, "%s %s %s\n", timestamp, levelStr, message)
 }
 
 // Info logs an info message
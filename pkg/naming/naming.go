@@ -0,0 +1,171 @@
+package naming
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// DefaultPattern gives names like "2025-08-02-anthropic-ai_services-1097sek"
+const DefaultPattern = "{date}-{company}-{description}-{sek:%dsek}"
+
+// DefaultMaxLength keeps names well below the 255 byte limit of common file systems,
+// leaving room for directories, collision suffixes and extensions
+const DefaultMaxLength = 100
+
+// DefaultMissing replaces fields without a value
+const DefaultMissing = "unknown"
+
+// DefaultCompanySuffixes are the legal forms removed from the end of company names
+var DefaultCompanySuffixes = []string{
+	"AB", "AB (publ)", "HB", "KB", "Inc", "Incorporated", "PBC", "Ltd", "Limited",
+	"LLC", "LLP", "GmbH", "Corp", "Corporation", "Co", "plc", "AS", "ASA", "ApS", "Oy", "BV", "SA",
+}
+
+// Options configure a Namer, zero values select the defaults
+type Options struct {
+	// Pattern is the naming pattern, see DefaultPattern
+	Pattern string
+
+	// MaxLength is the maximum length of a name in characters
+	MaxLength int
+
+	// Missing replaces fields without a value
+	Missing string
+
+	// CompanySuffixes are removed from the end of the company name, nil selects
+	// DefaultCompanySuffixes and an empty list keeps company names as they are
+	CompanySuffixes []string
+}
+
+// Namer generates filesystem-safe file names from extracted documents
+type Namer struct {
+	pattern   *Pattern
+	maxLength int
+	missing   string
+	suffixes  *regexp.Regexp
+}
+
+var (
+	unsafeRun    = regexp.MustCompile(`[^a-z0-9_-]+`)
+	separatorRun = regexp.MustCompile(`[-_]{2,}`)
+)
+
+// transliterations spell accented letters in ASCII (å, ä and ö become a, a and o)
+var transliterations = strings.NewReplacer(
+	"å", "a", "ä", "a", "à", "a", "á", "a", "â", "a", "ã", "a",
+	"ö", "o", "ø", "o", "ò", "o", "ó", "o", "ô", "o", "õ", "o",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"ü", "u", "ù", "u", "ú", "u", "û", "u",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ç", "c", "ñ", "n", "ý", "y", "ÿ", "y",
+	"æ", "ae", "œ", "oe", "ß", "ss", "&", "and",
+)
+
+// New returns a Namer, or an error if the pattern is invalid
+func New(opts Options) (*Namer, error) {
+	if opts.Pattern == "" {
+		opts.Pattern = DefaultPattern
+	}
+	if opts.MaxLength <= 0 {
+		opts.MaxLength = DefaultMaxLength
+	}
+	if opts.Missing == "" {
+		opts.Missing = DefaultMissing
+	}
+	if opts.CompanySuffixes == nil {
+		opts.CompanySuffixes = DefaultCompanySuffixes
+	}
+
+	pattern, err := Parse(opts.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Namer{
+		pattern:   pattern,
+		maxLength: opts.MaxLength,
+		missing:   opts.Missing,
+		suffixes:  suffixPattern(opts.CompanySuffixes),
+	}, nil
+}
+
+// Name returns the file name (without extension) for a document
+func (n *Namer) Name(info *interfaces.ReceiptInvoiceInfo) string {
+	if info.Company != nil && n.suffixes != nil {
+		copied := *info
+		company := stripCompanySuffixes(*info.Company, n.suffixes)
+		copied.Company = &company
+		info = &copied
+	}
+
+	name := Clean(n.pattern.Execute(info, Clean, n.missing))
+	if runes := []rune(name); len(runes) > n.maxLength {
+		name = strings.TrimRight(string(runes[:n.maxLength]), "-_")
+	}
+	if name == "" {
+		return n.missing
+	}
+	return name
+}
+
+// Clean makes s a lowercase, ASCII-only file name part
+// Letters are transliterated, other characters become "_", and runs of separators are
+// collapsed to one ("-" wins over "_"). Separators at the ends are removed.
+func Clean(s string) string {
+	s = transliterations.Replace(strings.ToLower(s))
+	s = unsafeRun.ReplaceAllString(s, "_")
+	s = separatorRun.ReplaceAllStringFunc(s, func(run string) string {
+		if strings.Contains(run, "-") {
+			return "-"
+		}
+		return "_"
+	})
+	return strings.Trim(s, "-_")
+}
+
+// StripCompanySuffixes removes the given legal forms from the end of a company name,
+// nil selects DefaultCompanySuffixes
+func StripCompanySuffixes(company string, suffixes []string) string {
+	if suffixes == nil {
+		suffixes = DefaultCompanySuffixes
+	}
+	pattern := suffixPattern(suffixes)
+	if pattern == nil {
+		return company
+	}
+	return stripCompanySuffixes(company, pattern)
+}
+
+// stripCompanySuffixes removes legal forms such as "AB" or ", Inc." from the end of a
+// company name, repeatedly, as long as something is left
+func stripCompanySuffixes(company string, suffixes *regexp.Regexp) string {
+	for {
+		stripped := strings.TrimSpace(suffixes.ReplaceAllString(company, ""))
+		stripped = strings.TrimRight(stripped, " ,")
+		if stripped == company || stripped == "" {
+			return company
+		}
+		company = stripped
+	}
+}
+
+// suffixPattern compiles the suffix list into one pattern anchored at the end of the name
+// Each suffix matches case-insensitively with or without a trailing dot.
+func suffixPattern(suffixes []string) *regexp.Regexp {
+	if len(suffixes) == 0 {
+		return nil
+	}
+	alternatives := make([]string, 0, len(suffixes))
+	for _, suffix := range suffixes {
+		suffix = strings.TrimSuffix(strings.TrimSpace(suffix), ".")
+		if suffix != "" {
+			alternatives = append(alternatives, regexp.QuoteMeta(suffix))
+		}
+	}
+	if len(alternatives) == 0 {
+		return nil
+	}
+	return regexp.MustCompile(`(?i)[\s,]+(?:` + strings.Join(alternatives, "|") + `)\.?\s*$`)
+}
@@ -0,0 +1,126 @@
+package naming
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+func str(s string) *string { return &s }
+
+func num(f float64) *float64 { return &f }
+
+func cents(c int) *int { return &c }
+
+// receipt returns the extraction of a 95.37 EUR receipt
+func receipt() *interfaces.ReceiptInvoiceInfo {
+	return &interfaces.ReceiptInvoiceInfo{
+		DocumentType:     interfaces.DocumentTypeReceipt,
+		Description:      "AI Services",
+		Company:          str("Anthropic, PBC"),
+		DateIssued:       str("2025-08-02"),
+		OriginalAmount:   num(95.37),
+		OriginalCurrency: str("EUR"),
+		SECentAmount:     cents(109677),
+		IdFields:         []interfaces.IdField{{Name: "Receipt Number", Value: "2831-4417"}},
+		Extra:            map[string]interface{}{"project_code": "P 7"},
+	}
+}
+
+func TestName(t *testing.T) {
+	tests := []struct {
+		name   string
+		opts   Options
+		modify func(info *interfaces.ReceiptInvoiceInfo)
+		want   string
+	}{
+		{"default pattern", Options{}, nil, "2025-08-02-anthropic-ai_services-1097sek"},
+		{"date parts and currency", Options{Pattern: "{year}/{month}/{day}_{original_amount}{original_currency}"}, nil, "2025_08_02_95_37eur"},
+		{"date layout", Options{Pattern: "{date:Jan 2006}"}, nil, "aug_2025"},
+		{"text length", Options{Pattern: "{company:4}-{description:2}"}, nil, "anth-ai"},
+		{"ID and extra fields", Options{Pattern: "{id:receipt number}-{extra:project_code}"}, nil, "2831-4417-p_7"},
+		{"missing values", Options{Pattern: "{date}-{company}-{id:Invoice Number}", Missing: "na"}, func(info *interfaces.ReceiptInvoiceInfo) {
+			info.DateIssued = nil
+		}, "na-anthropic-na"},
+		{"company suffixes kept", Options{Pattern: "{company}", CompanySuffixes: []string{}}, nil, "anthropic_pbc"},
+		{"repeated company suffixes", Options{Pattern: "{company}"}, func(info *interfaces.ReceiptInvoiceInfo) {
+			info.Company = str("Acme Holding AB (publ), Inc.")
+		}, "acme_holding"},
+		{"suffix is the whole name", Options{Pattern: "{company}"}, func(info *interfaces.ReceiptInvoiceInfo) {
+			info.Company = str("Ltd")
+		}, "ltd"},
+		{"swedish letters", Options{Pattern: "{company}"}, func(info *interfaces.ReceiptInvoiceInfo) {
+			info.Company = str("Åkerö Städ & Söner AB")
+		}, "akero_stad_and_soner"},
+		{"credit note amount", Options{Pattern: "{sek:%dsek}_{original_amount:%.0f}"}, func(info *interfaces.ReceiptInvoiceInfo) {
+			info.SECentAmount, info.OriginalAmount = cents(-109677), num(-95.37)
+		}, "minus1097sek_minus95"},
+		{"maximum length", Options{MaxLength: 16}, nil, "2025-08-02-anthr"},
+		{"cut at a separator", Options{MaxLength: 11}, nil, "2025-08-02"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			namer, err := New(tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			info := receipt()
+			if tt.modify != nil {
+				tt.modify(info)
+			}
+			if got := namer.Name(info); got != tt.want {
+				t.Errorf("Name = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    string
+	}{
+		{"{amount}", "unknown field {amount}"},
+		{"{company:short}", "maximum length"},
+		{"{id:Invoice Number:0}", "maximum length"},
+		{"{sek:%.2f}", "one printf verb"},
+		{"{original_amount:%d}", "one printf verb"},
+		{"{sek:%d-%d}", "one printf verb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			_, err := Parse(tt.pattern)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %v, want %q", tt.pattern, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePassthrough(t *testing.T) {
+	pattern, err := Parse("{year}/{company}{ext}", "ext")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := pattern.Execute(receipt(), Clean, DefaultMissing); got != "2025/anthropic_pbc{ext}" {
+		t.Errorf("Execute = %q", got)
+	}
+}
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"AI Services", "ai_services"},
+		{"  --Cloud / Hosting--  ", "cloud_hosting"},
+		{"a_-_b", "a-b"},
+		{"Café Ære", "cafe_aere"},
+		{"日本", ""},
+	}
+	for _, tt := range tests {
+		if got := Clean(tt.in); got != tt.want {
+			t.Errorf("Clean(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
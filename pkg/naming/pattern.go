@@ -0,0 +1,296 @@
+package naming
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/extra"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// IdFieldPrefix selects an ID field by name, e.g. {id:Invoice Number}
+const IdFieldPrefix = "id:"
+
+// ExtraFieldPrefix selects a user-defined field by name, e.g. {extra:project_code}
+const ExtraFieldPrefix = extra.Prefix
+
+// fieldKind decides what the optional format after the colon means
+type fieldKind int
+
+const (
+	// kindText formats are a maximum length in characters, e.g. {company:20}
+	kindText fieldKind = iota
+
+	// kindDate formats are Go time layouts, e.g. {date:2006-01}
+	kindDate
+
+	// kindNumber formats are printf verbs, e.g. {sek:%dsek} or {original_amount:%.0f}
+	kindNumber
+)
+
+// field describes a pattern field and how its value is read from a document
+// The value of a kindNumber field is an int, or a float64 if float is set.
+type field struct {
+	kind          fieldKind
+	defaultFormat string
+	float         bool
+	value         func(info *interfaces.ReceiptInvoiceInfo) interface{}
+}
+
+// negativePrefix replaces the minus sign of negative amounts, which file name cleaning removes
+const negativePrefix = "minus"
+
+// printfVerb matches a printf verb with its flags, width and precision
+var printfVerb = regexp.MustCompile(`%[-+# 0]*[0-9]*(?:\.[0-9]*)?[a-zA-Z%]`)
+
+// fields are the fields available in patterns, named like the JSON fields
+var fields = map[string]field{
+	"document_type": {kind: kindText, value: func(info *interfaces.ReceiptInvoiceInfo) interface{} {
+		return info.DocumentType
+	}},
+	"description": {kind: kindText, value: func(info *interfaces.ReceiptInvoiceInfo) interface{} {
+		return info.Description
+	}},
+	"company": {kind: kindText, value: func(info *interfaces.ReceiptInvoiceInfo) interface{} {
+		return document.StringValue(info.Company)
+	}},
+	"service_description": {kind: kindText, value: func(info *interfaces.ReceiptInvoiceInfo) interface{} {
+		return document.StringValue(info.ServiceDescription)
+	}},
+	"original_currency": {kind: kindText, value: func(info *interfaces.ReceiptInvoiceInfo) interface{} {
+		return document.StringValue(info.OriginalCurrency)
+	}},
+	"suggested_filename": {kind: kindText, value: func(info *interfaces.ReceiptInvoiceInfo) interface{} {
+		return info.SuggestedFileName
+	}},
+	"source": {kind: kindText, value: func(info *interfaces.ReceiptInvoiceInfo) interface{} {
+		return info.Source
+	}},
+	"date_issued": {kind: kindDate, defaultFormat: document.DateLayout, value: dateValue},
+	"date":        {kind: kindDate, defaultFormat: document.DateLayout, value: dateValue},
+	"year":        {kind: kindDate, defaultFormat: "2006", value: dateValue},
+	"month":       {kind: kindDate, defaultFormat: "01", value: dateValue},
+	"day":         {kind: kindDate, defaultFormat: "02", value: dateValue},
+	"se_cent_amount": {kind: kindNumber, defaultFormat: "%d", value: func(info *interfaces.ReceiptInvoiceInfo) interface{} {
+		if info.SECentAmount == nil {
+			return nil
+		}
+		return *info.SECentAmount
+	}},
+	"sek": {kind: kindNumber, defaultFormat: "%d", value: func(info *interfaces.ReceiptInvoiceInfo) interface{} {
+		if info.SECentAmount == nil {
+			return nil
+		}
+		// Rounded to whole kronor
+		return int(math.Round(float64(*info.SECentAmount) / 100))
+	}},
+	"original_amount": {kind: kindNumber, defaultFormat: "%.2f", float: true, value: func(info *interfaces.ReceiptInvoiceInfo) interface{} {
+		if info.OriginalAmount == nil {
+			return nil
+		}
+		return *info.OriginalAmount
+	}},
+	"original_vat_amount": {kind: kindNumber, defaultFormat: "%.2f", float: true, value: func(info *interfaces.ReceiptInvoiceInfo) interface{} {
+		if info.OriginalVatAmount == nil {
+			return nil
+		}
+		return *info.OriginalVatAmount
+	}},
+}
+
+// placeholderPattern matches {name} and {name:format}, ID field names may contain spaces
+var placeholderPattern = regexp.MustCompile(`\{(id:[^}:]+|extra:[a-z0-9_]+|[a-z_]+)(?::([^}]*))?\}`)
+
+// Pattern is a parsed naming pattern such as "{date}-{company}-{sek:%dsek}"
+type Pattern struct {
+	parts []part
+}
+
+// part is literal text or a placeholder
+type part struct {
+	literal string
+	name    string
+	format  string
+}
+
+// Parse parses a pattern
+// Placeholders named in passthrough are not document fields, they are kept as "{name}" in
+// the output for the caller to fill in (organize uses this for {ext}).
+func Parse(pattern string, passthrough ...string) (*Pattern, error) {
+	p := &Pattern{}
+	pos := 0
+	for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(pattern, -1) {
+		if loc[0] > pos {
+			p.parts = append(p.parts, part{literal: pattern[pos:loc[0]]})
+		}
+		pos = loc[1]
+
+		name := pattern[loc[2]:loc[3]]
+		format := ""
+		if loc[4] >= 0 {
+			format = pattern[loc[4]:loc[5]]
+		}
+
+		if contains(passthrough, name) {
+			p.parts = append(p.parts, part{literal: pattern[loc[0]:loc[1]]})
+			continue
+		}
+		if strings.HasPrefix(name, IdFieldPrefix) || strings.HasPrefix(name, ExtraFieldPrefix) {
+			if _, err := lengthFormat(format); err != nil {
+				return nil, err
+			}
+			p.parts = append(p.parts, part{name: name, format: format})
+			continue
+		}
+
+		f, ok := fields[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field {%s} in pattern (available: %s, id:<name>, extra:<name>)", name, strings.Join(Fields(passthrough...), ", "))
+		}
+		if f.kind == kindText {
+			if _, err := lengthFormat(format); err != nil {
+				return nil, err
+			}
+		}
+		if f.kind == kindNumber && format != "" {
+			if err := checkNumberFormat(name, format, f.float); err != nil {
+				return nil, err
+			}
+		}
+		p.parts = append(p.parts, part{name: name, format: format})
+	}
+	if pos < len(pattern) {
+		p.parts = append(p.parts, part{literal: pattern[pos:]})
+	}
+	return p, nil
+}
+
+// Fields returns the names of all fields, sorted, including extra names
+func Fields(extra ...string) []string {
+	names := append([]string{}, extra...)
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Execute fills in the pattern
+// Each value is passed through clean, empty values are replaced by missing before cleaning.
+func (p *Pattern) Execute(info *interfaces.ReceiptInvoiceInfo, clean func(string) string, missing string) string {
+	var b strings.Builder
+	for _, pt := range p.parts {
+		if pt.name == "" {
+			b.WriteString(pt.literal)
+			continue
+		}
+		value := p.value(info, pt)
+		if strings.TrimSpace(value) == "" {
+			value = missing
+		}
+		b.WriteString(clean(value))
+	}
+	return b.String()
+}
+
+// value returns the formatted value of a placeholder, "" if it is missing
+func (p *Pattern) value(info *interfaces.ReceiptInvoiceInfo, pt part) string {
+	if strings.HasPrefix(pt.name, IdFieldPrefix) {
+		return truncate(document.IdFieldValue(info, strings.TrimPrefix(pt.name, IdFieldPrefix)), pt.format)
+	}
+	if strings.HasPrefix(pt.name, ExtraFieldPrefix) {
+		return truncate(extra.Value(info, strings.TrimPrefix(pt.name, ExtraFieldPrefix)), pt.format)
+	}
+
+	f := fields[pt.name]
+	raw := f.value(info)
+	if raw == nil {
+		return ""
+	}
+	format := pt.format
+	if format == "" {
+		format = f.defaultFormat
+	}
+
+	switch f.kind {
+	case kindDate:
+		return raw.(time.Time).Format(format)
+	case kindNumber:
+		// Credit notes have negative amounts, the sign must survive cleaning to keep their names
+		// apart from invoices of the same amount
+		formatted := fmt.Sprintf(format, raw)
+		if number := strings.TrimLeft(formatted, " "); strings.HasPrefix(number, "-") {
+			return negativePrefix + strings.TrimPrefix(number, "-")
+		}
+		return formatted
+	}
+	return truncate(fmt.Sprint(raw), format)
+}
+
+// checkNumberFormat reports a format that is not exactly one printf verb for the type of a
+// number field, e.g. %d for an amount in float64 that would produce "%!d(float64=95.37)"
+func checkNumberFormat(name, format string, float bool) error {
+	var sample interface{} = 0
+	example := "%d"
+	if float {
+		sample, example = 0.0, "%.2f or %g"
+	}
+
+	verbs := 0
+	for _, verb := range printfVerb.FindAllString(format, -1) {
+		if verb != "%%" {
+			verbs++
+		}
+	}
+	if verbs != 1 || strings.Contains(fmt.Sprintf(format, sample), "%!") {
+		return fmt.Errorf("format of {%s} must contain one printf verb for a %T such as %s, got %q", name, sample, example, format)
+	}
+	return nil
+}
+
+// dateValue returns the issue date of a document, nil if it is missing
+func dateValue(info *interfaces.ReceiptInvoiceInfo) interface{} {
+	t, ok := document.ParseDate(info)
+	if !ok {
+		return nil
+	}
+	return t
+}
+
+// lengthFormat parses the maximum length of a text field, 0 for no limit
+func lengthFormat(format string) (int, error) {
+	if format == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(format)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("format of a text field must be a maximum length, got %q", format)
+	}
+	return n, nil
+}
+
+// truncate shortens s to the length given as format, if any
+func truncate(s string, format string) string {
+	n, _ := lengthFormat(format)
+	s = strings.TrimSpace(s)
+	if runes := []rune(s); n > 0 && len(runes) > n {
+		return strings.TrimSpace(string(runes[:n]))
+	}
+	return s
+}
+
+// contains reports whether list contains s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,174 @@
+package organize
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// Apply carries out the operations of a plan in order
+// Each completed operation is written to undoLog as one JSON line, so an interrupted run
+// can be undone as far as it got. Existing files are never overwritten.
+func Apply(plan *Plan, undoLog io.Writer) error {
+	encoder := json.NewEncoder(undoLog)
+	for _, op := range plan.Operations {
+		if _, err := os.Lstat(op.To); err == nil {
+			return fmt.Errorf("target already exists: %s", op.To)
+		}
+		if err := os.MkdirAll(filepath.Dir(op.To), 0755); err != nil {
+			return fmt.Errorf("failed to create directory for %s: %w", op.To, err)
+		}
+
+		var err error
+		if op.Action == ActionCopy {
+			err = copyFile(op.From, op.To)
+		} else {
+			err = moveFile(op.From, op.To)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to %s %s to %s: %w", op.Action, op.From, op.To, err)
+		}
+
+		if op.SourceFile != "" {
+			if err := setSourceFile(op.To, op.SourceFile); err != nil {
+				return fmt.Errorf("failed to update source_file in %s: %w", op.To, err)
+			}
+		}
+
+		if err := encoder.Encode(op); err != nil {
+			return fmt.Errorf("failed to write undo log: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReadUndoLog reads the operations written by Apply
+func ReadUndoLog(r io.Reader) ([]Operation, error) {
+	var ops []Operation
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var op Operation
+		if err := json.Unmarshal([]byte(text), &op); err != nil {
+			return nil, fmt.Errorf("invalid undo log line %d: %w", line, err)
+		}
+		ops = append(ops, op)
+	}
+	return ops, scanner.Err()
+}
+
+// Undo reverts operations in reverse order: moved files are moved back and copies are removed
+// Operations that cannot be reverted safely (the file is gone, or its old path is taken
+// again) are skipped with a warning. It returns the number of reverted operations.
+func Undo(ops []Operation, log interfaces.Logger) (int, error) {
+	reverted := 0
+	for i := len(ops) - 1; i >= 0; i-- {
+		op := ops[i]
+		if _, err := os.Lstat(op.To); err != nil {
+			log.Warn("Skipping %s, file no longer exists", op.To)
+			continue
+		}
+
+		if op.Action == ActionCopy {
+			if err := os.Remove(op.To); err != nil {
+				return reverted, fmt.Errorf("failed to remove %s: %w", op.To, err)
+			}
+		} else {
+			if _, err := os.Lstat(op.From); err == nil {
+				log.Warn("Skipping %s, %s exists again", op.To, op.From)
+				continue
+			}
+			if err := os.MkdirAll(filepath.Dir(op.From), 0755); err != nil {
+				return reverted, fmt.Errorf("failed to create directory for %s: %w", op.From, err)
+			}
+			if err := moveFile(op.To, op.From); err != nil {
+				return reverted, fmt.Errorf("failed to move %s back to %s: %w", op.To, op.From, err)
+			}
+			if op.SourceFile != "" {
+				if err := setSourceFile(op.From, op.PreviousSourceFile); err != nil {
+					return reverted, fmt.Errorf("failed to restore source_file in %s: %w", op.From, err)
+				}
+			}
+		}
+
+		log.Debug("Reverted %s %s -> %s", op.Action, op.From, op.To)
+		removeEmptyDirs(filepath.Dir(op.To))
+		reverted++
+	}
+	return reverted, nil
+}
+
+// moveFile renames from to to, falling back to copy and remove across file systems
+func moveFile(from, to string) error {
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	if err := copyFile(from, to); err != nil {
+		return err
+	}
+	return os.Remove(from)
+}
+
+// copyFile copies the content and permissions of from to a new file
+func copyFile(from, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(to)
+		return err
+	}
+	return out.Close()
+}
+
+// setSourceFile rewrites the source_file field of an extracted JSON document
+func setSourceFile(path string, sourceFile string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var info interfaces.ReceiptInvoiceInfo
+	if err := json.Unmarshal(content, &info); err != nil {
+		return err
+	}
+	info.SourceFile = sourceFile
+
+	content, err = json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// removeEmptyDirs removes dir and its parents as long as they are empty
+func removeEmptyDirs(dir string) {
+	for dir != "." && dir != string(filepath.Separator) {
+		if err := os.Remove(dir); err != nil {
+			return
+		}
+		dir = filepath.Dir(dir)
+	}
+}
@@ -0,0 +1,279 @@
+package organize
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/naming"
+)
+
+// DefaultPattern places documents in one folder per month, named by their suggested filename
+const DefaultPattern = "{year}/{month}/{suggested_filename}.{ext}"
+
+// maxSuffix is the highest collision suffix recognised on files that are already organised
+const maxSuffix = 1000
+
+// Unknown replaces fields without a value, e.g. {year} of a document without date
+const Unknown = "unknown"
+
+// Actions of an operation
+const (
+	ActionMove = "move"
+	ActionCopy = "copy"
+)
+
+// SiblingExtensions are the outputs of other commands that are kept together with the
+// JSON file, they share its base name (receipt.json, receipt.html, receipt.pdf)
+var SiblingExtensions = []string{".html", ".pdf"}
+
+// unsafeCharacters are not allowed in file names on common file systems
+var unsafeCharacters = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]+`)
+
+// Options control how documents are organised
+type Options struct {
+	// Destination is the root directory of the pattern
+	Destination string
+
+	// Pattern is the path of each file below Destination, see DefaultPattern
+	Pattern string
+
+	// Copy copies the files instead of moving them
+	Copy bool
+}
+
+// Operation moves or copies one file, it is also the line format of the undo log
+type Operation struct {
+	Action string `json:"action"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+
+	// SourceFile is written to source_file of a JSON document so it still finds its
+	// moved source, PreviousSourceFile is the value it had before
+	SourceFile         string `json:"source_file,omitempty"`
+	PreviousSourceFile string `json:"previous_source_file,omitempty"`
+}
+
+// Plan is the list of operations for a set of documents
+type Plan struct {
+	Operations []Operation
+
+	// InPlace are files that already are at their target path
+	InPlace []string
+
+	// MissingSources are documents whose source file could not be found, only the JSON
+	// file and its siblings are organised for them
+	MissingSources []string
+}
+
+// ValidatePattern checks that a pattern only uses known fields and contains {ext},
+// which keeps the files of a document apart
+func ValidatePattern(pattern string) error {
+	_, err := parsePattern(pattern)
+	return err
+}
+
+// parsePattern parses a pattern with the fields of the naming package and {ext}
+func parsePattern(pattern string) (*naming.Pattern, error) {
+	if !strings.Contains(pattern, "{ext}") {
+		return nil, fmt.Errorf("pattern %q must contain {ext}", pattern)
+	}
+	if filepath.IsAbs(pattern) {
+		return nil, fmt.Errorf("pattern %q must be relative to the destination", pattern)
+	}
+	return naming.Parse(pattern, "ext")
+}
+
+// NewPlan computes where the files of each document go
+// Documents are handled in the given order. When a target is taken by an earlier document
+// or an existing file, "-2", "-3", ... is appended to the name of all files of the document,
+// so the result only depends on the input and the destination.
+func NewPlan(docs []document.Document, opts Options) (*Plan, error) {
+	pattern, err := parsePattern(opts.Pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	action := ActionMove
+	if opts.Copy {
+		action = ActionCopy
+	}
+
+	destination, err := filepath.Abs(opts.Destination)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{}
+	planned := make(map[string]bool)    // targets and sources handled so far
+	targetOf := make(map[string]string) // target of each source handled so far
+	for _, doc := range docs {
+		type file struct {
+			path string
+			ext  string
+		}
+
+		var files []file
+		source := document.SourcePath(doc)
+		sourceTarget := targetOf[filepath.Clean(source)]
+		if source != "" && sourceTarget == "" {
+			if _, err := os.Stat(source); err == nil {
+				files = append(files, file{source, filepath.Ext(source)})
+			} else {
+				plan.MissingSources = append(plan.MissingSources, doc.Path)
+				source = ""
+			}
+		}
+		files = append(files, file{doc.Path, filepath.Ext(doc.Path)})
+		stem := strings.TrimSuffix(doc.Path, filepath.Ext(doc.Path))
+		for _, ext := range SiblingExtensions {
+			// A sibling with the extension of the source would get the same target, it stays
+			if strings.EqualFold(ext, filepath.Ext(source)) {
+				continue
+			}
+			if _, err := os.Stat(stem + ext); err == nil {
+				files = append(files, file{stem + ext, ext})
+			}
+		}
+
+		base := pattern.Execute(doc.Info, sanitize, Unknown)
+		target := func(n int, ext string) string {
+			return filepath.Join(destination, withExt(base, n, ext))
+		}
+
+		// Files that already match the pattern stay where they are, so organising an
+		// organised tree again changes nothing. The suffix of an organised JSON file is
+		// tried first for the files that still have to move.
+		first := 1
+		var pending []file
+		for _, f := range files {
+			n := suffixInPlace(f.path, func(n int) string { return target(n, f.ext) })
+			if n == 0 {
+				pending = append(pending, f)
+				continue
+			}
+			if f.path == doc.Path {
+				first = n
+			}
+			if f.path == source {
+				sourceTarget = target(n, f.ext)
+				targetOf[filepath.Clean(source)] = sourceTarget
+			}
+			planned[filepath.Clean(f.path)] = true
+			plan.InPlace = append(plan.InPlace, f.path)
+		}
+		if len(pending) == 0 {
+			continue
+		}
+
+		var targets []string
+		for n := first; ; n++ {
+			targets = targets[:0]
+			free := true
+			for _, f := range pending {
+				t := target(n, f.ext)
+				targets = append(targets, t)
+				if planned[filepath.Clean(t)] || occupied(t, f.path) {
+					free = false
+				}
+			}
+			if free {
+				break
+			}
+		}
+
+		for i, f := range pending {
+			if f.path == source {
+				// A source shared by several documents is only organised with the first one
+				sourceTarget = targets[i]
+				targetOf[filepath.Clean(source)] = targets[i]
+			}
+			planned[filepath.Clean(f.path)] = true
+			planned[filepath.Clean(targets[i])] = true
+		}
+
+		for i, f := range pending {
+			// Absolute paths keep the undo log usable from any working directory
+			from, err := filepath.Abs(f.path)
+			if err != nil {
+				return nil, err
+			}
+			op := Operation{Action: action, From: from, To: targets[i]}
+			if f.path == doc.Path && source != "" {
+				if rel, err := filepath.Rel(filepath.Dir(op.To), sourceTarget); err == nil && rel != doc.Info.SourceFile {
+					op.SourceFile = rel
+					op.PreviousSourceFile = doc.Info.SourceFile
+				}
+			}
+			plan.Operations = append(plan.Operations, op)
+		}
+	}
+
+	return plan, nil
+}
+
+// withExt fills in {ext} and, for n > 1, appends "-n" to the file name before the extension
+func withExt(base string, n int, ext string) string {
+	ext = strings.TrimPrefix(ext, ".")
+	if n > 1 {
+		suffix := "-" + strconv.Itoa(n)
+		if strings.Contains(base, ".{ext}") {
+			base = strings.Replace(base, ".{ext}", suffix+".{ext}", 1)
+		} else {
+			base = strings.Replace(base, "{ext}", suffix+"{ext}", 1)
+		}
+	}
+	if ext == "" {
+		base = strings.ReplaceAll(base, ".{ext}", "")
+	}
+	return filepath.FromSlash(strings.ReplaceAll(base, "{ext}", ext))
+}
+
+// sanitize makes a value safe as a single path component
+func sanitize(value string) string {
+	value = unsafeCharacters.ReplaceAllString(strings.TrimSpace(value), "_")
+	value = strings.Trim(value, ". ")
+	if value == "" {
+		return Unknown
+	}
+	return value
+}
+
+// suffixInPlace returns the suffix number n for which path already is the target, 0 if none
+func suffixInPlace(path string, target func(n int) string) int {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return 0
+	}
+	for n := 1; n <= maxSuffix; n++ {
+		if target(n) == abs {
+			return n
+		}
+	}
+	return 0
+}
+
+// occupied reports whether target exists and is not the file at path
+func occupied(target string, path string) bool {
+	if _, err := os.Lstat(target); err != nil {
+		return false
+	}
+	return !sameFile(path, target)
+}
+
+// sameFile reports whether both paths refer to the same existing file
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(a)
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(b)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
@@ -0,0 +1,178 @@
+package organize
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/logger"
+)
+
+func TestValidatePattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		valid   bool
+	}{
+		{DefaultPattern, true},
+		{"{company}/{date}-{id:Invoice Number}.{ext}", true},
+		{"{year}/{suggested_filename}", false},
+		{"/archive/{year}/{suggested_filename}.{ext}", false},
+		{"{year}/{amount}.{ext}", false},
+	}
+	for _, tt := range tests {
+		if err := ValidatePattern(tt.pattern); (err == nil) != tt.valid {
+			t.Errorf("ValidatePattern(%q) = %v, want valid %v", tt.pattern, err, tt.valid)
+		}
+	}
+}
+
+func TestWithExt(t *testing.T) {
+	tests := []struct {
+		base string
+		n    int
+		ext  string
+		want string
+	}{
+		{"2025/08/receipt.{ext}", 1, ".pdf", "2025/08/receipt.pdf"},
+		{"2025/08/receipt.{ext}", 2, ".json", "2025/08/receipt-2.json"},
+		{"2025/08/receipt.{ext}", 1, "", "2025/08/receipt"},
+	}
+	for _, tt := range tests {
+		if got := withExt(tt.base, tt.n, tt.ext); got != filepath.FromSlash(tt.want) {
+			t.Errorf("withExt(%q, %d, %q) = %q, want %q", tt.base, tt.n, tt.ext, got, tt.want)
+		}
+	}
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Anthropic, PBC", "Anthropic, PBC"},
+		{"a/b:c", "a_b_c"},
+		{" ..hidden. ", "hidden"},
+		{"..", Unknown},
+	}
+	for _, tt := range tests {
+		if got := sanitize(tt.in); got != tt.want {
+			t.Errorf("sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// writeDocument writes an extracted document as JSON and returns it
+func writeDocument(t *testing.T, path string, info *interfaces.ReceiptInvoiceInfo) document.Document {
+	t.Helper()
+	content, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return document.Document{Path: path, Info: info}
+}
+
+func TestPlanApplyUndo(t *testing.T) {
+	dir := t.TempDir()
+	in, out := filepath.Join(dir, "in"), filepath.Join(dir, "out")
+	if err := os.Mkdir(in, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.pdf", "a.html"} {
+		if err := os.WriteFile(filepath.Join(in, name), []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	date := "2025-08-02"
+	docs := []document.Document{
+		writeDocument(t, filepath.Join(in, "a.json"), &interfaces.ReceiptInvoiceInfo{DateIssued: &date, SuggestedFileName: "anthropic", SourceFile: "a.pdf"}),
+		writeDocument(t, filepath.Join(in, "b.json"), &interfaces.ReceiptInvoiceInfo{DateIssued: &date, SuggestedFileName: "anthropic", SourceFile: "b.pdf"}),
+	}
+	opts := Options{Destination: out, Pattern: DefaultPattern}
+
+	plan, err := NewPlan(docs, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := filepath.Join(out, "2025", "08")
+	want := []Operation{
+		{Action: ActionMove, From: filepath.Join(in, "a.pdf"), To: filepath.Join(target, "anthropic.pdf")},
+		{Action: ActionMove, From: filepath.Join(in, "a.json"), To: filepath.Join(target, "anthropic.json"), SourceFile: "anthropic.pdf", PreviousSourceFile: "a.pdf"},
+		{Action: ActionMove, From: filepath.Join(in, "a.html"), To: filepath.Join(target, "anthropic.html")},
+		{Action: ActionMove, From: filepath.Join(in, "b.json"), To: filepath.Join(target, "anthropic-2.json")},
+	}
+	if !reflect.DeepEqual(plan.Operations, want) {
+		t.Fatalf("operations\n%+v\nwant\n%+v", plan.Operations, want)
+	}
+	if wantMissing := []string{filepath.Join(in, "b.json")}; !reflect.DeepEqual(plan.MissingSources, wantMissing) {
+		t.Errorf("missing sources = %v, want %v", plan.MissingSources, wantMissing)
+	}
+
+	var undoLog bytes.Buffer
+	if err := Apply(plan, &undoLog); err != nil {
+		t.Fatal(err)
+	}
+	moved, err := document.Load(filepath.Join(target, "anthropic.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved.Info.SourceFile != "anthropic.pdf" {
+		t.Errorf("source_file = %q, want anthropic.pdf", moved.Info.SourceFile)
+	}
+
+	// Organising the organised files again changes nothing
+	again, err := document.LoadAll([]string{out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	replan, err := NewPlan(again, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(replan.Operations) != 0 || len(replan.InPlace) != 4 {
+		t.Errorf("second plan has operations %+v and %d file(s) in place, want none and 4", replan.Operations, len(replan.InPlace))
+	}
+
+	ops, err := ReadUndoLog(&undoLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reverted, err := Undo(ops, logger.NewColorLoggerTo(io.Discard))
+	if err != nil || reverted != len(want) {
+		t.Fatalf("Undo = %d, %v, want %d", reverted, err, len(want))
+	}
+	restored, err := document.Load(filepath.Join(in, "a.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Info.SourceFile != "a.pdf" {
+		t.Errorf("restored source_file = %q, want a.pdf", restored.Info.SourceFile)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("empty destination directories were not removed: %v", err)
+	}
+}
+
+func TestApplyNeverOverwrites(t *testing.T) {
+	dir := t.TempDir()
+	from, to := filepath.Join(dir, "a.pdf"), filepath.Join(dir, "b.pdf")
+	for _, path := range []string{from, to} {
+		if err := os.WriteFile(path, []byte(path), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	plan := &Plan{Operations: []Operation{{Action: ActionMove, From: from, To: to}}}
+	if err := Apply(plan, io.Discard); err == nil {
+		t.Error("expected an error for an existing target")
+	}
+	if content, _ := os.ReadFile(to); string(content) != to {
+		t.Errorf("target was overwritten")
+	}
+}
@@ -0,0 +1,160 @@
+package pdf
+
+// Font is one of the standard Type 1 fonts every PDF reader provides, so no font
+// files have to be embedded
+type Font int
+
+// Supported standard fonts
+const (
+	Helvetica Font = iota
+	HelveticaBold
+	HelveticaOblique
+	Courier
+)
+
+// baseFonts are the PDF BaseFont names, indexed by Font
+var baseFonts = []string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Courier"}
+
+// resourceName returns the name the font is registered under in page resources
+func (f Font) resourceName() string {
+	return []string{"F1", "F2", "F3", "F4"}[f]
+}
+
+// Glyph widths in 1/1000 em from the Adobe Font Metrics of the standard fonts,
+// for WinAnsi codes 32-126 and 160-255
+var (
+	helveticaASCII = [...]int{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278, // space - /
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, // 0-9
+		278, 278, 584, 584, 584, 556, 1015, // : - @
+		667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, // A-M
+		722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, // N-Z
+		278, 278, 278, 469, 556, 333, // [ - `
+		556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, // a-m
+		556, 556, 556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, // n-z
+		334, 260, 334, 584, // { - ~
+	}
+	helveticaLatin1 = [...]int{
+		278, 333, 556, 556, 556, 556, 260, 556, 333, 737, 370, 556, 584, 333, 737, 333, // nbsp - ¯
+		400, 584, 333, 333, 333, 556, 537, 278, 333, 333, 365, 556, 834, 834, 834, 611, // ° - ¿
+		667, 667, 667, 667, 667, 667, 1000, 722, 667, 667, 667, 667, 278, 278, 278, 278, // À - Ï
+		722, 722, 778, 778, 778, 778, 778, 584, 778, 722, 722, 722, 722, 667, 667, 611, // Ð - ß
+		556, 556, 556, 556, 556, 556, 889, 500, 556, 556, 556, 556, 278, 278, 278, 278, // à - ï
+		556, 556, 556, 556, 556, 556, 556, 584, 611, 556, 556, 556, 556, 500, 556, 500, // ð - ÿ
+	}
+	helveticaBoldASCII = [...]int{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556,
+		333, 333, 584, 584, 584, 611, 975,
+		722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833,
+		722, 778, 667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611,
+		333, 278, 333, 584, 556, 333,
+		556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889,
+		611, 611, 611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500,
+		389, 280, 389, 584,
+	}
+	helveticaBoldLatin1 = [...]int{
+		278, 333, 556, 556, 556, 556, 280, 556, 333, 737, 370, 556, 584, 333, 737, 333,
+		400, 584, 333, 333, 333, 611, 556, 278, 333, 333, 365, 556, 834, 834, 834, 611,
+		722, 722, 722, 722, 722, 722, 1000, 722, 667, 667, 667, 667, 278, 278, 278, 278,
+		722, 722, 778, 778, 778, 778, 778, 584, 778, 722, 722, 722, 722, 667, 667, 611,
+		556, 556, 556, 556, 556, 556, 889, 556, 556, 556, 556, 556, 278, 278, 278, 278,
+		611, 611, 611, 611, 611, 611, 611, 584, 611, 611, 611, 611, 611, 556, 611, 556,
+	}
+)
+
+// winAnsiSpecials maps the characters of WinAnsi codes 128-159 to their codes and
+// Helvetica / Helvetica-Bold widths
+var winAnsiSpecials = map[rune]struct {
+	code        byte
+	width, bold int
+}{
+	'€': {0x80, 556, 556},
+	'‚': {0x82, 222, 278},
+	'„': {0x84, 333, 500},
+	'…': {0x85, 1000, 1000},
+	'‘': {0x91, 222, 278},
+	'’': {0x92, 222, 278},
+	'“': {0x93, 333, 500},
+	'”': {0x94, 333, 500},
+	'•': {0x95, 350, 350},
+	'–': {0x96, 556, 556},
+	'—': {0x97, 1000, 1000},
+	'™': {0x99, 1000, 1000},
+}
+
+// winAnsiFallbacks are look-alikes for common characters outside WinAnsi
+var winAnsiFallbacks = map[rune]rune{
+	'\t':     ' ',
+	'\u2009': ' ',      // thin space
+	'\u202f': '\u00a0', // narrow no-break space
+	'\u2010': '-',      // hyphen
+	'\u2011': '-',      // non-breaking hyphen
+	'\u2212': '-',      // minus sign
+	'\u2032': '\'',     // prime
+}
+
+// encodeWinAnsi converts a string to WinAnsiEncoding, unsupported characters become '?'
+func encodeWinAnsi(s string) []byte {
+	out := make([]byte, 0, len(s))
+	for _, r := range s {
+		if fallback, ok := winAnsiFallbacks[r]; ok {
+			r = fallback
+		}
+		switch {
+		case r >= 32 && r <= 126, r >= 160 && r <= 255:
+			out = append(out, byte(r))
+		default:
+			if special, ok := winAnsiSpecials[r]; ok {
+				out = append(out, special.code)
+			} else {
+				out = append(out, '?')
+			}
+		}
+	}
+	return out
+}
+
+// glyphWidth returns the width of r in 1/1000 em
+func glyphWidth(font Font, r rune) int {
+	if font == Courier {
+		return 600
+	}
+	bold := font == HelveticaBold
+	if fallback, ok := winAnsiFallbacks[r]; ok {
+		r = fallback
+	}
+
+	switch {
+	case r >= 32 && r <= 126:
+	case r >= 160 && r <= 255:
+	default:
+		if special, ok := winAnsiSpecials[r]; ok {
+			if bold {
+				return special.bold
+			}
+			return special.width
+		}
+		r = '?'
+	}
+
+	if r <= 126 {
+		if bold {
+			return helveticaBoldASCII[r-32]
+		}
+		return helveticaASCII[r-32]
+	}
+	if bold {
+		return helveticaBoldLatin1[r-160]
+	}
+	return helveticaLatin1[r-160]
+}
+
+// TextWidth returns the width of s in points when set in font at size
+func TextWidth(font Font, size float64, s string) float64 {
+	total := 0
+	for _, r := range s {
+		total += glyphWidth(font, r)
+	}
+	return float64(total) * size / 1000
+}
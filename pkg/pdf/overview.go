@@ -0,0 +1,330 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/i18n"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/locale"
+)
+
+// Page layout of the overview in points
+const (
+	margin        = 42.0
+	contentWidth  = PageWidth - 2*margin
+	footerTop     = PageHeight - margin - 20
+	labelWidth    = 110.0
+	bodySize      = 10.0
+	lineHeight    = 14.0
+	sourceSize    = 7.5
+	sourceLeading = 9.5
+)
+
+// Overview is the information rendered by RenderOverview, the PDF counterpart of the
+// HTML overview
+type Overview struct {
+	Info        *interfaces.ReceiptInvoiceInfo
+	Translator  *i18n.Translator
+	Locale      locale.Locale
+	ProcessedAt string
+
+	// Source is the embedded source document, nil to leave it out
+	Source *Source
+}
+
+// Source is the original text with the extracted values marked
+type Source struct {
+	Path string
+
+	// Segments cover the source text, segments with a Label are highlighted
+	Segments []SourceSegment
+
+	// Found and Missing are the labels of extracted values found and not found in the source
+	Found   []string
+	Missing []string
+}
+
+// SourceSegment is a piece of the source text, Label names the extracted value it matched
+type SourceSegment struct {
+	Text  string
+	Label string
+}
+
+// overviewLayout tracks the current page and vertical position while rendering
+type overviewLayout struct {
+	doc  *Document
+	page *Page
+	y    float64
+}
+
+// RenderOverview writes the overview of an extracted document as an A4 PDF
+func RenderOverview(w io.Writer, o Overview) error {
+	tr := o.Translator
+	info := o.Info
+
+	l := &overviewLayout{doc: New(tr.T("overview.title"))}
+	l.newPage()
+
+	// Header
+	l.page.Text(margin, l.y+20, HelveticaBold, 20, Black, tr.T("overview.title"))
+	subtitle := tr.T("overview.subtitle")
+	if company := document.StringValue(info.Company); company != "" {
+		subtitle = company
+	}
+	l.page.Text(margin, l.y+38, Helvetica, 11, Gray, subtitle)
+	l.y += 48
+	l.page.Line(margin, l.y, margin+contentWidth, l.y, 2, Black)
+	l.y += 20
+
+	// Document information
+	l.sectionTitle(tr.T("overview.section.document"))
+	docType := tr.DocumentType(info.DocumentType)
+	if !document.Bookable(info) {
+		docType += " (" + tr.T("label.not_booked") + ")"
+	}
+	l.infoRow(tr.T("label.type"), docType)
+	if invoice := document.ReferencedInvoice(info); invoice != "" {
+		l.infoRow(tr.T("label.referenced_invoice"), invoice)
+	}
+	l.infoRow(tr.T("label.description"), info.Description)
+	if company := document.StringValue(info.Company); company != "" {
+		l.infoRow(tr.T("label.company"), company)
+	}
+	if date, ok := document.ParseDate(info); ok {
+		l.infoRow(tr.T("label.date_issued"), o.Locale.FormatDate(date))
+	} else if info.DateIssued != nil && *info.DateIssued != "" {
+		l.infoRow(tr.T("label.date_issued"), *info.DateIssued)
+	}
+	if start, end, ok := document.ParsePeriod(info); ok {
+		l.infoRow(tr.T("label.period"), o.Locale.FormatDate(start)+" – "+o.Locale.FormatDate(end))
+	} else if period := document.PeriodString(info); period != "" {
+		l.infoRow(tr.T("label.period"), period)
+	}
+	if info.Recurring != nil {
+		recurring := tr.T("recurring.no")
+		if *info.Recurring {
+			recurring = tr.T("recurring.yes")
+			if interval := document.BillingInterval(info); interval != "" {
+				recurring += ", " + tr.BillingInterval(interval)
+			}
+		}
+		l.infoRow(tr.T("label.recurring"), recurring)
+	}
+	if info.ServiceDescription != nil && *info.ServiceDescription != "" {
+		l.infoRow(tr.T("label.service"), *info.ServiceDescription)
+	}
+	l.y += 12
+
+	// Financial information
+	if info.SECentAmount != nil || info.OriginalAmount != nil || info.OriginalVatAmount != nil {
+		l.sectionTitle(tr.T("overview.section.financial"))
+		l.amountBoxes(o)
+		l.y += 12
+	}
+
+	// Identification fields
+	l.sectionTitle(tr.T("overview.section.ids"))
+	if len(info.IdFields) > 0 {
+		l.idTable(tr, info.IdFields)
+	} else {
+		l.infoRow(tr.T("label.status"), tr.T("overview.no_ids"))
+	}
+	l.y += 12
+
+	if o.Source != nil {
+		l.source(tr, o.Source)
+	}
+
+	// Footers are drawn last, when the number of pages is known
+	pages := l.doc.Pages()
+	for i, page := range pages {
+		page.Line(margin, footerTop, margin+contentWidth, footerTop, 1, Black)
+		page.Text(margin, footerTop+14, Helvetica, 8, Gray, tr.T("footer.generated"))
+		page.TextCenter(PageWidth/2, footerTop+14, Helvetica, 8, Gray, fmt.Sprintf("%d / %d", i+1, len(pages)))
+		page.TextRight(margin+contentWidth, footerTop+14, Helvetica, 8, Gray, tr.T("footer.processed")+": "+o.ProcessedAt)
+	}
+
+	return l.doc.Write(w)
+}
+
+// newPage starts a new page at the top margin
+func (l *overviewLayout) newPage() {
+	l.page = l.doc.AddPage()
+	l.y = margin
+}
+
+// ensure starts a new page unless height points fit above the footer
+func (l *overviewLayout) ensure(height float64) {
+	if l.y+height > footerTop-10 {
+		l.newPage()
+	}
+}
+
+// sectionTitle draws an underlined section title, kept together with the first line after it
+func (l *overviewLayout) sectionTitle(title string) {
+	l.ensure(24 + 2*lineHeight)
+	l.page.Text(margin, l.y+12, HelveticaBold, 12, Black, title)
+	l.y += 17
+	l.page.Line(margin, l.y, margin+contentWidth, l.y, 1, Black)
+	l.y += 8
+}
+
+// infoRow draws a bold label and a value wrapped next to it
+func (l *overviewLayout) infoRow(label, value string) {
+	lines := WrapText(Helvetica, bodySize, value, contentWidth-labelWidth)
+	for i, line := range lines {
+		l.ensure(lineHeight)
+		if i == 0 {
+			l.page.Text(margin, l.y+bodySize, HelveticaBold, bodySize, Black, label+":")
+		}
+		l.page.Text(margin+labelWidth, l.y+bodySize, Helvetica, bodySize, Black, line)
+		l.y += lineHeight
+	}
+}
+
+// amountBoxes draws the SEK, original and VAT amounts side by side
+func (l *overviewLayout) amountBoxes(o Overview) {
+	const gap, height = 12.0, 58.0
+	tr, info := o.Translator, o.Info
+	currency := document.StringValue(info.OriginalCurrency)
+
+	type box struct{ label, value, note string }
+	boxes := []box{
+		{label: tr.T("label.sek"), value: "—"},
+		{label: tr.T("label.original_amount"), value: "—"},
+		{label: tr.T("label.vat"), value: "—"},
+	}
+	if info.SECentAmount != nil {
+		boxes[0].value = o.Locale.FormatDecimal(float64(*info.SECentAmount)/100, 2, true) + " SEK"
+		boxes[0].note = fmt.Sprintf("(%d %s)", *info.SECentAmount, tr.T("label.ore"))
+	}
+	if info.OriginalAmount != nil {
+		boxes[1].label = tr.T("label.original")
+		if currency != "" {
+			boxes[1].label = currency
+		}
+		boxes[1].value = money(o.Locale, *info.OriginalAmount, currency)
+	}
+	if info.OriginalVatAmount != nil {
+		boxes[2].value = money(o.Locale, *info.OriginalVatAmount, currency)
+	}
+
+	l.ensure(height)
+	width := (contentWidth - 2*gap) / 3
+	for i, b := range boxes {
+		x := margin + float64(i)*(width+gap)
+		center := x + width/2
+		l.page.Rect(x, l.y, width, height, 1, Black)
+		l.page.TextCenter(center, l.y+16, Helvetica, 8, Gray, strings.ToUpper(b.label))
+		l.page.TextCenter(center, l.y+35, HelveticaBold, 14, Black, b.value)
+		if b.note != "" {
+			l.page.TextCenter(center, l.y+49, Helvetica, 8, Gray, b.note)
+		}
+	}
+	l.y += height
+}
+
+// idTable draws the identification fields as a two-column table with a grey header row
+func (l *overviewLayout) idTable(tr *i18n.Translator, fields []interfaces.IdField) {
+	const padding = 5.0
+	nameWidth := contentWidth * 0.35
+	valueX := margin + nameWidth
+
+	header := func() {
+		l.page.FillRect(margin, l.y, contentWidth, lineHeight+2*padding-4, LightGray)
+		l.page.Rect(margin, l.y, contentWidth, lineHeight+2*padding-4, 0.5, Black)
+		l.page.Text(margin+padding, l.y+bodySize+padding-1, HelveticaBold, bodySize, Black, tr.T("label.field_type"))
+		l.page.Text(valueX+padding, l.y+bodySize+padding-1, HelveticaBold, bodySize, Black, tr.T("label.value"))
+		l.y += lineHeight + 2*padding - 4
+	}
+
+	l.ensure(2 * (lineHeight + 2*padding))
+	header()
+	for _, field := range fields {
+		names := WrapText(Helvetica, bodySize, field.Name, nameWidth-2*padding)
+		values := WrapText(Helvetica, bodySize, field.Value, contentWidth-nameWidth-2*padding)
+		rows := len(names)
+		if len(values) > rows {
+			rows = len(values)
+		}
+		height := float64(rows)*lineHeight + 2*padding - 4
+
+		if l.y+height > footerTop-10 {
+			l.newPage()
+			header()
+		}
+		l.page.Rect(margin, l.y, contentWidth, height, 0.5, Black)
+		l.page.Line(valueX, l.y, valueX, l.y+height, 0.5, Black)
+		for i, line := range names {
+			l.page.Text(margin+padding, l.y+bodySize+padding-1+float64(i)*lineHeight, Helvetica, bodySize, Black, line)
+		}
+		for i, line := range values {
+			l.page.Text(valueX+padding, l.y+bodySize+padding-1+float64(i)*lineHeight, Helvetica, bodySize, Black, line)
+		}
+		l.y += height
+	}
+}
+
+// source draws the source document in a monospaced font, highlighting the extracted values
+// Long lines are wrapped at the content width and continue on the next page.
+func (l *overviewLayout) source(tr *i18n.Translator, source *Source) {
+	l.sectionTitle(tr.T("overview.section.source"))
+	l.infoRow(tr.T("label.file"), source.Path)
+	if len(source.Found) > 0 {
+		l.infoRow(tr.T("overview.source.found"), strings.Join(source.Found, ", "))
+	}
+	if len(source.Missing) > 0 {
+		l.infoRow(tr.T("overview.source.missing"), strings.Join(source.Missing, ", "))
+	}
+	l.y += 6
+
+	charWidth := TextWidth(Courier, sourceSize, " ")
+	columns := int(contentWidth / charWidth)
+	column := 0
+
+	l.ensure(sourceLeading)
+	newLine := func() {
+		column = 0
+		l.y += sourceLeading
+		l.ensure(sourceLeading)
+	}
+
+	for _, segment := range source.Segments {
+		for i, part := range strings.Split(strings.ReplaceAll(segment.Text, "\t", "    "), "\n") {
+			if i > 0 {
+				newLine()
+			}
+			runes := []rune(part)
+			for len(runes) > 0 {
+				if column == columns {
+					newLine()
+				}
+				n := columns - column
+				if n > len(runes) {
+					n = len(runes)
+				}
+				chunk := string(runes[:n])
+				x := margin + float64(column)*charWidth
+				if segment.Label != "" {
+					l.page.FillRect(x, l.y+1, float64(n)*charWidth, sourceLeading, Highlight)
+				}
+				l.page.Text(x, l.y+sourceSize+1, Courier, sourceSize, Black, chunk)
+				column += n
+				runes = runes[n:]
+			}
+		}
+	}
+	l.y += sourceLeading
+}
+
+// money formats an amount in the locale, followed by the currency code if known
+func money(loc locale.Locale, amount float64, currency string) string {
+	result := loc.FormatDecimal(amount, 2, true)
+	if currency != "" {
+		result += " " + currency
+	}
+	return result
+}
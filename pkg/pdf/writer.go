@@ -0,0 +1,285 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// A4 page size in points (1/72 inch)
+const (
+	PageWidth  = 595.28
+	PageHeight = 841.89
+)
+
+// Color is an RGB color with components from 0 to 1
+type Color struct {
+	R, G, B float64
+}
+
+// Colors used by the layouts
+var (
+	Black     = Color{0, 0, 0}
+	Gray      = Color{0.4, 0.4, 0.4}
+	LightGray = Color{0.94, 0.94, 0.94}
+	Highlight = Color{1, 0.878, 0.4}
+)
+
+// Document is a PDF document built from pages of text, lines and rectangles
+// Only the standard Type 1 fonts are used, so the output needs no font files and
+// text is limited to the WinAnsi (Latin-1) character set.
+type Document struct {
+	// Title is written to the document information dictionary
+	Title string
+
+	// CreationDate is written to the document information dictionary
+	CreationDate time.Time
+
+	pages []*Page
+}
+
+// Page is a single A4 page, coordinates are in points from the top left corner
+type Page struct {
+	content bytes.Buffer
+}
+
+// New returns an empty document
+func New(title string) *Document {
+	return &Document{Title: title, CreationDate: time.Now()}
+}
+
+// AddPage appends a new blank page
+func (d *Document) AddPage() *Page {
+	page := &Page{}
+	d.pages = append(d.pages, page)
+	return page
+}
+
+// Pages returns the pages in order
+func (d *Document) Pages() []*Page {
+	return d.pages
+}
+
+// Text draws s with its baseline at y
+func (p *Page) Text(x, y float64, font Font, size float64, color Color, s string) {
+	if s == "" {
+		return
+	}
+	fmt.Fprintf(&p.content, "%s rg BT /%s %s Tf %s %s Td (%s) Tj ET\n",
+		color.operands(), font.resourceName(), num(size), num(x), num(PageHeight-y), escapeText(s))
+}
+
+// TextRight draws s right-aligned to x
+func (p *Page) TextRight(x, y float64, font Font, size float64, color Color, s string) {
+	p.Text(x-TextWidth(font, size, s), y, font, size, color, s)
+}
+
+// TextCenter draws s centered on x
+func (p *Page) TextCenter(x, y float64, font Font, size float64, color Color, s string) {
+	p.Text(x-TextWidth(font, size, s)/2, y, font, size, color, s)
+}
+
+// Line draws a straight line
+func (p *Page) Line(x1, y1, x2, y2, width float64, color Color) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s m %s %s l S\n",
+		color.operands(), num(width), num(x1), num(PageHeight-y1), num(x2), num(PageHeight-y2))
+}
+
+// Rect draws the outline of a rectangle whose top left corner is at x, y
+func (p *Page) Rect(x, y, w, h, width float64, color Color) {
+	fmt.Fprintf(&p.content, "%s RG %s w %s %s %s %s re S\n",
+		color.operands(), num(width), num(x), num(PageHeight-y-h), num(w), num(h))
+}
+
+// FillRect fills a rectangle whose top left corner is at x, y
+func (p *Page) FillRect(x, y, w, h float64, color Color) {
+	fmt.Fprintf(&p.content, "%s rg %s %s %s %s re f\n",
+		color.operands(), num(x), num(PageHeight-y-h), num(w), num(h))
+}
+
+// WrapText splits s into lines no wider than maxWidth, breaking at spaces
+// Words that do not fit on a line of their own are broken between characters.
+func WrapText(font Font, size float64, s string, maxWidth float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if TextWidth(font, size, candidate) <= maxWidth {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = word
+			for TextWidth(font, size, line) > maxWidth {
+				head, tail := splitAtWidth(font, size, line, maxWidth)
+				lines = append(lines, head)
+				line = tail
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// splitAtWidth splits s after the last character that fits in maxWidth, at least one character is kept
+func splitAtWidth(font Font, size float64, s string, maxWidth float64) (string, string) {
+	width := 0.0
+	for i, r := range s {
+		width += float64(glyphWidth(font, r)) * size / 1000
+		if width > maxWidth && i > 0 {
+			return s[:i], s[i:]
+		}
+	}
+	return s, ""
+}
+
+// Write writes the document as PDF 1.4 with compressed page contents
+func (d *Document) Write(w io.Writer) error {
+	pages := d.pages
+	if len(pages) == 0 {
+		pages = []*Page{{}}
+	}
+
+	// Object numbers: 1 catalog, 2 page tree, 3 info, then the fonts, then a page
+	// object and a content stream per page
+	const firstFont = 4
+	firstPage := firstFont + len(baseFonts)
+
+	out := &countingWriter{w: w}
+	var offsets []int64
+	begin := func() {
+		offsets = append(offsets, out.n)
+		fmt.Fprintf(out, "%d 0 obj\n", len(offsets))
+	}
+	end := func() {
+		fmt.Fprint(out, "endobj\n")
+	}
+
+	io.WriteString(out, "%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	begin()
+	fmt.Fprint(out, "<< /Type /Catalog /Pages 2 0 R >>\n")
+	end()
+
+	begin()
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	fmt.Fprintf(out, "<< /Type /Pages /Kids [%s] /Count %d >>\n", strings.Join(kids, " "), len(pages))
+	end()
+
+	begin()
+	fmt.Fprintf(out, "<< /Title %s /Producer %s /CreationDate (%s) >>\n",
+		textString(d.Title), textString("reciept-invoice-ai-tool"), d.CreationDate.UTC().Format("D:20060102150405Z"))
+	end()
+
+	fonts := make([]string, len(baseFonts))
+	for i, name := range baseFonts {
+		begin()
+		fmt.Fprintf(out, "<< /Type /Font /Subtype /Type1 /BaseFont /%s /Encoding /WinAnsiEncoding >>\n", name)
+		end()
+		fonts[i] = fmt.Sprintf("/%s %d 0 R", Font(i).resourceName(), firstFont+i)
+	}
+
+	for i, page := range pages {
+		contentNumber := firstPage + 2*i + 1
+
+		begin()
+		fmt.Fprintf(out, "<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %s %s] /Resources << /Font << %s >> >> /Contents %d 0 R >>\n",
+			num(PageWidth), num(PageHeight), strings.Join(fonts, " "), contentNumber)
+		end()
+
+		var compressed bytes.Buffer
+		zw := zlib.NewWriter(&compressed)
+		if _, err := zw.Write(page.content.Bytes()); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+
+		begin()
+		fmt.Fprintf(out, "<< /Length %d /Filter /FlateDecode >>\nstream\n", compressed.Len())
+		out.Write(compressed.Bytes())
+		fmt.Fprint(out, "\nendstream\n")
+		end()
+	}
+
+	xref := out.n
+	fmt.Fprintf(out, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(out, "trailer\n<< /Size %d /Root 1 0 R /Info 3 0 R >>\nstartxref\n%d\n%%EOF\n", len(offsets)+1, xref)
+
+	return out.err
+}
+
+// countingWriter tracks the byte offset for the cross-reference table and keeps the first error
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// operands returns the color as three PDF numbers
+func (c Color) operands() string {
+	return num(c.R) + " " + num(c.G) + " " + num(c.B)
+}
+
+// num formats a coordinate with at most two decimals and no trailing zeros
+func num(f float64) string {
+	s := fmt.Sprintf("%.2f", f)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" || s == "" {
+		return "0"
+	}
+	return s
+}
+
+// escapeText encodes s for a PDF string literal in WinAnsiEncoding
+func escapeText(s string) string {
+	var b strings.Builder
+	for _, c := range encodeWinAnsi(s) {
+		switch c {
+		case '\\', '(', ')':
+			b.WriteByte('\\')
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// textString encodes s as a UTF-16 hex string, used for document information
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, unit := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", unit)
+	}
+	b.WriteString(">")
+	return b.String()
+}
@@ -0,0 +1,126 @@
+package prompts
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// DefaultVersion is the built-in prompt used when none is configured
+const DefaultVersion = "accountant-v2"
+
+// Templates every prompt file must define
+var requiredTemplates = []string{"system", "user", "correction"}
+
+//go:embed builtin/*.tmpl
+var builtinFiles embed.FS
+
+// Prompt is a parsed prompt file
+// The version identifies the prompt in the output, the hash identifies its exact wording, so
+// an edited custom prompt can be told apart even if its version was not changed.
+type Prompt struct {
+	version string
+	hash    string
+	tmpl    *template.Template
+}
+
+// Load returns a built-in prompt by version, e.g. "accountant-v1"
+// An empty version returns the default prompt.
+func Load(version string) (*Prompt, error) {
+	if version == "" {
+		version = DefaultVersion
+	}
+	source, ok := Source(version)
+	if !ok {
+		return nil, fmt.Errorf("unknown prompt version %q (available: %s)", version, strings.Join(Versions(), ", "))
+	}
+	return Parse(version, source)
+}
+
+// LoadFile reads a custom prompt file, its version is the file name without extension
+func LoadFile(filename string) (*Prompt, error) {
+	content, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read prompt file %s: %w", filename, err)
+	}
+	version := strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))
+	return Parse(version, string(content))
+}
+
+// Parse parses the source of a prompt file
+func Parse(version string, source string) (*Prompt, error) {
+	tmpl, err := template.New(version).Option("missingkey=error").Parse(source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse prompt %s: %w", version, err)
+	}
+	for _, name := range requiredTemplates {
+		if tmpl.Lookup(name) == nil {
+			return nil, fmt.Errorf("prompt %s does not define the %q template", version, name)
+		}
+	}
+
+	sum := sha256.Sum256([]byte(source))
+	return &Prompt{version: version, hash: hex.EncodeToString(sum[:]), tmpl: tmpl}, nil
+}
+
+// Versions returns the versions of the built-in prompts, sorted
+func Versions() []string {
+	entries, _ := builtinFiles.ReadDir("builtin")
+	var versions []string
+	for _, entry := range entries {
+		versions = append(versions, strings.TrimSuffix(entry.Name(), ".tmpl"))
+	}
+	sort.Strings(versions)
+	return versions
+}
+
+// Source returns the source of a built-in prompt
+func Source(version string) (string, bool) {
+	content, err := builtinFiles.ReadFile(path.Join("builtin", version+".tmpl"))
+	if err != nil {
+		return "", false
+	}
+	return string(content), true
+}
+
+// Version returns the version of the prompt
+func (p *Prompt) Version() string {
+	return p.version
+}
+
+// Hash returns the SHA-256 of the prompt source in hex
+func (p *Prompt) Hash() string {
+	return p.hash
+}
+
+// System renders the system message
+func (p *Prompt) System() (string, error) {
+	return p.render("system", nil)
+}
+
+// User renders the user message carrying the document text
+func (p *Prompt) User(content string) (string, error) {
+	return p.render("user", struct{ Content string }{content})
+}
+
+// Correction renders the follow-up message asking to correct the problems of an extraction
+func (p *Prompt) Correction(problems []string) (string, error) {
+	return p.render("correction", struct{ Problems []string }{problems})
+}
+
+// render executes one template of the prompt
+func (p *Prompt) render(name string, data interface{}) (string, error) {
+	var buf bytes.Buffer
+	if err := p.tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", fmt.Errorf("failed to render %s message of prompt %s: %w", name, p.version, err)
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,169 @@
+package report
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// Fallback group keys for documents without category or company
+const (
+	NoCategory = "(none)"
+	NoCompany  = "(unknown)"
+)
+
+// Report is the aggregated content of a multi-document summary report
+type Report struct {
+	// Title is shown in the report header
+	Title string
+
+	// GeneratedAt is the time the report was generated
+	GeneratedAt time.Time
+
+	// FirstDate and LastDate span the issue dates of the documents, zero if no document is dated
+	FirstDate time.Time
+	LastDate  time.Time
+
+	// Entries are the documents in date order
+	Entries []Entry
+
+	// Currencies are the totals of bookable documents per original currency, sorted by currency code
+	Currencies []CurrencyTotal
+
+	// SEKCents is the sum of all known SEK amounts of bookable documents in öre, credit notes
+	// count negative
+	SEKCents int
+
+	// VatCents is the sum of all VAT amounts of bookable documents that could be converted to öre
+	VatCents int
+
+	// MissingSEK is the number of bookable documents without a SEK amount
+	MissingSEK int
+
+	// NotBooked is the number of documents left out of the totals because their type is not
+	// booked (reminders, quotes, statements, ...)
+	NotBooked int
+
+	// Categories are the subtotals per Description category
+	Categories []document.Group
+
+	// Companies are the subtotals per company
+	Companies []document.Group
+}
+
+// Entry is one document of the report
+type Entry struct {
+	// Number is the 1-based position in the report
+	Number int
+
+	// Anchor is the id of the document's detail section
+	Anchor string
+
+	// Document is the loaded JSON document, with the amounts of a credit note negative
+	document.Document
+
+	// VatCents is the VAT in öre, nil if it cannot be determined
+	VatCents *int
+}
+
+// CurrencyTotal is the sum of original amounts in one currency
+type CurrencyTotal struct {
+	// Currency is the ISO currency code
+	Currency string
+
+	// Count is the number of documents in the currency
+	Count int
+
+	// AmountCents is the sum of original amounts in hundredths of the currency
+	AmountCents int
+
+	// VatCents is the sum of original VAT amounts in hundredths of the currency
+	VatCents int
+}
+
+// Build aggregates the documents into a report, documents are sorted by date
+func Build(docs []document.Document, title string, generatedAt time.Time) *Report {
+	sorted := make([]document.Document, len(docs))
+	copy(sorted, docs)
+	document.Sort(sorted)
+
+	r := &Report{Title: title, GeneratedAt: generatedAt}
+	currencies := make(map[string]*CurrencyTotal)
+
+	for i, doc := range sorted {
+		info := document.Signed(doc.Info)
+		entry := Entry{Number: i + 1, Anchor: fmt.Sprintf("doc-%d", i+1), Document: document.Document{Path: doc.Path, Info: info}}
+		bookable := document.Bookable(info)
+
+		if vat, ok := document.VatCents(info); ok {
+			entry.VatCents = &vat
+			if bookable {
+				r.VatCents += vat
+			}
+		}
+		switch {
+		case !bookable:
+			r.NotBooked++
+		case info.SECentAmount != nil:
+			r.SEKCents += *info.SECentAmount
+		default:
+			r.MissingSEK++
+		}
+
+		if date, ok := document.ParseDate(info); ok {
+			if r.FirstDate.IsZero() || date.Before(r.FirstDate) {
+				r.FirstDate = date
+			}
+			if date.After(r.LastDate) {
+				r.LastDate = date
+			}
+		}
+
+		currency := strings.ToUpper(strings.TrimSpace(document.StringValue(info.OriginalCurrency)))
+		if bookable && currency != "" && info.OriginalAmount != nil {
+			total, ok := currencies[currency]
+			if !ok {
+				total = &CurrencyTotal{Currency: currency}
+				currencies[currency] = total
+			}
+			total.Count++
+			total.AmountCents += int(math.Round(*info.OriginalAmount * 100))
+			if info.OriginalVatAmount != nil {
+				total.VatCents += int(math.Round(*info.OriginalVatAmount * 100))
+			}
+		}
+
+		r.Entries = append(r.Entries, entry)
+	}
+
+	for _, total := range currencies {
+		r.Currencies = append(r.Currencies, *total)
+	}
+	sort.Slice(r.Currencies, func(i, j int) bool {
+		return r.Currencies[i].Currency < r.Currencies[j].Currency
+	})
+
+	r.Categories = document.GroupBy(sorted, func(info *interfaces.ReceiptInvoiceInfo) string {
+		return strings.TrimSpace(info.Description)
+	}, NoCategory)
+	r.Companies = document.GroupBy(sorted, func(info *interfaces.ReceiptInvoiceInfo) string {
+		return strings.TrimSpace(document.StringValue(info.Company))
+	}, NoCompany)
+
+	return r
+}
+
+// EntryFor returns the entry of a document path, nil if it is not part of the report
+func (r *Report) EntryFor(path string) *Entry {
+	for i := range r.Entries {
+		if r.Entries[i].Path == path {
+			return &r.Entries[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,297 @@
+package sourceview
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/locale"
+)
+
+// Field keys of the extracted values that are searched for in the source
+const (
+	FieldCompany           = "company"
+	FieldDateIssued        = "date_issued"
+	FieldPeriodStart       = "period_start"
+	FieldPeriodEnd         = "period_end"
+	FieldOriginalAmount    = "original_amount"
+	FieldOriginalVatAmount = "original_vat_amount"
+	FieldSEKAmount         = "se_cent_amount"
+	IdFieldPrefix          = "id:"
+)
+
+// dateLayouts are the ways an issue date is commonly written in receipts and invoices
+var dateLayouts = []string{
+	"2006-01-02",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"2 January 2006",
+	"2 Jan 2006",
+	"02/01/2006",
+	"01/02/2006",
+	"2.1.2006",
+	"02.01.2006",
+	"20060102",
+}
+
+// periodLayouts are the additional ways a day of a service period is written, periods often
+// give the year once for both dates ("Aug 2 – Sep 2, 2025")
+var periodLayouts = []string{
+	"January 2",
+	"Jan 2",
+	"2 January",
+	"2 Jan",
+}
+
+// Needle is an extracted value and the spellings it may have in the source
+type Needle struct {
+	// Field is the field key, e.g. "company" or "id:Invoice Number"
+	Field string
+
+	// Values are the spellings searched for, the longest match wins
+	Values []string
+
+	// Numeric needles only match when not surrounded by other digits
+	Numeric bool
+}
+
+// Segment is a piece of the source text, Field is set if it matched an extracted value
+type Segment struct {
+	Text  string
+	Field string
+}
+
+// View is the source text split into plain and highlighted segments
+type View struct {
+	// Segments cover the whole source text in order
+	Segments []Segment
+
+	// Found are the fields that matched at least once, in needle order
+	Found []string
+
+	// Missing are the fields that were extracted but not found in the source, in needle order
+	Missing []string
+}
+
+// NeedlesFor builds the needles for all extracted values of a document
+func NeedlesFor(info *interfaces.ReceiptInvoiceInfo) []Needle {
+	var needles []Needle
+
+	if company := strings.TrimSpace(document.StringValue(info.Company)); company != "" {
+		needles = append(needles, Needle{Field: FieldCompany, Values: []string{company}})
+	}
+
+	if date, ok := document.ParseDate(info); ok {
+		needles = append(needles, Needle{Field: FieldDateIssued, Values: dateSpellings(date)})
+	}
+	for _, period := range []struct {
+		field string
+		date  *string
+	}{{FieldPeriodStart, info.PeriodStart}, {FieldPeriodEnd, info.PeriodEnd}} {
+		if date, err := time.Parse(document.DateLayout, strings.TrimSpace(document.StringValue(period.date))); err == nil {
+			needles = append(needles, Needle{Field: period.field, Values: periodSpellings(date)})
+		}
+	}
+
+	if info.OriginalAmount != nil {
+		needles = append(needles, Needle{Field: FieldOriginalAmount, Values: amountSpellings(*info.OriginalAmount), Numeric: true})
+	}
+	if info.OriginalVatAmount != nil && *info.OriginalVatAmount != 0 {
+		needles = append(needles, Needle{Field: FieldOriginalVatAmount, Values: amountSpellings(*info.OriginalVatAmount), Numeric: true})
+	}
+
+	// The SEK amount is only searched for if it is not already the original amount
+	currency := strings.ToUpper(strings.TrimSpace(document.StringValue(info.OriginalCurrency)))
+	if info.SECentAmount != nil && currency != "SEK" {
+		needles = append(needles, Needle{Field: FieldSEKAmount, Values: amountSpellings(float64(*info.SECentAmount) / 100), Numeric: true})
+	}
+
+	for _, idField := range info.IdFields {
+		if value := strings.TrimSpace(idField.Value); value != "" {
+			needles = append(needles, Needle{Field: IdFieldPrefix + idField.Name, Values: []string{value}})
+		}
+	}
+
+	return needles
+}
+
+// Highlight splits text into segments, marking every occurrence of the needles
+// Overlapping matches are resolved in favour of the earliest, then the longest match.
+func Highlight(text string, needles []Needle) View {
+	type match struct {
+		start, end int
+		field      string
+	}
+
+	var matches []match
+	found := make(map[string]bool)
+	for _, needle := range needles {
+		re := needlePattern(needle)
+		if re == nil {
+			continue
+		}
+		for _, loc := range findAll(re, text, needle.Numeric) {
+			matches = append(matches, match{start: loc[0], end: loc[1], field: needle.Field})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].start != matches[j].start {
+			return matches[i].start < matches[j].start
+		}
+		return matches[i].end-matches[i].start > matches[j].end-matches[j].start
+	})
+
+	var view View
+	pos := 0
+	for _, m := range matches {
+		if m.start < pos {
+			continue
+		}
+		if m.start > pos {
+			view.Segments = append(view.Segments, Segment{Text: text[pos:m.start]})
+		}
+		view.Segments = append(view.Segments, Segment{Text: text[m.start:m.end], Field: m.field})
+		found[m.field] = true
+		pos = m.end
+	}
+	if pos < len(text) {
+		view.Segments = append(view.Segments, Segment{Text: text[pos:]})
+	}
+
+	for _, needle := range needles {
+		if found[needle.Field] {
+			view.Found = append(view.Found, needle.Field)
+		} else {
+			view.Missing = append(view.Missing, needle.Field)
+		}
+	}
+
+	return view
+}
+
+// needlePattern compiles the alternatives of a needle into one case-insensitive pattern
+// The match itself is always the first submatch.
+func needlePattern(needle Needle) *regexp.Regexp {
+	values := make([]string, 0, len(needle.Values))
+	seen := make(map[string]bool)
+	for _, value := range needle.Values {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		// Any run of whitespace (including non-breaking spaces) in the value matches any run in the source
+		parts := strings.Fields(value)
+		for i, part := range parts {
+			parts[i] = regexp.QuoteMeta(part)
+		}
+		values = append(values, strings.Join(parts, `[\s\x{00a0}]+`))
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	// Longer alternatives first so "1 096.77" wins over "096.77"
+	sort.SliceStable(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+
+	return regexp.MustCompile("(?i)(" + strings.Join(values, "|") + ")")
+}
+
+// findAll returns the start and end of every match of re in text
+// Numeric matches must not be part of a longer number. The boundaries are checked after matching
+// and are not consumed, so "95,37 95,37" yields two matches.
+func findAll(re *regexp.Regexp, text string, numeric bool) [][2]int {
+	var locs [][2]int
+	for offset := 0; offset < len(text); {
+		loc := re.FindStringSubmatchIndex(text[offset:])
+		if loc == nil {
+			break
+		}
+		start, end := offset+loc[2], offset+loc[3]
+		if numeric && !numberBoundary(text, start, end) {
+			// Values start with an ASCII digit, the next byte starts a character
+			offset = start + 1
+			continue
+		}
+		locs = append(locs, [2]int{start, end})
+		offset = end
+		if end == start {
+			offset++
+		}
+	}
+	return locs
+}
+
+// numberBoundary reports whether text[start:end] is a whole number: it is not preceded by a digit or
+// separator, nor followed by a digit or a separator and digit as in the decimals of "1234,50"
+func numberBoundary(text string, start, end int) bool {
+	if start > 0 && strings.ContainsRune("0123456789.,", rune(text[start-1])) {
+		return false
+	}
+	if end < len(text) && isDigit(text[end]) {
+		return false
+	}
+	if end+1 < len(text) && (text[end] == '.' || text[end] == ',') && isDigit(text[end+1]) {
+		return false
+	}
+	return true
+}
+
+// isDigit reports whether b is an ASCII digit
+func isDigit(b byte) bool {
+	return b >= '0' && b <= '9'
+}
+
+// dateSpellings returns the date in all supported layouts
+func dateSpellings(date time.Time) []string {
+	spellings := make([]string, 0, len(dateLayouts))
+	for _, layout := range dateLayouts {
+		spellings = append(spellings, date.Format(layout))
+	}
+	return spellings
+}
+
+// periodSpellings returns the spellings of a date of a service period, with and without year
+func periodSpellings(date time.Time) []string {
+	spellings := dateSpellings(date)
+	for _, layout := range periodLayouts {
+		spellings = append(spellings, date.Format(layout))
+	}
+	return spellings
+}
+
+// amountSpellings returns an amount with dot and comma decimals, with and without grouping
+// Whole amounts are also spelled without decimals, e.g. "1234,-" and "1 234".
+func amountSpellings(amount float64) []string {
+	if amount < 0 {
+		amount = -amount
+	}
+	plain := strconv.FormatFloat(amount, 'f', 2, 64)
+	intPart, fracPart, _ := strings.Cut(plain, ".")
+
+	spellings := []string{
+		intPart + "." + fracPart,
+		intPart + "," + fracPart,
+	}
+	if len(intPart) > 3 {
+		for _, group := range []struct{ thousands, decimal string }{
+			{",", "."}, {" ", ","}, {".", ","}, {" ", "."},
+		} {
+			spellings = append(spellings, locale.GroupDigits(intPart, group.thousands)+group.decimal+fracPart)
+		}
+	}
+	if fracPart == "00" {
+		spellings = append(spellings, intPart+",-", intPart+":-", intPart)
+		if len(intPart) > 3 {
+			for _, thousands := range []string{" ", ".", ","} {
+				spellings = append(spellings, locale.GroupDigits(intPart, thousands))
+			}
+		}
+	}
+	return spellings
+}
@@ -0,0 +1,132 @@
+package sourceview
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+func TestHighlight(t *testing.T) {
+	str := func(s string) *string { return &s }
+	num := func(f float64) *float64 { return &f }
+	cents := func(c int) *int { return &c }
+
+	tests := []struct {
+		name        string
+		text        string
+		info        interfaces.ReceiptInvoiceInfo
+		highlighted []string // field=text of the highlighted segments
+		missing     []string
+	}{
+		{
+			name: "receipt",
+			text: "Anthropic, PBC\nDate paid August 2, 2025\nInvoice number D8F67A38-0007\nVAT €19.07\nTotal €95.37",
+			info: interfaces.ReceiptInvoiceInfo{
+				Company:           str("Anthropic, PBC"),
+				DateIssued:        str("2025-08-02"),
+				OriginalAmount:    num(95.37),
+				OriginalVatAmount: num(19.07),
+				OriginalCurrency:  str("EUR"),
+				SECentAmount:      cents(109677),
+				IdFields:          []interfaces.IdField{{Name: "Invoice Number", Value: "D8F67A38-0007"}},
+			},
+			highlighted: []string{
+				"company=Anthropic, PBC",
+				"date_issued=August 2, 2025",
+				"id:Invoice Number=D8F67A38-0007",
+				"original_vat_amount=19.07",
+				"original_amount=95.37",
+			},
+			missing: []string{FieldSEKAmount},
+		},
+		{
+			name:        "case and whitespace differ",
+			text:        "ANTHROPIC,  PBC",
+			info:        interfaces.ReceiptInvoiceInfo{Company: str("Anthropic, PBC")},
+			highlighted: []string{"company=ANTHROPIC,  PBC"},
+		},
+		{
+			name:        "adjacent amounts",
+			text:        "95,37 95,37",
+			info:        interfaces.ReceiptInvoiceInfo{OriginalAmount: num(95.37)},
+			highlighted: []string{"original_amount=95,37", "original_amount=95,37"},
+		},
+		{
+			name:        "grouped amount",
+			text:        "Att betala 1 096,77 kr",
+			info:        interfaces.ReceiptInvoiceInfo{OriginalAmount: num(1096.77)},
+			highlighted: []string{"original_amount=1 096,77"},
+		},
+		{
+			name:        "whole amount without decimals",
+			text:        "Summa 1 234 kr",
+			info:        interfaces.ReceiptInvoiceInfo{OriginalAmount: num(1234)},
+			highlighted: []string{"original_amount=1 234"},
+		},
+		{
+			name:        "whole amount with kronor sign",
+			text:        "Summa 250:-",
+			info:        interfaces.ReceiptInvoiceInfo{OriginalAmount: num(250)},
+			highlighted: []string{"original_amount=250:-"},
+		},
+		{
+			name:        "credit note amount is found without sign",
+			text:        "Kredit -125,00",
+			info:        interfaces.ReceiptInvoiceInfo{OriginalAmount: num(-125)},
+			highlighted: []string{"original_amount=125,00"},
+		},
+		{
+			name:    "amount inside a longer number",
+			text:    "Order 112345 of 1234,50 kr",
+			info:    interfaces.ReceiptInvoiceInfo{OriginalAmount: num(1234)},
+			missing: []string{FieldOriginalAmount},
+		},
+		{
+			name:        "overlap goes to the earliest match",
+			text:        "Total 1 234",
+			info:        interfaces.ReceiptInvoiceInfo{OriginalAmount: num(1234), OriginalVatAmount: num(234)},
+			highlighted: []string{"original_amount=1 234"},
+			missing:     []string{FieldOriginalVatAmount},
+		},
+		{
+			name: "service period sharing the year",
+			text: "Aug 2 – Sep 2, 2025",
+			info: interfaces.ReceiptInvoiceInfo{PeriodStart: str("2025-08-02"), PeriodEnd: str("2025-09-02")},
+			highlighted: []string{
+				"period_start=Aug 2",
+				"period_end=Sep 2, 2025",
+			},
+		},
+		{
+			name:    "date in another layout",
+			text:    "Datum 2025-08-20",
+			info:    interfaces.ReceiptInvoiceInfo{DateIssued: str("2025-08-02")},
+			missing: []string{FieldDateIssued},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			view := Highlight(tt.text, NeedlesFor(&tt.info))
+
+			var text strings.Builder
+			var highlighted []string
+			for _, segment := range view.Segments {
+				text.WriteString(segment.Text)
+				if segment.Field != "" {
+					highlighted = append(highlighted, segment.Field+"="+segment.Text)
+				}
+			}
+			if text.String() != tt.text {
+				t.Errorf("segments give %q, want the whole text", text.String())
+			}
+			if !reflect.DeepEqual(highlighted, tt.highlighted) {
+				t.Errorf("highlighted %q, want %q", highlighted, tt.highlighted)
+			}
+			if !reflect.DeepEqual(view.Missing, tt.missing) {
+				t.Errorf("missing %v, want %v", view.Missing, tt.missing)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package subscriptions
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/naming"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/vendors"
+)
+
+// monthLayout is the layout of the months in a Subscription
+const monthLayout = "2006-01"
+
+// DefaultMinCharges is the number of charges at regular intervals that make a vendor's documents
+// a subscription when none of them is classified as recurring
+const DefaultMinCharges = 3
+
+// Options configure Detect, zero values select the defaults
+type Options struct {
+	// Now is the time the next charges are compared with, zero means the current time
+	Now time.Time
+
+	// MinCharges is the number of regular charges that make a subscription without a recurring flag
+	MinCharges int
+}
+
+// Charge is one document of a subscription
+type Charge struct {
+	// File is the JSON file of the document
+	File string `json:"file"`
+
+	// Month is the month the charge is for, from period_start or else date_issued, as YYYY-MM
+	Month string `json:"month"`
+
+	// Date is the date the charge is for, as YYYY-MM-DD
+	Date string `json:"date"`
+
+	// AmountCents is the original amount in hundredths of the currency
+	AmountCents int `json:"amount_cents"`
+
+	// SEKCents is the amount in öre, nil if unknown
+	SEKCents *int `json:"sek_cents,omitempty"`
+}
+
+// PriceChange is a charge whose amount differs from the previous one
+type PriceChange struct {
+	// Month is the month of the charge with the new price
+	Month string `json:"month"`
+
+	// File is the JSON file of the charge with the new price
+	File string `json:"file"`
+
+	// FromCents and ToCents are the old and new amount in hundredths of the currency
+	FromCents int `json:"from_cents"`
+	ToCents   int `json:"to_cents"`
+}
+
+// Subscription is a recurring charge of one vendor in one currency
+type Subscription struct {
+	// Vendor is the vendor of the registry, or the company without its legal form
+	Vendor string `json:"vendor"`
+
+	// Currency is the original currency of the charges
+	Currency string `json:"currency"`
+
+	// Interval is the billing interval, "" if it could not be determined
+	Interval string `json:"interval"`
+
+	// Inferred is true if the interval was derived from the dates of the charges rather than
+	// extracted from the documents
+	Inferred bool `json:"inferred"`
+
+	// Charges are the documents of the subscription in date order
+	Charges []Charge `json:"charges"`
+
+	// PriceChanges are the charges whose amount differs from the previous charge
+	PriceChanges []PriceChange `json:"price_changes"`
+
+	// Missed are the months between the first and the last charge without an expected charge
+	Missed []string `json:"missed"`
+
+	// NextMonth is the month the next charge is expected, "" if the interval is unknown
+	NextMonth string `json:"next_month"`
+
+	// Lapsed is true if the next charge is expected before the current month, the subscription
+	// was cancelled or its latest documents are missing from the archive
+	Lapsed bool `json:"lapsed"`
+
+	// YearlySEKCents is the cost of a year at the latest price in öre, nil if unknown
+	YearlySEKCents *int `json:"yearly_sek_cents,omitempty"`
+}
+
+// LatestCents returns the amount of the latest charge in hundredths of the currency
+func (s *Subscription) LatestCents() int {
+	return s.Charges[len(s.Charges)-1].AmountCents
+}
+
+// group is the charges of one vendor in one currency before they are classified
+type group struct {
+	vendor    string
+	currency  string
+	charges   []Charge
+	recurring bool
+	intervals map[string]int
+}
+
+// Detect finds the subscriptions among documents, sorted by vendor and currency
+// Invoices and receipts with an amount and a date are grouped by vendor and currency. A group is a
+// subscription if one of its documents is classified as recurring, or if it has at least
+// MinCharges charges most of which are one billing interval apart.
+func Detect(docs []document.Document, opts Options) []Subscription {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.MinCharges <= 0 {
+		opts.MinCharges = DefaultMinCharges
+	}
+
+	index := make(map[string]*group)
+	var groups []*group
+	for _, doc := range docs {
+		info := doc.Info
+		if info.DocumentType != interfaces.DocumentTypeInvoice && info.DocumentType != interfaces.DocumentTypeReceipt {
+			continue
+		}
+		vendor := vendorName(info)
+		date, ok := document.ServiceDate(info)
+		if vendor == "" || !ok || info.OriginalAmount == nil {
+			continue
+		}
+		currency := strings.ToUpper(strings.TrimSpace(document.StringValue(info.OriginalCurrency)))
+
+		key := vendors.NameKey(vendor) + "/" + currency
+		g, ok := index[key]
+		if !ok {
+			g = &group{vendor: vendor, currency: currency, intervals: make(map[string]int)}
+			index[key] = g
+			groups = append(groups, g)
+		}
+		g.charges = append(g.charges, Charge{
+			File:        doc.Path,
+			Month:       date.Format(monthLayout),
+			Date:        date.Format(document.DateLayout),
+			AmountCents: int(math.Round(*info.OriginalAmount * 100)),
+			SEKCents:    info.SECentAmount,
+		})
+		if document.IsRecurring(info) {
+			g.recurring = true
+		}
+		if interval := document.BillingInterval(info); document.BillingIntervals[interval] > 0 {
+			g.intervals[interval]++
+		}
+	}
+
+	var subscriptions []Subscription
+	for _, g := range groups {
+		if subscription, ok := g.subscription(opts); ok {
+			subscriptions = append(subscriptions, subscription)
+		}
+	}
+	sort.SliceStable(subscriptions, func(i, j int) bool {
+		if subscriptions[i].Vendor != subscriptions[j].Vendor {
+			return strings.ToLower(subscriptions[i].Vendor) < strings.ToLower(subscriptions[j].Vendor)
+		}
+		return subscriptions[i].Currency < subscriptions[j].Currency
+	})
+	return subscriptions
+}
+
+// subscription classifies the charges of a group, returning false if they are not recurring
+func (g *group) subscription(opts Options) (Subscription, bool) {
+	sort.SliceStable(g.charges, func(i, j int) bool {
+		return g.charges[i].Date < g.charges[j].Date
+	})
+
+	s := Subscription{Vendor: g.vendor, Currency: g.currency, Charges: g.charges}
+	s.Interval = mostCommon(g.intervals)
+	if s.Interval == "" {
+		s.Interval = inferInterval(g.charges)
+		s.Inferred = s.Interval != ""
+		if !g.recurring && (len(g.charges) < opts.MinCharges || s.Interval == "") {
+			return Subscription{}, false
+		}
+	}
+
+	for i := 1; i < len(g.charges); i++ {
+		if previous, charge := g.charges[i-1], g.charges[i]; charge.AmountCents != previous.AmountCents {
+			s.PriceChanges = append(s.PriceChanges, PriceChange{
+				Month:     charge.Month,
+				File:      charge.File,
+				FromCents: previous.AmountCents,
+				ToCents:   charge.AmountCents,
+			})
+		}
+	}
+
+	months := document.BillingIntervals[s.Interval]
+	if months == 0 {
+		return s, true
+	}
+
+	charged := make(map[string]bool)
+	for _, charge := range g.charges {
+		charged[charge.Month] = true
+	}
+	first, _ := time.Parse(monthLayout, g.charges[0].Month)
+	last, _ := time.Parse(monthLayout, g.charges[len(g.charges)-1].Month)
+	for month := first.AddDate(0, months, 0); month.Before(last); month = month.AddDate(0, months, 0) {
+		if !charged[month.Format(monthLayout)] {
+			s.Missed = append(s.Missed, month.Format(monthLayout))
+		}
+	}
+
+	next := last.AddDate(0, months, 0)
+	s.NextMonth = next.Format(monthLayout)
+	current := time.Date(opts.Now.Year(), opts.Now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	s.Lapsed = next.Before(current)
+
+	if latest := g.charges[len(g.charges)-1].SEKCents; latest != nil {
+		yearly := *latest * 12 / months
+		s.YearlySEKCents = &yearly
+	}
+	return s, true
+}
+
+// vendorName returns the vendor of the registry the document was matched to, or else its
+// company without legal form
+func vendorName(info *interfaces.ReceiptInvoiceInfo) string {
+	if info.Vendor != nil && strings.TrimSpace(info.Vendor.Name) != "" {
+		return strings.TrimSpace(info.Vendor.Name)
+	}
+	return naming.StripCompanySuffixes(strings.TrimSpace(document.StringValue(info.Company)), nil)
+}
+
+// inferInterval returns the billing interval most charges are apart, "" if there is none
+// Gaps are counted in months, repeated charges within a month are ignored.
+func inferInterval(charges []Charge) string {
+	gaps := make(map[int]int)
+	total := 0
+	for i := 1; i < len(charges); i++ {
+		if gap := monthsBetween(charges[i-1].Month, charges[i].Month); gap > 0 {
+			gaps[gap]++
+			total++
+		}
+	}
+
+	best := ""
+	for interval, months := range document.BillingIntervals {
+		if gaps[months]*2 > total && (best == "" || gaps[months] > gaps[document.BillingIntervals[best]]) {
+			best = interval
+		}
+	}
+	return best
+}
+
+// mostCommon returns the key with the highest count, the alphabetically first on ties
+func mostCommon(counts map[string]int) string {
+	best := ""
+	for key, count := range counts {
+		if best == "" || count > counts[best] || count == counts[best] && key < best {
+			best = key
+		}
+	}
+	return best
+}
+
+// monthsBetween returns the number of months from one YYYY-MM month to another
+func monthsBetween(from, to string) int {
+	f, err1 := time.Parse(monthLayout, from)
+	t, err2 := time.Parse(monthLayout, to)
+	if err1 != nil || err2 != nil {
+		return 0
+	}
+	return (t.Year()-f.Year())*12 + int(t.Month()) - int(f.Month())
+}
@@ -0,0 +1,151 @@
+package subscriptions
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// charge returns a receipt of company issued on date, SEK amounts are 11.5 times the amount
+func charge(company, date string, amount float64, currency string) document.Document {
+	sek := int(amount * 1150)
+	return document.Document{
+		Path: fmt.Sprintf("%s-%s.json", company, date),
+		Info: &interfaces.ReceiptInvoiceInfo{
+			DocumentType:     interfaces.DocumentTypeReceipt,
+			Company:          &company,
+			DateIssued:       &date,
+			OriginalAmount:   &amount,
+			OriginalCurrency: &currency,
+			SECentAmount:     &sek,
+		},
+	}
+}
+
+// recurring marks a document as a recurring charge billed at interval
+func recurring(doc document.Document, interval string) document.Document {
+	yes := true
+	doc.Info.Recurring, doc.Info.BillingInterval = &yes, &interval
+	return doc
+}
+
+func TestDetect(t *testing.T) {
+	// summary is the part of a Subscription compared by the tests
+	type summary struct {
+		Vendor, Currency, Interval string
+		Inferred                   bool
+		Charges                    int
+		PriceChanges               []PriceChange
+		Missed                     []string
+		NextMonth                  string
+		Lapsed                     bool
+		YearlySEKCents             int
+	}
+
+	tests := []struct {
+		name string
+		docs []document.Document
+		want []summary
+	}{
+		{
+			name: "monthly interval inferred from the dates",
+			docs: []document.Document{
+				charge("Anthropic, PBC", "2025-07-02", 20, "USD"),
+				charge("Anthropic, PBC", "2025-05-02", 20, "USD"),
+				charge("Anthropic", "2025-06-02", 20, "USD"),
+				charge("Anthropic, PBC", "2025-09-02", 20, "USD"),
+			},
+			want: []summary{{
+				Vendor: "Anthropic", Currency: "USD", Interval: interfaces.BillingIntervalMonthly, Inferred: true, Charges: 4,
+				Missed: []string{"2025-08"}, NextMonth: "2025-10", YearlySEKCents: 276000,
+			}},
+		},
+		{
+			name: "too few charges without a recurring flag",
+			docs: []document.Document{
+				charge("Anthropic", "2025-05-02", 20, "USD"),
+				charge("Anthropic", "2025-06-02", 20, "USD"),
+			},
+		},
+		{
+			name: "irregular charges",
+			docs: []document.Document{
+				charge("Kjell", "2025-01-10", 99, "SEK"),
+				charge("Kjell", "2025-02-10", 99, "SEK"),
+				charge("Kjell", "2025-07-10", 99, "SEK"),
+				charge("Kjell", "2025-09-10", 99, "SEK"),
+			},
+		},
+		{
+			name: "yearly subscription that lapsed",
+			docs: []document.Document{recurring(charge("JetBrains s.r.o.", "2024-03-01", 100, "EUR"), interfaces.BillingIntervalYearly)},
+			want: []summary{{
+				Vendor: "JetBrains s.r.o.", Currency: "EUR", Interval: interfaces.BillingIntervalYearly, Charges: 1,
+				NextMonth: "2025-03", Lapsed: true, YearlySEKCents: 115000,
+			}},
+		},
+		{
+			name: "price change",
+			docs: []document.Document{
+				charge("GitHub", "2025-06-15", 4, "USD"),
+				charge("GitHub", "2025-07-15", 4, "USD"),
+				charge("GitHub", "2025-08-15", 4.5, "USD"),
+			},
+			want: []summary{{
+				Vendor: "GitHub", Currency: "USD", Interval: interfaces.BillingIntervalMonthly, Inferred: true, Charges: 3,
+				PriceChanges: []PriceChange{{Month: "2025-08", File: "GitHub-2025-08-15.json", FromCents: 400, ToCents: 450}},
+				NextMonth:    "2025-09", Lapsed: true, YearlySEKCents: 62100,
+			}},
+		},
+		{
+			name: "currencies are separate subscriptions",
+			docs: []document.Document{
+				recurring(charge("Zoom", "2025-09-01", 15, "USD"), interfaces.BillingIntervalMonthly),
+				recurring(charge("Adobe", "2025-09-01", 30, "EUR"), interfaces.BillingIntervalMonthly),
+				recurring(charge("Zoom", "2025-09-05", 140, "SEK"), interfaces.BillingIntervalMonthly),
+			},
+			want: []summary{
+				{Vendor: "Adobe", Currency: "EUR", Interval: interfaces.BillingIntervalMonthly, Charges: 1, NextMonth: "2025-10", YearlySEKCents: 414000},
+				{Vendor: "Zoom", Currency: "SEK", Interval: interfaces.BillingIntervalMonthly, Charges: 1, NextMonth: "2025-10", YearlySEKCents: 1932000},
+				{Vendor: "Zoom", Currency: "USD", Interval: interfaces.BillingIntervalMonthly, Charges: 1, NextMonth: "2025-10", YearlySEKCents: 207000},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []summary
+			for _, s := range Detect(tt.docs, Options{Now: time.Date(2025, 10, 15, 0, 0, 0, 0, time.UTC)}) {
+				sum := summary{
+					Vendor: s.Vendor, Currency: s.Currency, Interval: s.Interval, Inferred: s.Inferred, Charges: len(s.Charges),
+					PriceChanges: s.PriceChanges, Missed: s.Missed, NextMonth: s.NextMonth, Lapsed: s.Lapsed,
+				}
+				if s.YearlySEKCents != nil {
+					sum.YearlySEKCents = *s.YearlySEKCents
+				}
+				got = append(got, sum)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("subscriptions\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectIgnoresOtherDocuments(t *testing.T) {
+	var docs []document.Document
+	for _, date := range []string{"2025-06-02", "2025-07-02", "2025-08-02"} {
+		doc := charge("Anthropic", date, 20, "USD")
+		doc.Info.DocumentType = interfaces.DocumentTypeCreditNote
+		docs = append(docs, doc)
+	}
+	undated := charge("Anthropic", "", 20, "USD")
+	docs = append(docs, undated)
+
+	if got := Detect(docs, Options{}); len(got) != 0 {
+		t.Errorf("Detect = %+v, want no subscriptions", got)
+	}
+}
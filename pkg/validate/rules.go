@@ -0,0 +1,399 @@
+package validate
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+// vatRoundingSlack is the VAT difference, in the original currency, that is accepted as
+// rounding of individual lines regardless of the rate
+const vatRoundingSlack = 0.05
+
+// Rule is a named check of a document
+type Rule struct {
+	// Name identifies the rule in findings and in the config
+	Name string
+
+	// Description tells what the rule checks
+	Description string
+
+	// Severity is the default severity of findings
+	Severity Severity
+
+	check func(info *interfaces.ReceiptInvoiceInfo, opts *Options) []problem
+}
+
+// problem is a finding before the document and severity are known
+type problem struct {
+	field   string
+	message string
+}
+
+// Rules are all rules in the order they are run
+var Rules = []Rule{
+	{
+		Name:        "required_fields",
+		Description: "fields required for the document type are present",
+		Severity:    SeverityError,
+		check:       checkRequiredFields,
+	},
+	{
+		Name:        "date",
+		Description: "date_issued is a YYYY-MM-DD date and not in the future",
+		Severity:    SeverityError,
+		check:       checkDate,
+	},
+	{
+		Name:        "period",
+		Description: "period_start and period_end are YYYY-MM-DD dates in order around date_issued, billing_interval is known and only set on recurring documents",
+		Severity:    SeverityWarning,
+		check:       checkPeriod,
+	},
+	{
+		Name:        "currency",
+		Description: "original_currency is an ISO 4217 currency code",
+		Severity:    SeverityError,
+		check:       checkCurrency,
+	},
+	{
+		Name:        "vat",
+		Description: "original_vat_amount matches a Swedish VAT rate on documents in SEK",
+		Severity:    SeverityWarning,
+		check:       checkVAT,
+	},
+	{
+		Name:        "sek_amount",
+		Description: "se_cent_amount agrees with original_amount and the exchange rate",
+		Severity:    SeverityError,
+		check:       checkSEKAmount,
+	},
+	{
+		Name:        "credit_note",
+		Description: "credit notes have negative amounts and reference an invoice, invoices and receipts positive amounts",
+		Severity:    SeverityWarning,
+		check:       checkCreditNote,
+	},
+	{
+		Name:        "cross_check",
+		Description: "extracted values do not conflict with rule-based pre-extraction",
+		Severity:    SeverityWarning,
+		check:       checkCrossChecks,
+	},
+	{
+		Name:        "ensemble",
+		Description: "the backends of an ensemble extraction agree well enough to skip review",
+		Severity:    SeverityWarning,
+		check:       checkEnsemble,
+	},
+}
+
+// requiredFields tells whether a field has a value, by JSON field name
+var requiredFields = map[string]func(info *interfaces.ReceiptInvoiceInfo) bool{
+	"description": func(info *interfaces.ReceiptInvoiceInfo) bool {
+		return strings.TrimSpace(info.Description) != ""
+	},
+	"company": func(info *interfaces.ReceiptInvoiceInfo) bool {
+		return strings.TrimSpace(document.StringValue(info.Company)) != ""
+	},
+	"date_issued": func(info *interfaces.ReceiptInvoiceInfo) bool {
+		return document.DateString(info) != ""
+	},
+	"period_start": func(info *interfaces.ReceiptInvoiceInfo) bool {
+		return strings.TrimSpace(document.StringValue(info.PeriodStart)) != ""
+	},
+	"period_end": func(info *interfaces.ReceiptInvoiceInfo) bool {
+		return strings.TrimSpace(document.StringValue(info.PeriodEnd)) != ""
+	},
+	"service_description": func(info *interfaces.ReceiptInvoiceInfo) bool {
+		return strings.TrimSpace(document.StringValue(info.ServiceDescription)) != ""
+	},
+	"se_cent_amount": func(info *interfaces.ReceiptInvoiceInfo) bool {
+		return info.SECentAmount != nil
+	},
+	"original_amount": func(info *interfaces.ReceiptInvoiceInfo) bool {
+		return info.OriginalAmount != nil
+	},
+	"original_currency": func(info *interfaces.ReceiptInvoiceInfo) bool {
+		return strings.TrimSpace(document.StringValue(info.OriginalCurrency)) != ""
+	},
+	"original_vat_amount": func(info *interfaces.ReceiptInvoiceInfo) bool {
+		return info.OriginalVatAmount != nil
+	},
+	"id_fields": func(info *interfaces.ReceiptInvoiceInfo) bool {
+		return len(info.IdFields) > 0
+	},
+}
+
+// idFieldPrefix selects an ID field by name in the required fields, e.g. "id:Invoice Number"
+const idFieldPrefix = "id:"
+
+// knownField reports whether name can be used as a required field
+func knownField(name string) bool {
+	if strings.HasPrefix(name, idFieldPrefix) {
+		return strings.TrimSpace(strings.TrimPrefix(name, idFieldPrefix)) != ""
+	}
+	_, ok := requiredFields[name]
+	return ok
+}
+
+// fieldNames returns the names usable as required fields, sorted
+func fieldNames() []string {
+	names := make([]string, 0, len(requiredFields))
+	for name := range requiredFields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// checkRequiredFields reports missing fields and unknown document types
+func checkRequiredFields(info *interfaces.ReceiptInvoiceInfo, opts *Options) []problem {
+	fields, ok := opts.RequiredFields[strings.ToLower(strings.TrimSpace(info.DocumentType))]
+	if !ok {
+		return []problem{{"document_type", fmt.Sprintf("unknown document type %q", info.DocumentType)}}
+	}
+
+	var problems []problem
+	for _, name := range fields {
+		if strings.HasPrefix(name, idFieldPrefix) {
+			idName := strings.TrimSpace(strings.TrimPrefix(name, idFieldPrefix))
+			if strings.TrimSpace(document.IdFieldValue(info, idName)) == "" {
+				problems = append(problems, problem{"id_fields", fmt.Sprintf("ID field %q is required for %s documents", idName, info.DocumentType)})
+			}
+			continue
+		}
+		if !requiredFields[name](info) {
+			problems = append(problems, problem{name, fmt.Sprintf("%s is required for %s documents", name, info.DocumentType)})
+		}
+	}
+	return problems
+}
+
+// checkDate reports issue dates that cannot be parsed or lie in the future
+func checkDate(info *interfaces.ReceiptInvoiceInfo, opts *Options) []problem {
+	date := document.DateString(info)
+	if date == "" {
+		return nil
+	}
+	t, ok := document.ParseDate(info)
+	if !ok {
+		return []problem{{"date_issued", fmt.Sprintf("%q is not a date in YYYY-MM-DD format", date)}}
+	}
+
+	today := time.Date(opts.Now.Year(), opts.Now.Month(), opts.Now.Day(), 0, 0, 0, 0, time.UTC)
+	if latest := today.AddDate(0, 0, opts.MaxFutureDays); t.After(latest) {
+		return []problem{{"date_issued", fmt.Sprintf("%s is in the future", date)}}
+	}
+	return nil
+}
+
+// checkPeriod reports service periods that cannot be parsed, end before they start or lie too far
+// from the issue date, and billing intervals that are unknown or given for one-off purchases
+func checkPeriod(info *interfaces.ReceiptInvoiceInfo, opts *Options) []problem {
+	var problems []problem
+	dates := make(map[string]time.Time)
+	for _, field := range []struct {
+		name string
+		date *string
+	}{{"period_start", info.PeriodStart}, {"period_end", info.PeriodEnd}} {
+		date := strings.TrimSpace(document.StringValue(field.date))
+		if date == "" {
+			continue
+		}
+		t, err := time.Parse(document.DateLayout, date)
+		if err != nil {
+			problems = append(problems, problem{field.name, fmt.Sprintf("%q is not a date in YYYY-MM-DD format", date)})
+			continue
+		}
+		dates[field.name] = t
+	}
+	if start, ok := dates["period_start"]; ok {
+		if end, ok := dates["period_end"]; ok && end.Before(start) {
+			problems = append(problems, problem{"period_end", fmt.Sprintf("period ends %s before it starts %s",
+				end.Format(document.DateLayout), start.Format(document.DateLayout))})
+		}
+	}
+	if issued, ok := document.ParseDate(info); ok && opts.PeriodToleranceDays != nil {
+		tolerance := *opts.PeriodToleranceDays
+		if start, ok := dates["period_start"]; ok && issued.Before(start.AddDate(0, 0, -tolerance)) {
+			problems = append(problems, problem{"date_issued", fmt.Sprintf("date %s is more than %d days before the service period starts %s",
+				issued.Format(document.DateLayout), tolerance, start.Format(document.DateLayout))})
+		}
+		if end, ok := dates["period_end"]; ok && issued.After(end.AddDate(0, 0, tolerance)) {
+			problems = append(problems, problem{"date_issued", fmt.Sprintf("date %s is more than %d days after the service period ends %s",
+				issued.Format(document.DateLayout), tolerance, end.Format(document.DateLayout))})
+		}
+	}
+
+	interval := document.BillingInterval(info)
+	if interval == "" {
+		return problems
+	}
+	if _, ok := document.BillingIntervals[interval]; !ok {
+		problems = append(problems, problem{"billing_interval", fmt.Sprintf("unknown billing interval %q (use %s, %s, %s or %s)", interval,
+			interfaces.BillingIntervalMonthly, interfaces.BillingIntervalQuarterly, interfaces.BillingIntervalSemiannual, interfaces.BillingIntervalYearly)})
+	}
+	if info.Recurring != nil && !*info.Recurring {
+		problems = append(problems, problem{"billing_interval", fmt.Sprintf("billing interval %q on a document that is not recurring", interval)})
+	}
+	return problems
+}
+
+// checkCurrency reports currencies that are not ISO 4217 codes
+func checkCurrency(info *interfaces.ReceiptInvoiceInfo, opts *Options) []problem {
+	if info.OriginalCurrency == nil || strings.TrimSpace(*info.OriginalCurrency) == "" {
+		return nil
+	}
+	currency := *info.OriginalCurrency
+	if !IsCurrency(strings.ToUpper(strings.TrimSpace(currency))) {
+		return []problem{{"original_currency", fmt.Sprintf("%q is not an ISO 4217 currency code", currency)}}
+	}
+	if currency != strings.ToUpper(currency) || currency != strings.TrimSpace(currency) {
+		return []problem{{"original_currency", fmt.Sprintf("%q should be written %q", currency, strings.ToUpper(strings.TrimSpace(currency)))}}
+	}
+	return nil
+}
+
+// checkVAT reports VAT amounts that do not fit the total
+// The rate is only checked on documents in SEK, foreign documents carry foreign VAT.
+func checkVAT(info *interfaces.ReceiptInvoiceInfo, opts *Options) []problem {
+	if info.OriginalVatAmount == nil || info.OriginalAmount == nil {
+		return nil
+	}
+	vat, total := *info.OriginalVatAmount, *info.OriginalAmount
+
+	// Credit notes have negative amounts, VAT and total must have the same sign
+	if vat != 0 && (vat < 0) != (total < 0) {
+		return []problem{{"original_vat_amount", fmt.Sprintf("VAT %.2f and total %.2f have different signs", vat, total)}}
+	}
+	vat, total = math.Abs(vat), math.Abs(total)
+	if vat >= total && vat > 0 {
+		return []problem{{"original_vat_amount", fmt.Sprintf("VAT %.2f is not less than the total %.2f", vat, total)}}
+	}
+
+	currency := strings.ToUpper(strings.TrimSpace(document.StringValue(info.OriginalCurrency)))
+	if currency != "" && currency != "SEK" || len(opts.VATRates) == 0 {
+		return nil
+	}
+
+	for _, rate := range opts.VATRates {
+		expected := total * rate / (100 + rate)
+		if math.Abs(vat-expected) <= vatRoundingSlack {
+			return nil
+		}
+	}
+	actual := vat / (total - vat) * 100
+	for _, rate := range opts.VATRates {
+		if math.Abs(actual-rate) <= opts.VATTolerance {
+			return nil
+		}
+	}
+	return []problem{{"original_vat_amount", fmt.Sprintf("VAT %.2f is %.1f%% of the net amount %.2f, expected one of %s",
+		vat, actual, total-vat, formatRates(opts.VATRates))}}
+}
+
+// checkSEKAmount reports SEK amounts that do not follow from the original amount
+func checkSEKAmount(info *interfaces.ReceiptInvoiceInfo, opts *Options) []problem {
+	if info.SECentAmount == nil || info.OriginalAmount == nil {
+		return nil
+	}
+	cents, amount := *info.SECentAmount, *info.OriginalAmount
+
+	currency := strings.ToUpper(strings.TrimSpace(document.StringValue(info.OriginalCurrency)))
+	if currency == "SEK" {
+		expected := int(math.Round(amount * 100))
+		if diff := cents - expected; diff > opts.SEKToleranceCents || -diff > opts.SEKToleranceCents {
+			return []problem{{"se_cent_amount", fmt.Sprintf("%d öre does not match the original amount %.2f SEK", cents, amount)}}
+		}
+		return nil
+	}
+
+	if amount == 0 {
+		if cents != 0 {
+			return []problem{{"se_cent_amount", fmt.Sprintf("%d öre for an original amount of 0", cents)}}
+		}
+		return nil
+	}
+	if cents == 0 || (cents < 0) != (amount < 0) {
+		return []problem{{"se_cent_amount", fmt.Sprintf("%d öre does not have the sign of the original amount %.2f", cents, amount)}}
+	}
+
+	expected, ok := opts.ExchangeRates[currency]
+	if !ok {
+		return nil
+	}
+	rate := float64(cents) / 100 / amount
+	if math.Abs(rate-expected)/expected*100 > opts.ExchangeRateTolerance {
+		return []problem{{"se_cent_amount", fmt.Sprintf("%d öre implies 1 %s = %.4f SEK, expected about %.4f SEK (±%g%%)",
+			cents, currency, rate, expected, opts.ExchangeRateTolerance)}}
+	}
+	return nil
+}
+
+// checkCreditNote reports credit notes with positive amounts or without the invoice they credit,
+// and invoices or receipts with a negative total, which are probably credit notes
+func checkCreditNote(info *interfaces.ReceiptInvoiceInfo, opts *Options) []problem {
+	total := info.OriginalAmount
+	if total == nil && info.SECentAmount != nil {
+		cents := float64(*info.SECentAmount) / 100
+		total = &cents
+	}
+
+	switch info.DocumentType {
+	case interfaces.DocumentTypeCreditNote:
+		var problems []problem
+		if total != nil && *total > 0 {
+			problems = append(problems, problem{"original_amount", fmt.Sprintf("credit note total %.2f is positive, credit notes have negative amounts", *total)})
+		}
+		if document.ReferencedInvoice(info) == "" {
+			problems = append(problems, problem{"id_fields", fmt.Sprintf("credit note has no ID field %q", interfaces.IdFieldReferencedInvoice)})
+		}
+		return problems
+	case interfaces.DocumentTypeInvoice, interfaces.DocumentTypeReceipt:
+		if total != nil && *total < 0 {
+			return []problem{{"document_type", fmt.Sprintf("%s total %.2f is negative, it is probably a CreditNote", info.DocumentType, *total)}}
+		}
+	}
+	return nil
+}
+
+// checkCrossChecks reports the conflicts recorded by the extract command
+func checkCrossChecks(info *interfaces.ReceiptInvoiceInfo, opts *Options) []problem {
+	var problems []problem
+	for _, check := range info.CrossChecks {
+		if check.Status == interfaces.CheckConflict {
+			problems = append(problems, problem{check.Field, fmt.Sprintf("%s %q is not among the values found in the document: %s",
+				check.Field, check.Value, strings.Join(check.Found, ", "))})
+		}
+	}
+	return problems
+}
+
+// checkEnsemble reports documents an ensemble extraction flagged for review, with the fields the backends disagree on
+func checkEnsemble(info *interfaces.ReceiptInvoiceInfo, opts *Options) []problem {
+	if info.Ensemble == nil || !info.Ensemble.NeedsReview {
+		return nil
+	}
+	var fields []string
+	for _, vote := range info.Ensemble.Votes {
+		if !vote.Unanimous {
+			fields = append(fields, vote.Field)
+		}
+	}
+	return []problem{{"ensemble", fmt.Sprintf("the %d backends disagree on %s, review the document", len(info.Ensemble.Backends), strings.Join(fields, ", "))}}
+}
+
+// formatRates formats rates as "25%, 12%, 6%, 0%"
+func formatRates(rates []float64) string {
+	parts := make([]string, len(rates))
+	for i, rate := range rates {
+		parts[i] = fmt.Sprintf("%g%%", rate)
+	}
+	return strings.Join(parts, ", ")
+}
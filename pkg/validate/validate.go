@@ -0,0 +1,254 @@
+package validate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+)
+
+// Severity decides how a finding of a rule is reported
+type Severity string
+
+// Severities, SeverityOff disables a rule
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityOff     Severity = "off"
+)
+
+// Defaults of Options
+const (
+	DefaultVATTolerance          = 0.5
+	DefaultSEKToleranceCents     = 1
+	DefaultExchangeRateTolerance = 10.0
+	DefaultPeriodToleranceDays   = 31
+)
+
+// DefaultVATRates are the Swedish VAT rates in percent
+var DefaultVATRates = []float64{25, 12, 6, 0}
+
+// DefaultRequiredFields are the fields each document type must have, see Options.RequiredFields
+var DefaultRequiredFields = map[string][]string{
+	"Invoice":             {"description", "company", "date_issued", "original_amount", "original_currency", "se_cent_amount", "id_fields"},
+	"Receipt":             {"description", "company", "date_issued", "original_amount", "original_currency", "se_cent_amount"},
+	"CreditNote":          {"description", "company", "date_issued", "original_amount", "original_currency", "se_cent_amount", "id_fields"},
+	"PaymentReminder":     {"description", "company", "date_issued", "original_amount", "original_currency", "id_fields"},
+	"OrderConfirmation":   {"description", "company", "date_issued"},
+	"Quote":               {"description", "company", "date_issued"},
+	"AccountStatement":    {"description", "company", "date_issued"},
+	"SubscriptionRenewal": {"description", "company", "date_issued"},
+	"None":                {"description"},
+}
+
+// Options configure a Validator, zero values select the defaults
+type Options struct {
+	// Severities overrides the default severity of rules by name
+	Severities map[string]Severity
+
+	// Now is the time dates are checked against, zero means the current time
+	Now time.Time
+
+	// MaxFutureDays is the number of days after Now an issue date may be
+	MaxFutureDays int
+
+	// PeriodToleranceDays is the number of days an issue date may lie before or after the service
+	// period, for documents issued in advance or in arrears. nil means DefaultPeriodToleranceDays.
+	PeriodToleranceDays *int
+
+	// VATRates are the plausible VAT rates in percent of the net amount
+	VATRates []float64
+
+	// VATTolerance is the allowed deviation from a VAT rate in percentage points
+	VATTolerance float64
+
+	// SEKToleranceCents is the allowed difference between the SEK amount and the original
+	// amount of documents in SEK, in öre
+	SEKToleranceCents int
+
+	// ExchangeRates are the expected SEK per unit of foreign currencies, e.g. "EUR": 11.2
+	// The SEK amount of documents in other currencies is only checked for its sign.
+	ExchangeRates map[string]float64
+
+	// ExchangeRateTolerance is the allowed deviation from ExchangeRates in percent
+	ExchangeRateTolerance float64
+
+	// RequiredFields lists the fields each document type must have, by JSON field name or
+	// as "id:<name>" for an ID field. Types are matched case-insensitively and replace the
+	// entries of DefaultRequiredFields.
+	RequiredFields map[string][]string
+}
+
+// Finding is one problem found in a document
+type Finding struct {
+	File     string   `json:"file"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Field    string   `json:"field,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// Result is the outcome of validating a set of documents
+type Result struct {
+	Files    int       `json:"files"`
+	Errors   int       `json:"errors"`
+	Warnings int       `json:"warnings"`
+	Findings []Finding `json:"findings"`
+}
+
+// Failed reports whether the result has findings of at least the given severity
+// SeverityOff never fails.
+func (r *Result) Failed(failOn Severity) bool {
+	switch failOn {
+	case SeverityError:
+		return r.Errors > 0
+	case SeverityWarning:
+		return r.Errors > 0 || r.Warnings > 0
+	}
+	return false
+}
+
+// Validator checks documents with the enabled rules
+type Validator struct {
+	opts  Options
+	rules []activeRule
+}
+
+// activeRule is an enabled rule with its effective severity
+type activeRule struct {
+	Rule
+	severity Severity
+}
+
+// New returns a Validator, or an error if the options name unknown rules, severities or fields
+func New(opts Options) (*Validator, error) {
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.VATRates == nil {
+		opts.VATRates = DefaultVATRates
+	}
+	if opts.VATTolerance <= 0 {
+		opts.VATTolerance = DefaultVATTolerance
+	}
+	if opts.SEKToleranceCents <= 0 {
+		opts.SEKToleranceCents = DefaultSEKToleranceCents
+	}
+	if opts.ExchangeRateTolerance <= 0 {
+		opts.ExchangeRateTolerance = DefaultExchangeRateTolerance
+	}
+	if opts.PeriodToleranceDays == nil {
+		days := DefaultPeriodToleranceDays
+		opts.PeriodToleranceDays = &days
+	} else if *opts.PeriodToleranceDays < 0 {
+		return nil, fmt.Errorf("period tolerance must not be negative, got %d days", *opts.PeriodToleranceDays)
+	}
+
+	required := make(map[string][]string)
+	for docType, fields := range DefaultRequiredFields {
+		required[strings.ToLower(docType)] = fields
+	}
+	for docType, fields := range opts.RequiredFields {
+		for _, name := range fields {
+			if !knownField(name) {
+				return nil, fmt.Errorf("unknown required field %q for %s (available: %s, id:<name>)", name, docType, strings.Join(fieldNames(), ", "))
+			}
+		}
+		required[strings.ToLower(docType)] = fields
+	}
+	opts.RequiredFields = required
+
+	rates := make(map[string]float64, len(opts.ExchangeRates))
+	for currency, rate := range opts.ExchangeRates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("exchange rate of %s must be positive, got %g", currency, rate)
+		}
+		rates[strings.ToUpper(currency)] = rate
+	}
+	opts.ExchangeRates = rates
+
+	for name, severity := range opts.Severities {
+		if _, ok := ruleByName(name); !ok {
+			return nil, fmt.Errorf("unknown rule %q (available: %s)", name, strings.Join(RuleNames(), ", "))
+		}
+		if _, err := ParseSeverity(string(severity)); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", name, err)
+		}
+	}
+
+	v := &Validator{opts: opts}
+	for _, rule := range Rules {
+		severity := rule.Severity
+		if s, ok := opts.Severities[rule.Name]; ok {
+			severity, _ = ParseSeverity(string(s))
+		}
+		if severity != SeverityOff {
+			v.rules = append(v.rules, activeRule{Rule: rule, severity: severity})
+		}
+	}
+	return v, nil
+}
+
+// ParseSeverity parses "error", "warning" or "off" (case-insensitive)
+func ParseSeverity(s string) (Severity, error) {
+	switch severity := Severity(strings.ToLower(strings.TrimSpace(s))); severity {
+	case SeverityError, SeverityWarning, SeverityOff:
+		return severity, nil
+	}
+	return "", fmt.Errorf("invalid severity %q, use error, warning or off", s)
+}
+
+// Validate checks one document
+func (v *Validator) Validate(doc document.Document) []Finding {
+	var findings []Finding
+	for _, rule := range v.rules {
+		for _, p := range rule.check(doc.Info, &v.opts) {
+			findings = append(findings, Finding{
+				File:     doc.Path,
+				Rule:     rule.Name,
+				Severity: rule.severity,
+				Field:    p.field,
+				Message:  p.message,
+			})
+		}
+	}
+	return findings
+}
+
+// ValidateAll checks every document, findings keep the order of docs and Rules
+func (v *Validator) ValidateAll(docs []document.Document) *Result {
+	result := &Result{Files: len(docs), Findings: []Finding{}}
+	for _, doc := range docs {
+		for _, finding := range v.Validate(doc) {
+			if finding.Severity == SeverityError {
+				result.Errors++
+			} else {
+				result.Warnings++
+			}
+			result.Findings = append(result.Findings, finding)
+		}
+	}
+	return result
+}
+
+// RuleNames returns the names of all rules, sorted
+func RuleNames() []string {
+	names := make([]string, 0, len(Rules))
+	for _, rule := range Rules {
+		names = append(names, rule.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ruleByName returns the rule with the given name
+func ruleByName(name string) (Rule, bool) {
+	for _, rule := range Rules {
+		if rule.Name == name {
+			return rule, true
+		}
+	}
+	return Rule{}, false
+}
@@ -0,0 +1,205 @@
+package validate
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+func str(s string) *string { return &s }
+
+func num(f float64) *float64 { return &f }
+
+func cents(c int) *int { return &c }
+
+func flag(b bool) *bool { return &b }
+
+// invoice returns an invoice that passes every rule
+func invoice() *interfaces.ReceiptInvoiceInfo {
+	return &interfaces.ReceiptInvoiceInfo{
+		DocumentType:      interfaces.DocumentTypeInvoice,
+		Description:       "Software",
+		Company:           str("Fortnox AB"),
+		DateIssued:        str("2025-08-02"),
+		OriginalAmount:    num(1250),
+		OriginalVatAmount: num(250),
+		OriginalCurrency:  str("SEK"),
+		SECentAmount:      cents(125000),
+		IdFields:          []interfaces.IdField{{Name: "Invoice Number", Value: "F-1001"}},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(info *interfaces.ReceiptInvoiceInfo)
+		want   []string // rule/field of the findings
+	}{
+		{"valid invoice", func(info *interfaces.ReceiptInvoiceInfo) {}, nil},
+		{"missing company", func(info *interfaces.ReceiptInvoiceInfo) { info.Company = nil }, []string{"required_fields/company"}},
+		{"unknown document type", func(info *interfaces.ReceiptInvoiceInfo) { info.DocumentType = "Letter" }, []string{"required_fields/document_type"}},
+		{"receipt needs no ID", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.DocumentType = interfaces.DocumentTypeReceipt
+			info.IdFields = nil
+		}, nil},
+		{"invalid date", func(info *interfaces.ReceiptInvoiceInfo) { info.DateIssued = str("02/08/2025") }, []string{"date/date_issued"}},
+		{"future date", func(info *interfaces.ReceiptInvoiceInfo) { info.DateIssued = str("2025-09-02") }, []string{"date/date_issued"}},
+		{"period in order", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.PeriodStart, info.PeriodEnd = str("2025-08-02"), str("2025-09-01")
+		}, nil},
+		{"period ends before it starts", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.PeriodStart, info.PeriodEnd = str("2025-08-02"), str("2025-07-15")
+		}, []string{"period/period_end"}},
+		{"invalid period date", func(info *interfaces.ReceiptInvoiceInfo) { info.PeriodStart = str("Aug 2") }, []string{"period/period_start"}},
+		{"issued long before the period", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.PeriodStart, info.PeriodEnd = str("2025-10-01"), str("2026-09-30")
+		}, []string{"period/date_issued"}},
+		{"issued long after the period", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.PeriodStart, info.PeriodEnd = str("2025-01-01"), str("2025-06-30")
+		}, []string{"period/date_issued"}},
+		{"unknown billing interval", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.Recurring, info.BillingInterval = flag(true), str("weekly")
+		}, []string{"period/billing_interval"}},
+		{"billing interval of a one-off purchase", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.Recurring, info.BillingInterval = flag(false), str(interfaces.BillingIntervalMonthly)
+		}, []string{"period/billing_interval"}},
+		{"unknown currency", func(info *interfaces.ReceiptInvoiceInfo) { info.OriginalCurrency = str("XYZ") }, []string{"currency/original_currency"}},
+		{"lower case currency", func(info *interfaces.ReceiptInvoiceInfo) { info.OriginalCurrency = str("sek") }, []string{"currency/original_currency"}},
+		{"12 percent VAT", func(info *interfaces.ReceiptInvoiceInfo) { info.OriginalVatAmount = num(133.93) }, nil},
+		{"implausible VAT rate", func(info *interfaces.ReceiptInvoiceInfo) { info.OriginalVatAmount = num(200) }, []string{"vat/original_vat_amount"}},
+		{"VAT not less than the total", func(info *interfaces.ReceiptInvoiceInfo) { info.OriginalVatAmount = num(1250) }, []string{"vat/original_vat_amount"}},
+		{"foreign VAT rate is not checked", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.OriginalCurrency, info.OriginalAmount, info.OriginalVatAmount = str("EUR"), num(95.37), num(19.07)
+			info.SECentAmount = cents(109677)
+		}, nil},
+		{"SEK amount off by more than an öre", func(info *interfaces.ReceiptInvoiceInfo) { info.SECentAmount = cents(125002) }, []string{"sek_amount/se_cent_amount"}},
+		{"SEK amount within an öre", func(info *interfaces.ReceiptInvoiceInfo) { info.SECentAmount = cents(125001) }, nil},
+		{"exchange rate far off", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.OriginalCurrency, info.OriginalAmount, info.OriginalVatAmount = str("EUR"), num(95.37), num(19.07)
+			info.SECentAmount = cents(9537)
+		}, []string{"sek_amount/se_cent_amount"}},
+		{"SEK amount with the wrong sign", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.OriginalCurrency, info.OriginalAmount, info.OriginalVatAmount = str("USD"), num(20), num(0)
+			info.SECentAmount = cents(-21000)
+		}, []string{"sek_amount/se_cent_amount"}},
+		{"credit note", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.DocumentType = interfaces.DocumentTypeCreditNote
+			info.OriginalAmount, info.OriginalVatAmount, info.SECentAmount = num(-1250), num(-250), cents(-125000)
+			info.IdFields = append(info.IdFields, interfaces.IdField{Name: interfaces.IdFieldReferencedInvoice, Value: "F-1000"})
+		}, nil},
+		{"positive credit note without reference", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.DocumentType = interfaces.DocumentTypeCreditNote
+		}, []string{"credit_note/original_amount", "credit_note/id_fields"}},
+		{"negative invoice", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.OriginalAmount, info.OriginalVatAmount, info.SECentAmount = num(-1250), num(-250), cents(-125000)
+		}, []string{"credit_note/document_type"}},
+		{"VAT and total with different signs", func(info *interfaces.ReceiptInvoiceInfo) { info.OriginalVatAmount = num(-250) }, []string{"vat/original_vat_amount"}},
+		{"cross-check conflict", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.CrossChecks = []interfaces.FieldCheck{
+				{Field: "date_issued", Status: interfaces.CheckConflict, Value: "2025-08-02", Found: []string{"2025-08-03"}},
+				{Field: "original_amount", Status: interfaces.CheckAgree},
+			}
+		}, []string{"cross_check/date_issued"}},
+		{"ensemble needs review", func(info *interfaces.ReceiptInvoiceInfo) {
+			info.Ensemble = &interfaces.EnsembleResult{Backends: []string{"a", "b"}, NeedsReview: true,
+				Votes: []interfaces.FieldVote{{Field: "company", Unanimous: true}, {Field: "date_issued"}}}
+		}, []string{"ensemble/ensemble"}},
+	}
+
+	validator, err := New(Options{Now: time.Date(2025, 8, 20, 12, 0, 0, 0, time.UTC), ExchangeRates: map[string]float64{"EUR": 11.5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := invoice()
+			tt.modify(info)
+			var got []string
+			for _, finding := range validator.Validate(document.Document{Path: "doc.json", Info: info}) {
+				got = append(got, finding.Rule+"/"+finding.Field)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSeverities(t *testing.T) {
+	docs := []document.Document{{Path: "doc.json", Info: &interfaces.ReceiptInvoiceInfo{
+		DocumentType:      interfaces.DocumentTypeReceipt,
+		Description:       "Software",
+		Company:           str("Fortnox AB"),
+		DateIssued:        str("2025-08-02"),
+		OriginalAmount:    num(100),
+		OriginalVatAmount: num(50),
+		OriginalCurrency:  str("sek"),
+		SECentAmount:      cents(10000),
+	}}}
+
+	tests := []struct {
+		name       string
+		severities map[string]Severity
+		errors     int
+		warnings   int
+	}{
+		{"defaults", nil, 1, 1},
+		{"rule turned off", map[string]Severity{"vat": SeverityOff}, 1, 0},
+		{"warning raised to error", map[string]Severity{"vat": "ERROR"}, 2, 0},
+		{"error lowered to warning", map[string]Severity{"currency": SeverityWarning}, 0, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator, err := New(Options{Severities: tt.severities})
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := validator.ValidateAll(docs)
+			if result.Errors != tt.errors || result.Warnings != tt.warnings {
+				t.Errorf("got %d errors and %d warnings, want %d and %d: %+v", result.Errors, result.Warnings, tt.errors, tt.warnings, result.Findings)
+			}
+			if result.Failed(SeverityError) != (tt.errors > 0) || result.Failed(SeverityWarning) != (tt.errors+tt.warnings > 0) || result.Failed(SeverityOff) {
+				t.Errorf("Failed does not follow the counts")
+			}
+		})
+	}
+}
+
+func TestNewRejectsInvalidOptions(t *testing.T) {
+	negative := -1
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"unknown rule", Options{Severities: map[string]Severity{"spelling": SeverityError}}},
+		{"invalid severity", Options{Severities: map[string]Severity{"vat": "fatal"}}},
+		{"negative period tolerance", Options{PeriodToleranceDays: &negative}},
+		{"unknown required field", Options{RequiredFields: map[string][]string{"Invoice": {"amount"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.opts); err == nil {
+				t.Errorf("New accepted %+v", tt.opts)
+			}
+		})
+	}
+}
+
+func TestRequiredFieldsOverride(t *testing.T) {
+	validator, err := New(Options{RequiredFields: map[string][]string{"invoice": {"company", "id:Order Number"}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	info := invoice()
+	info.Description = ""
+	var got []string
+	for _, finding := range validator.Validate(document.Document{Info: info}) {
+		got = append(got, finding.Rule+"/"+finding.Field)
+	}
+	if want := []string{"required_fields/id_fields"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("findings = %v, want %v", got, want)
+	}
+}
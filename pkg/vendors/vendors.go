@@ -0,0 +1,334 @@
+package vendors
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/accounting"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/naming"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/validate"
+	"gopkg.in/yaml.v3"
+)
+
+// orgNumberDigits is the length of a Swedish organisation number
+const orgNumberDigits = 10
+
+// orgNumberWords are the words of ID field names that carry an organisation number
+var orgNumberWords = []string{"org", "organisation", "organization", "corporate id", "registration"}
+
+// Vendor is the profile of a company we buy from
+type Vendor struct {
+	// Name is the normalised company name written to extracted documents
+	Name string `yaml:"name"`
+
+	// Aliases are other spellings of the name found in documents
+	Aliases []string `yaml:"aliases,omitempty"`
+
+	// OrgNumber is the organisation number, e.g. "556677-8899"
+	OrgNumber string `yaml:"org_number,omitempty"`
+
+	// Category replaces the Description category of the vendor's documents
+	Category string `yaml:"category,omitempty"`
+
+	// Account is the BAS expense account of the vendor's documents, 0 uses the category mapping
+	Account int `yaml:"account,omitempty"`
+
+	// Currency fills in the currency of documents without one
+	Currency string `yaml:"currency,omitempty"`
+
+	// VAT is the VAT treatment of the vendor's documents (domestic, foreign, reverse_charge, none),
+	// empty lets the currency decide
+	VAT string `yaml:"vat,omitempty"`
+}
+
+// Registry is the list of known vendors, stored as YAML
+type Registry struct {
+	path    string
+	Vendors []*Vendor `yaml:"vendors"`
+}
+
+// Load reads a registry file, a missing file yields an empty registry
+func Load(path string) (*Registry, error) {
+	registry := &Registry{path: path}
+	content, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return registry, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vendor registry %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(content, registry); err != nil {
+		return nil, fmt.Errorf("failed to parse vendor registry %s: %w", path, err)
+	}
+	for _, vendor := range registry.Vendors {
+		if err := Check(vendor); err != nil {
+			return nil, fmt.Errorf("vendor registry %s: %w", path, err)
+		}
+	}
+	return registry, nil
+}
+
+// Save writes the registry back to its file, vendors sorted by name
+func (r *Registry) Save() error {
+	sort.SliceStable(r.Vendors, func(i, j int) bool {
+		return strings.ToLower(r.Vendors[i].Name) < strings.ToLower(r.Vendors[j].Name)
+	})
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(r); err != nil {
+		return fmt.Errorf("failed to marshal vendor registry: %w", err)
+	}
+	if dir := filepath.Dir(r.path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("failed to create directory of vendor registry: %w", err)
+		}
+	}
+	if err := os.WriteFile(r.path, buf.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write vendor registry %s: %w", r.path, err)
+	}
+	return nil
+}
+
+// Path returns the file of the registry
+func (r *Registry) Path() string {
+	return r.path
+}
+
+// Check returns an error if a vendor has no name or an invalid currency or VAT treatment
+func Check(vendor *Vendor) error {
+	if strings.TrimSpace(vendor.Name) == "" {
+		return fmt.Errorf("vendor without name")
+	}
+	if vendor.Currency != "" && !validate.IsCurrency(vendor.Currency) {
+		return fmt.Errorf("vendor %s: unknown currency %q", vendor.Name, vendor.Currency)
+	}
+	switch vendor.VAT {
+	case "", accounting.VatDomestic, accounting.VatForeign, accounting.VatReverseCharge, accounting.VatNone:
+	default:
+		return fmt.Errorf("vendor %s: unknown VAT treatment %q (use %s, %s, %s or %s)", vendor.Name, vendor.VAT,
+			accounting.VatDomestic, accounting.VatForeign, accounting.VatReverseCharge, accounting.VatNone)
+	}
+	if vendor.OrgNumber != "" && len(onlyDigits(vendor.OrgNumber)) < orgNumberDigits {
+		return fmt.Errorf("vendor %s: organisation number %q has less than %d digits", vendor.Name, vendor.OrgNumber, orgNumberDigits)
+	}
+	return nil
+}
+
+// Find returns the vendor with the given name or alias, ignoring case, punctuation and legal forms
+func (r *Registry) Find(name string) *Vendor {
+	key := NameKey(name)
+	if key == "" {
+		return nil
+	}
+	for _, vendor := range r.Vendors {
+		if NameKey(vendor.Name) == key {
+			return vendor
+		}
+	}
+	for _, vendor := range r.Vendors {
+		for _, alias := range vendor.Aliases {
+			if NameKey(alias) == key {
+				return vendor
+			}
+		}
+	}
+	return nil
+}
+
+// Add adds a vendor, the name and aliases must not belong to another vendor
+func (r *Registry) Add(vendor *Vendor) error {
+	if err := Check(vendor); err != nil {
+		return err
+	}
+	for _, name := range append([]string{vendor.Name}, vendor.Aliases...) {
+		if existing := r.Find(name); existing != nil && existing != vendor {
+			return fmt.Errorf("%q is already registered as vendor %s", name, existing.Name)
+		}
+	}
+	r.Vendors = append(r.Vendors, vendor)
+	return nil
+}
+
+// Match returns the vendor of a document and how it was matched
+// Organisation numbers in the ID fields take precedence over the company name.
+func (r *Registry) Match(info *interfaces.ReceiptInvoiceInfo) (*Vendor, string) {
+	for _, digits := range orgNumbers(info) {
+		for _, vendor := range r.Vendors {
+			if vendor.OrgNumber != "" && strings.Contains(digits, onlyDigits(vendor.OrgNumber)) {
+				return vendor, interfaces.MatchedByOrgNumber
+			}
+		}
+	}
+
+	key := NameKey(document.StringValue(info.Company))
+	if key == "" {
+		return nil, ""
+	}
+	for _, vendor := range r.Vendors {
+		if NameKey(vendor.Name) == key {
+			return vendor, interfaces.MatchedByName
+		}
+	}
+	for _, vendor := range r.Vendors {
+		for _, alias := range vendor.Aliases {
+			if NameKey(alias) == key {
+				return vendor, interfaces.MatchedByAlias
+			}
+		}
+	}
+	return nil, ""
+}
+
+// Apply matches a document to its vendor and normalises it: the company becomes the vendor
+// name, the Description category is replaced by the vendor category and a missing currency
+// is filled in. The match is recorded in info.Vendor, nil is returned if no vendor matched.
+// The category only applies to bookable documents, the Description of other types tells what
+// the document is about and is kept.
+func (r *Registry) Apply(info *interfaces.ReceiptInvoiceInfo) *interfaces.VendorMatch {
+	vendor, matchedBy := r.Match(info)
+	if vendor == nil {
+		return nil
+	}
+
+	match := &interfaces.VendorMatch{
+		Name:         vendor.Name,
+		MatchedBy:    matchedBy,
+		Account:      vendor.Account,
+		VatTreatment: vendor.VAT,
+	}
+	if company := document.StringValue(info.Company); company != vendor.Name {
+		name := vendor.Name
+		info.Company = &name
+		match.Changes = append(match.Changes, interfaces.FieldChange{Field: "company", From: company, To: name})
+	}
+	if vendor.Category != "" && info.Description != vendor.Category && document.Bookable(info) {
+		match.Changes = append(match.Changes, interfaces.FieldChange{Field: "description", From: info.Description, To: vendor.Category})
+		info.Description = vendor.Category
+	}
+	if vendor.Currency != "" && strings.TrimSpace(document.StringValue(info.OriginalCurrency)) == "" {
+		currency := vendor.Currency
+		info.OriginalCurrency = &currency
+		match.Changes = append(match.Changes, interfaces.FieldChange{Field: "original_currency", To: currency})
+	}
+	info.Vendor = match
+	return match
+}
+
+// Learn records the vendor of an accepted document
+// An unknown company is added as new vendor with the category, currency and organisation number of
+// the document. For a known vendor, empty fields are filled in and a new spelling of the name is
+// added as alias; values already registered are never changed. It returns the vendor and whether
+// the registry changed.
+func (r *Registry) Learn(info *interfaces.ReceiptInvoiceInfo) (*Vendor, bool) {
+	if !document.Bookable(info) {
+		return nil, false
+	}
+	company := strings.TrimSpace(document.StringValue(info.Company))
+	if NameKey(company) == "" {
+		return nil, false
+	}
+	currency := strings.ToUpper(strings.TrimSpace(document.StringValue(info.OriginalCurrency)))
+	if !validate.IsCurrency(currency) {
+		currency = ""
+	}
+	orgNumber := ""
+	if numbers := orgNumbers(info); len(numbers) > 0 {
+		orgNumber = formatOrgNumber(numbers[0])
+	}
+
+	vendor, _ := r.Match(info)
+	if vendor == nil {
+		vendor = &Vendor{
+			Name:      naming.StripCompanySuffixes(company, nil),
+			OrgNumber: orgNumber,
+			Category:  strings.TrimSpace(info.Description),
+			Currency:  currency,
+		}
+		r.Vendors = append(r.Vendors, vendor)
+		return vendor, true
+	}
+
+	changed := false
+	if r.Find(company) == nil {
+		vendor.Aliases = append(vendor.Aliases, company)
+		changed = true
+	}
+	if vendor.OrgNumber == "" && orgNumber != "" {
+		vendor.OrgNumber = orgNumber
+		changed = true
+	}
+	if vendor.Category == "" && strings.TrimSpace(info.Description) != "" {
+		vendor.Category = strings.TrimSpace(info.Description)
+		changed = true
+	}
+	if vendor.Currency == "" && currency != "" {
+		vendor.Currency = currency
+		changed = true
+	}
+	return vendor, changed
+}
+
+// orgNumbers returns the digits of the ID fields that carry an organisation number, including
+// Swedish VAT numbers (SE + organisation number + 01)
+func orgNumbers(info *interfaces.ReceiptInvoiceInfo) []string {
+	var numbers []string
+	for _, idField := range info.IdFields {
+		digits := onlyDigits(idField.Value)
+		if len(digits) < orgNumberDigits {
+			continue
+		}
+		name := strings.ToLower(idField.Name)
+		if strings.Contains(name, "vat") || strings.Contains(name, "moms") {
+			if strings.HasPrefix(strings.ToUpper(strings.TrimSpace(idField.Value)), "SE") {
+				numbers = append(numbers, digits)
+			}
+			continue
+		}
+		for _, word := range orgNumberWords {
+			if strings.Contains(name, word) {
+				numbers = append(numbers, digits)
+				break
+			}
+		}
+	}
+	return numbers
+}
+
+// formatOrgNumber writes the organisation number in digits as NNNNNN-NNNN
+// The digits of a VAT number are cut to the organisation number.
+func formatOrgNumber(digits string) string {
+	digits = digits[:orgNumberDigits]
+	return digits[:6] + "-" + digits[6:]
+}
+
+// NameKey returns the lower case letters and digits of a company name without its legal form,
+// names with the same key belong to the same vendor
+func NameKey(name string) string {
+	name = naming.StripCompanySuffixes(strings.TrimSpace(name), nil)
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// onlyDigits returns the digits of s
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,213 @@
+package vendors
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+)
+
+func str(s string) *string { return &s }
+
+// registry returns a registry with a Swedish and a foreign vendor
+func registry() *Registry {
+	return &Registry{Vendors: []*Vendor{
+		{Name: "Fortnox", OrgNumber: "556469-6291", Category: "Accounting Software", Account: 5420, VAT: "domestic"},
+		{Name: "Anthropic", Aliases: []string{"Anthropic Ireland Limited"}, Category: "AI Services", Currency: "USD"},
+	}}
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		info      interfaces.ReceiptInvoiceInfo
+		vendor    string
+		matchedBy string
+	}{
+		{"name with legal form", interfaces.ReceiptInvoiceInfo{Company: str("ANTHROPIC, PBC")}, "Anthropic", interfaces.MatchedByName},
+		{"alias", interfaces.ReceiptInvoiceInfo{Company: str("Anthropic Ireland Ltd.")}, "Anthropic", interfaces.MatchedByAlias},
+		{"org number wins over the name", interfaces.ReceiptInvoiceInfo{
+			Company:  str("Anthropic"),
+			IdFields: []interfaces.IdField{{Name: "Org.nr", Value: "556469-6291"}},
+		}, "Fortnox", interfaces.MatchedByOrgNumber},
+		{"swedish VAT number", interfaces.ReceiptInvoiceInfo{
+			IdFields: []interfaces.IdField{{Name: "VAT Number", Value: "SE556469629101"}},
+		}, "Fortnox", interfaces.MatchedByOrgNumber},
+		{"foreign VAT number is not an org number", interfaces.ReceiptInvoiceInfo{
+			IdFields: []interfaces.IdField{{Name: "VAT Number", Value: "IE5564696291"}},
+		}, "", ""},
+		{"invoice number with the same digits", interfaces.ReceiptInvoiceInfo{
+			IdFields: []interfaces.IdField{{Name: "Invoice Number", Value: "5564696291"}},
+		}, "", ""},
+		{"unknown company", interfaces.ReceiptInvoiceInfo{Company: str("OpenAI")}, "", ""},
+		{"no company", interfaces.ReceiptInvoiceInfo{}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vendor, matchedBy := registry().Match(&tt.info)
+			name := ""
+			if vendor != nil {
+				name = vendor.Name
+			}
+			if name != tt.vendor || matchedBy != tt.matchedBy {
+				t.Errorf("matched %q by %q, want %q by %q", name, matchedBy, tt.vendor, tt.matchedBy)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		info        interfaces.ReceiptInvoiceInfo
+		want        *interfaces.VendorMatch
+		description string
+	}{
+		{
+			name: "normalised invoice",
+			info: interfaces.ReceiptInvoiceInfo{DocumentType: interfaces.DocumentTypeInvoice, Description: "Software", Company: str("Anthropic, PBC")},
+			want: &interfaces.VendorMatch{Name: "Anthropic", MatchedBy: interfaces.MatchedByName, Changes: []interfaces.FieldChange{
+				{Field: "company", From: "Anthropic, PBC", To: "Anthropic"},
+				{Field: "description", From: "Software", To: "AI Services"},
+				{Field: "original_currency", To: "USD"},
+			}},
+			description: "AI Services",
+		},
+		{
+			name: "account and VAT treatment",
+			info: interfaces.ReceiptInvoiceInfo{
+				DocumentType:     interfaces.DocumentTypeReceipt,
+				Description:      "Accounting Software",
+				Company:          str("Fortnox"),
+				OriginalCurrency: str("SEK"),
+			},
+			want:        &interfaces.VendorMatch{Name: "Fortnox", MatchedBy: interfaces.MatchedByName, Account: 5420, VatTreatment: "domestic"},
+			description: "Accounting Software",
+		},
+		{
+			name:        "category kept for other documents",
+			info:        interfaces.ReceiptInvoiceInfo{DocumentType: interfaces.DocumentTypeNone, Description: "Security notification", Company: str("Anthropic"), OriginalCurrency: str("EUR")},
+			want:        &interfaces.VendorMatch{Name: "Anthropic", MatchedBy: interfaces.MatchedByName},
+			description: "Security notification",
+		},
+		{
+			name:        "no match",
+			info:        interfaces.ReceiptInvoiceInfo{DocumentType: interfaces.DocumentTypeInvoice, Description: "Software", Company: str("OpenAI")},
+			description: "Software",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := registry().Apply(&tt.info)
+			if !reflect.DeepEqual(match, tt.want) {
+				t.Errorf("match = %+v, want %+v", match, tt.want)
+			}
+			if tt.info.Vendor != match {
+				t.Errorf("match is not recorded in the document")
+			}
+			if tt.info.Description != tt.description {
+				t.Errorf("description = %q, want %q", tt.info.Description, tt.description)
+			}
+		})
+	}
+}
+
+func TestLearn(t *testing.T) {
+	tests := []struct {
+		name    string
+		info    interfaces.ReceiptInvoiceInfo
+		want    *Vendor
+		changed bool
+	}{
+		{
+			name: "new vendor",
+			info: interfaces.ReceiptInvoiceInfo{
+				DocumentType:     interfaces.DocumentTypeInvoice,
+				Description:      "Web Hosting",
+				Company:          str("Hosting AB"),
+				OriginalCurrency: str("sek"),
+				IdFields:         []interfaces.IdField{{Name: "Seller VAT Number", Value: "SE556123456701"}},
+			},
+			want:    &Vendor{Name: "Hosting", OrgNumber: "556123-4567", Category: "Web Hosting", Currency: "SEK"},
+			changed: true,
+		},
+		{
+			name:    "new spelling becomes an alias",
+			info:    interfaces.ReceiptInvoiceInfo{DocumentType: interfaces.DocumentTypeInvoice, Description: "Software", IdFields: []interfaces.IdField{{Name: "Org nr", Value: "5564696291"}}, Company: str("Fortnox Finans")},
+			want:    &Vendor{Name: "Fortnox", Aliases: []string{"Fortnox Finans"}, OrgNumber: "556469-6291", Category: "Accounting Software", Account: 5420, VAT: "domestic"},
+			changed: true,
+		},
+		{
+			name:    "known vendor is not changed",
+			info:    interfaces.ReceiptInvoiceInfo{DocumentType: interfaces.DocumentTypeReceipt, Description: "Software", Company: str("Anthropic, PBC"), OriginalCurrency: str("EUR")},
+			want:    &Vendor{Name: "Anthropic", Aliases: []string{"Anthropic Ireland Limited"}, Category: "AI Services", Currency: "USD"},
+			changed: false,
+		},
+		{
+			name: "documents that are not bookable",
+			info: interfaces.ReceiptInvoiceInfo{DocumentType: interfaces.DocumentTypeNone, Company: str("Hosting AB")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			vendor, changed := registry().Learn(&tt.info)
+			if !reflect.DeepEqual(vendor, tt.want) || changed != tt.changed {
+				t.Errorf("learned %+v (changed %v), want %+v (changed %v)", vendor, changed, tt.want, tt.changed)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		vendor Vendor
+		valid  bool
+	}{
+		{"valid", Vendor{Name: "Fortnox", OrgNumber: "556469-6291", Currency: "SEK", VAT: "reverse_charge"}, true},
+		{"no name", Vendor{Name: " "}, false},
+		{"unknown currency", Vendor{Name: "Fortnox", Currency: "KRONOR"}, false},
+		{"unknown VAT treatment", Vendor{Name: "Fortnox", VAT: "exempt"}, false},
+		{"short org number", Vendor{Name: "Fortnox", OrgNumber: "556469"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Check(&tt.vendor); (err == nil) != tt.valid {
+				t.Errorf("Check = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestAddRejectsKnownNames(t *testing.T) {
+	r := registry()
+	if err := r.Add(&Vendor{Name: "Anthropic Ireland Ltd"}); err == nil {
+		t.Error("expected an error for a registered alias")
+	}
+	if err := r.Add(&Vendor{Name: "OpenAI", Aliases: []string{"OpenAI, LLC"}}); err != nil {
+		t.Errorf("Add = %v", err)
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config", "vendors.yaml")
+	missing, err := Load(path)
+	if err != nil || len(missing.Vendors) != 0 {
+		t.Fatalf("Load of a missing file = %v, %v", missing, err)
+	}
+
+	saved := registry()
+	saved.path = path
+	if err := saved.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Save sorts the vendors by name
+	if !reflect.DeepEqual(loaded.Vendors, saved.Vendors) || loaded.Vendors[0].Name != "Anthropic" {
+		t.Errorf("loaded %+v, want %+v", loaded.Vendors, saved.Vendors)
+	}
+}
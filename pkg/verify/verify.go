@@ -0,0 +1,143 @@
+package verify
+
+import (
+	"fmt"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/document"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/evidence"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/heuristics"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/validate"
+)
+
+// DefaultMaxRounds is the number of correction requests made when none is configured
+const DefaultMaxRounds = 1
+
+// Options configure the self-verification
+type Options struct {
+	// MaxRounds is the maximum number of correction requests per document, 0 disables them
+	MaxRounds int
+
+	// Validator finds the problems of an extraction
+	Validator *validate.Validator
+
+	// Trigger is the lowest severity of validate findings that counts as a problem
+	Trigger validate.Severity
+}
+
+// Verifier checks AI extractions and asks the provider to correct those with problems
+type Verifier struct {
+	opts Options
+}
+
+// New creates a Verifier
+func New(opts Options) (*Verifier, error) {
+	if opts.MaxRounds < 0 {
+		return nil, fmt.Errorf("max_rounds must not be negative, got %d", opts.MaxRounds)
+	}
+	if opts.Validator == nil {
+		return nil, fmt.Errorf("a validator is required")
+	}
+	switch opts.Trigger {
+	case "":
+		opts.Trigger = validate.SeverityWarning
+	case validate.SeverityError, validate.SeverityWarning:
+	default:
+		return nil, fmt.Errorf("invalid trigger severity %q, use error or warning", opts.Trigger)
+	}
+	return &Verifier{opts: opts}, nil
+}
+
+// Annotate verifies the evidence of an extraction against the source text and records its
+// cross-check with the rule-based findings
+func Annotate(info *interfaces.ReceiptInvoiceInfo, text string, findings *heuristics.Findings) {
+	evidence.Verify(info, text)
+	info.CrossChecks = heuristics.Compare(findings, info)
+}
+
+// Problems returns the problems of an annotated extraction, as sentences for the provider
+// Validate findings at or above the trigger severity are problems, which includes conflicts with
+// the rule-based findings, as are evidence snippets that are not in the source text.
+func (v *Verifier) Problems(info *interfaces.ReceiptInvoiceInfo) []string {
+	var problems []string
+	for _, finding := range v.opts.Validator.Validate(document.Document{Info: info}) {
+		if finding.Severity == validate.SeverityError || v.opts.Trigger == validate.SeverityWarning && finding.Severity == validate.SeverityWarning {
+			problems = append(problems, finding.Message)
+		}
+	}
+	for _, e := range info.Evidence {
+		if e.Note == evidence.NoteSnippetNotFound {
+			problems = append(problems, fmt.Sprintf("the snippet %q given for %s is not in the document", e.Snippet, e.Field))
+		}
+	}
+	return problems
+}
+
+// Run asks the provider to correct an annotated extraction until it has no problems or the
+// maximum number of rounds is reached
+// The result with the fewest problems is returned, the latest one if several tie. If any round was
+// made, all extractions are recorded in its Verification. A failed correction request ends the
+// rounds, the extractions made so far are kept and the error is recorded.
+func (v *Verifier) Run(provider interfaces.AIProvider, text string, findings *heuristics.Findings, info *interfaces.ReceiptInvoiceInfo, log interfaces.Logger) (*interfaces.ReceiptInvoiceInfo, error) {
+	problems := v.Problems(info)
+	if len(problems) == 0 {
+		log.Info("Self-verification found no problems")
+		return info, nil
+	}
+	logProblems(problems, log)
+
+	if v.opts.MaxRounds == 0 {
+		log.Debug("Self-verification rounds are disabled")
+		return info, nil
+	}
+	corrector, ok := provider.(interfaces.CorrectingAIProvider)
+	if !ok {
+		log.Warn("The AI provider cannot correct extractions, keeping the result")
+		return info, nil
+	}
+
+	verification := &interfaces.Verification{Rounds: []interfaces.VerificationRound{{Problems: problems, Result: info}}}
+	for round := 1; round <= v.opts.MaxRounds && len(problems) > 0; round++ {
+		log.Info("Self-verification round %d of %d: asking the AI provider to correct %d problem(s)", round, v.opts.MaxRounds, len(problems))
+		previous := verification.Rounds[len(verification.Rounds)-1].Result
+
+		corrected, err := corrector.CorrectReceiptInvoiceInfo(text, previous, problems)
+		if err != nil {
+			log.Warn("Self-verification round %d failed, keeping the extractions so far: %v", round, err)
+			verification.Error = fmt.Sprintf("round %d: %v", round, err)
+			break
+		}
+		corrected.Source = info.Source
+		document.NegateCreditNote(corrected)
+		Annotate(corrected, text, findings)
+
+		problems = v.Problems(corrected)
+		verification.Rounds = append(verification.Rounds, interfaces.VerificationRound{Problems: problems, Result: corrected})
+		if len(problems) > 0 {
+			logProblems(problems, log)
+		} else {
+			log.Info("Self-verification round %d resolved all problems", round)
+		}
+	}
+
+	for i, r := range verification.Rounds {
+		if len(r.Problems) <= len(verification.Rounds[verification.Selected].Problems) {
+			verification.Selected = i
+		}
+	}
+	if left := len(verification.Rounds[verification.Selected].Problems); left > 0 {
+		log.Warn("%d problem(s) remain after self-verification, using the extraction of round %d", left, verification.Selected)
+	}
+
+	selected := *verification.Rounds[verification.Selected].Result
+	selected.Verification = verification
+	return &selected, nil
+}
+
+// logProblems warns about the problems of an extraction
+func logProblems(problems []string, log interfaces.Logger) {
+	log.Warn("Self-verification found %d problem(s):", len(problems))
+	for _, problem := range problems {
+		log.Warn("  %s", problem)
+	}
+}
@@ -0,0 +1,184 @@
+package verify
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/heuristics"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/interfaces"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/logger"
+	"github.com/scalebit-com/reciept-invoice-ai-tool/pkg/validate"
+)
+
+const text = "Fortnox AB\nFaktura F-1001\nDatum 2025-08-02\nMoms 250,00 SEK\nAtt betala 1 250,00 SEK"
+
+func str(s string) *string { return &s }
+
+func num(f float64) *float64 { return &f }
+
+func cents(c int) *int { return &c }
+
+// invoice returns the correct extraction of text
+func invoice() *interfaces.ReceiptInvoiceInfo {
+	return &interfaces.ReceiptInvoiceInfo{
+		DocumentType:      interfaces.DocumentTypeInvoice,
+		Description:       "Software",
+		Company:           str("Fortnox AB"),
+		DateIssued:        str("2025-08-02"),
+		OriginalAmount:    num(1250),
+		OriginalVatAmount: num(250),
+		OriginalCurrency:  str("SEK"),
+		SECentAmount:      cents(125000),
+		IdFields:          []interfaces.IdField{{Name: "Invoice Number", Value: "F-1001"}},
+	}
+}
+
+// withoutCompany returns an extraction with one problem
+func withoutCompany() *interfaces.ReceiptInvoiceInfo {
+	info := invoice()
+	info.Company = nil
+	return info
+}
+
+// corrector answers correction requests with the given results in order
+type corrector struct {
+	results []*interfaces.ReceiptInvoiceInfo
+	err     error
+	calls   int
+}
+
+func (c *corrector) GetReceiptInvoiceInfo(content string) (*interfaces.ReceiptInvoiceInfo, error) {
+	return nil, fmt.Errorf("not implemented")
+}
+
+func (c *corrector) CorrectReceiptInvoiceInfo(content string, previous *interfaces.ReceiptInvoiceInfo, problems []string) (*interfaces.ReceiptInvoiceInfo, error) {
+	c.calls++
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.results[c.calls-1], nil
+}
+
+// extractor cannot correct extractions
+type extractor struct{}
+
+func (extractor) GetReceiptInvoiceInfo(content string) (*interfaces.ReceiptInvoiceInfo, error) {
+	return invoice(), nil
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name      string
+		maxRounds int
+		provider  interfaces.AIProvider
+		info      *interfaces.ReceiptInvoiceInfo
+		company   string
+		rounds    int // number of recorded extractions, 0 for none
+		selected  int
+		failed    bool
+	}{
+		{"no problems", 1, &corrector{}, invoice(), "Fortnox AB", 0, 0, false},
+		{"problem corrected", 2, &corrector{results: []*interfaces.ReceiptInvoiceInfo{invoice()}}, withoutCompany(), "Fortnox AB", 2, 1, false},
+		{"correction gets worse", 1, &corrector{results: []*interfaces.ReceiptInvoiceInfo{{DocumentType: interfaces.DocumentTypeInvoice}}}, withoutCompany(), "", 2, 0, false},
+		{"second round ties with the first", 2, &corrector{results: []*interfaces.ReceiptInvoiceInfo{withoutCompany(), withoutCompany()}}, withoutCompany(), "", 3, 2, false},
+		{"correction fails", 1, &corrector{err: fmt.Errorf("timeout")}, withoutCompany(), "", 1, 0, true},
+		{"rounds disabled", 0, &corrector{}, withoutCompany(), "", 0, 0, false},
+		{"provider cannot correct", 1, extractor{}, withoutCompany(), "", 0, 0, false},
+	}
+
+	validator, err := validate.New(validate.Options{Now: time.Date(2025, 8, 20, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier, err := New(Options{MaxRounds: tt.maxRounds, Validator: validator})
+			if err != nil {
+				t.Fatal(err)
+			}
+			findings := heuristics.Extract(text)
+			Annotate(tt.info, text, findings)
+
+			result, err := verifier.Run(tt.provider, text, findings, tt.info, logger.NewColorLoggerTo(io.Discard))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if company := derefString(result.Company); company != tt.company {
+				t.Errorf("company = %q, want %q", company, tt.company)
+			}
+			if tt.rounds == 0 {
+				if result.Verification != nil {
+					t.Errorf("verification = %+v, want none", result.Verification)
+				}
+				return
+			}
+			if result.Verification == nil {
+				t.Fatal("verification was not recorded")
+			}
+			if len(result.Verification.Rounds) != tt.rounds || result.Verification.Selected != tt.selected || (result.Verification.Error != "") != tt.failed {
+				t.Errorf("got %d round(s), selected %d, error %q, want %d, %d and failed %v", len(result.Verification.Rounds),
+					result.Verification.Selected, result.Verification.Error, tt.rounds, tt.selected, tt.failed)
+			}
+		})
+	}
+}
+
+func TestProblems(t *testing.T) {
+	validator, err := validate.New(validate.Options{Now: time.Date(2025, 8, 20, 0, 0, 0, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := invoice()
+	info.OriginalVatAmount = num(200) // an implausible VAT rate is a warning
+	info.Evidence = []interfaces.FieldEvidence{{Field: "company", Snippet: "Fortnox Finans AB"}}
+	Annotate(info, text, heuristics.Extract(text))
+
+	tests := []struct {
+		trigger validate.Severity
+		want    int
+	}{
+		{validate.SeverityWarning, 3}, // VAT rate, VAT cross-check and snippet
+		{validate.SeverityError, 1},   // snippet
+	}
+	for _, tt := range tests {
+		verifier, err := New(Options{MaxRounds: 1, Validator: validator, Trigger: tt.trigger})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if problems := verifier.Problems(info); len(problems) != tt.want {
+			t.Errorf("trigger %s: problems %q, want %d", tt.trigger, problems, tt.want)
+		}
+	}
+}
+
+func TestNewRejectsInvalidOptions(t *testing.T) {
+	validator, err := validate.New(validate.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		opts Options
+	}{
+		{"negative rounds", Options{MaxRounds: -1, Validator: validator}},
+		{"no validator", Options{MaxRounds: 1}},
+		{"trigger off", Options{MaxRounds: 1, Validator: validator, Trigger: validate.SeverityOff}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := New(tt.opts); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}